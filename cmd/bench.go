@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pierow2k/nogocomments/pkg/commentremover"
+	"github.com/spf13/cobra"
+)
+
+// bytesPerMB is the number of bytes in a megabyte, as throughput is given.
+const bytesPerMB = 1e6
+
+var (
+	// errInvalidRuns is returned when the --runs flag of the bench command
+	// is not positive.
+	errInvalidRuns = errors.New("invalid number of runs (want a positive number)")
+
+	// errInvalidOptionSet is returned when a --set value of the bench command
+	// is not a JSON object of settings.
+	errInvalidOptionSet = errors.New("invalid option set")
+)
+
+// benchLong is the long description of the bench command. It doubles as a
+// message catalog key.
+const benchLong = `bench removes the comments from the Go files under the given files and
+directories several times over, without changing anything, and reports the
+throughput of each engine and option set. Directories are walked like the
+root command walks them. Each --set is a JSON object of settings, keyed by
+long flag name as in the configuration, such as '{"keep-doc": true}'.`
+
+// benchCmd measures the throughput of comment removal.
+var benchCmd = &cobra.Command{
+	Use:   "bench [INPUT_FILE|DIR...]",
+	Short: "Measure how fast comments are removed from Go source code.",
+	Long:  benchLong,
+	Args:  cobra.ArbitraryArgs,
+	RunE:  runBench,
+}
+
+// init registers the bench command and its flags.
+func init() {
+	benchCmd.Flags().IntVar(&cfg.benchRuns, "runs", 3, "Number of times to process every file")
+	benchCmd.Flags().StringSliceVar(&cfg.benchEngines, "engines",
+		[]string{commentremover.EngineAST.String(), commentremover.EngineScanner.String()},
+		"Engines to measure: auto, ast, or scanner")
+	benchCmd.Flags().StringArrayVar(&cfg.benchSets, "set", nil,
+		"Measure with the settings of this JSON object (repeatable; default options if not given)")
+	benchCmd.Flags().StringVar(&cfg.format, "format", formatText, "Output format: text or json")
+	_ = benchCmd.Flags().MarkDeprecated("format", "use --report-format instead")
+	rootCmd.AddCommand(benchCmd)
+}
+
+// benchColumns are the columns of the bench command in the csv and
+// markdown formats.
+var benchColumns = []string{"engine", "options", "files", "bytes", "runs", "failed", "seconds", "MB/s", "files/s"}
+
+// benchResult is the throughput of one engine with one option set.
+type benchResult struct {
+	Engine         string  `json:"engine"`
+	Options        string  `json:"options"`
+	Files          int     `json:"files"`
+	Bytes          int     `json:"bytes"`
+	Runs           int     `json:"runs"`
+	Failed         int     `json:"failed"`
+	Seconds        float64 `json:"seconds"`
+	MBPerSecond    float64 `json:"mbPerSecond"`
+	FilesPerSecond float64 `json:"filesPerSecond"`
+}
+
+// runBench implements the bench command. It reads the selected files once
+// and then, for every option set and engine, removes their comments --runs
+// times, printing the average throughput. Files that fail to process count
+// toward the time taken and are reported as failed.
+//
+// Errors are returned in the following cases:
+//   - The report format is not recognized
+//   - --runs is not positive
+//   - An engine is not auto, ast, or scanner
+//   - A --set value is not a JSON object of settings that an override may
+//     hold, or its settings are invalid
+//   - An input directory cannot be walked or holds no Go files
+//   - Reading a file fails
+//   - The context of the command is done before all runs are finished
+func runBench(command *cobra.Command, args []string) error {
+	if err := validateReportFormat(); err != nil {
+		return err
+	}
+
+	if cfg.benchRuns < 1 {
+		return fmt.Errorf("%w: %d", errInvalidRuns, cfg.benchRuns)
+	}
+
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+
+	inputPaths, err := expandInputs(args)
+	if err != nil {
+		return err
+	}
+
+	sources := make([]string, len(inputPaths))
+
+	for i, inputPath := range inputPaths {
+		if sources[i], err = readFileString(inputPath); err != nil {
+			return fmt.Errorf("file read failed: %w", err)
+		}
+	}
+
+	sets := cfg.benchSets
+	if len(sets) == 0 {
+		sets = []string{"{}"}
+	}
+
+	engines, runs := cfg.benchEngines, cfg.benchRuns
+
+	var results []benchResult
+
+	for _, set := range sets {
+		for _, engine := range engines {
+			opts, err := benchOptions(command, set, engine)
+			if err != nil {
+				return err
+			}
+
+			result, err := measure(command, sources, runs, opts)
+			if err != nil {
+				return err
+			}
+
+			result.Engine, result.Options = engine, set
+			results = append(results, result)
+		}
+	}
+
+	return printBench(command, results)
+}
+
+// benchOptions returns the comment remover options for engine with the
+// settings of set, a JSON object, applied to the default options.
+// The global configuration is left unchanged.
+func benchOptions(command *cobra.Command, set, engine string) ([]commentremover.Option, error) {
+	var settings map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(set), &settings); err != nil {
+		return nil, fmt.Errorf("%w: %q: %w", errInvalidOptionSet, set, err)
+	}
+
+	saved := cfg
+	defer func() { cfg = saved }()
+
+	if err := applySettings(command.Root().Flags(), settings, "--set", overridable); err != nil {
+		return nil, err
+	}
+
+	cfg.engine = engine
+
+	return removerOptions()
+}
+
+// measure removes the comments from each of sources runs times with opts
+// and returns the average throughput.
+func measure(
+	command *cobra.Command, sources []string, runs int, opts []commentremover.Option,
+) (benchResult, error) {
+	result := benchResult{Files: len(sources), Runs: runs}
+
+	for _, source := range sources {
+		result.Bytes += len(source)
+	}
+
+	start := time.Now()
+
+	for run := range runs {
+		if err := command.Context().Err(); err != nil {
+			return benchResult{}, fmt.Errorf("%w: %w", errStopped, err)
+		}
+
+		for _, source := range sources {
+			if _, err := commentremover.Process(source, opts...); err != nil && run == 0 {
+				result.Failed++
+			}
+		}
+	}
+
+	result.Seconds = time.Since(start).Seconds() / float64(runs)
+	if result.Seconds > 0 {
+		result.MBPerSecond = float64(result.Bytes) / bytesPerMB / result.Seconds
+		result.FilesPerSecond = float64(result.Files) / result.Seconds
+	}
+
+	return result, nil
+}
+
+// printBench prints results to standard output in the --report-format.
+func printBench(command *cobra.Command, results []benchResult) error {
+	switch cfg.format {
+	case formatJSON:
+		return writeJSON(command.OutOrStdout(), results)
+	case formatCSV, formatMarkdown:
+		rows := make([][]string, 0, len(results))
+		for _, result := range results {
+			rows = append(rows, []string{
+				result.Engine, result.Options, strconv.Itoa(result.Files), strconv.Itoa(result.Bytes),
+				strconv.Itoa(result.Runs), strconv.Itoa(result.Failed),
+				strconv.FormatFloat(result.Seconds, 'f', 4, 64), strconv.FormatFloat(result.MBPerSecond, 'f', 2, 64),
+				strconv.FormatFloat(result.FilesPerSecond, 'f', 1, 64),
+			})
+		}
+
+		return writeTable(command.OutOrStdout(), benchColumns, rows)
+	}
+
+	var out strings.Builder
+
+	_, _ = fmt.Fprintf(&out, "%-8s %10s %10s %8s  %s\n",
+		tr("ENGINE"), tr("MB/S"), tr("FILES/S"), tr("FAILED"), tr("OPTIONS"))
+
+	for _, result := range results {
+		_, _ = fmt.Fprintf(&out, "%-8s %10.2f %10.1f %8d  %s\n",
+			result.Engine, result.MBPerSecond, result.FilesPerSecond, result.Failed, result.Options)
+	}
+
+	_, err := fmt.Fprint(command.OutOrStdout(), out.String())
+
+	return err //nolint:wrapcheck // Writing to standard output.
+}
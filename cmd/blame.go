@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pierow2k/nogocomments/pkg/commentremover"
+)
+
+var (
+	// errInvalidAge is returned when --older-than is neither an age such as
+	// "2y" nor a date.
+	errInvalidAge = errors.New("invalid age (want a number with unit y, m, w, or d, or a YYYY-MM-DD date)")
+
+	// errInvalidAuthor is returned when --author is not a valid regular
+	// expression.
+	errInvalidAuthor = errors.New("invalid author pattern")
+
+	// errBlameNeedsFile is returned when --older-than or --author is used
+	// with input that does not come from a file.
+	errBlameNeedsFile = errors.New("--older-than and --author require an input file")
+
+	// errBlame is returned when git blame cannot annotate an input file, such
+	// as when it is not tracked in a git work tree.
+	errBlame = errors.New("git blame failed")
+)
+
+// uncommitted is the commit that git blame reports for lines changed in the
+// work tree but not yet committed.
+const uncommitted = "0000000000000000000000000000000000000000"
+
+// blameFilter selects comments by the commit that last changed them, as
+// reported by git blame. The newest line of a comment decides: its commit
+// must predate cutoff, if set, and its author must match author, if set.
+// Uncommitted lines never match.
+type blameFilter struct {
+	cutoff time.Time      // cutoff, if set, selects lines last changed before it.
+	author *regexp.Regexp // author, if set, selects lines whose author, as "Name <email>", matches it.
+}
+
+// blameLine is the blame annotation of a single source line.
+type blameLine struct {
+	commit string    // commit is the hash of the commit that last changed the line.
+	author string    // author is the author of the commit, as "Name <email>".
+	time   time.Time // time is the author time of the commit.
+}
+
+// newBlameFilter returns the filter described by --older-than and
+// --author, measuring ages back from now, or nil if neither is given.
+func newBlameFilter(now time.Time) (*blameFilter, error) {
+	if cfg.olderThan == "" && cfg.author == "" {
+		return nil, nil //nolint:nilnil // No filter is requested.
+	}
+
+	filter := &blameFilter{}
+
+	if cfg.olderThan != "" {
+		cutoff, err := parseAge(cfg.olderThan, now)
+		if err != nil {
+			return nil, err
+		}
+
+		filter.cutoff = cutoff
+	}
+
+	if cfg.author != "" {
+		author, err := regexp.Compile(cfg.author)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %w", errInvalidAuthor, cfg.author, err)
+		}
+
+		filter.author = author
+	}
+
+	return filter, nil
+}
+
+// parseAge converts an --older-than value to the time it denotes: an age
+// such as "2y", "6m", "3w", or "10d" counted back from now, or a date in
+// the form YYYY-MM-DD.
+func parseAge(text string, now time.Time) (time.Time, error) {
+	if date, err := time.ParseInLocation(time.DateOnly, text, now.Location()); err == nil {
+		return date, nil
+	}
+
+	count, err := strconv.Atoi(text[:len(text)-1])
+	if err != nil || count < 0 {
+		return time.Time{}, fmt.Errorf("%w: %q", errInvalidAge, text)
+	}
+
+	switch text[len(text)-1] {
+	case 'y':
+		return now.AddDate(-count, 0, 0), nil
+	case 'm':
+		return now.AddDate(0, -count, 0), nil
+	case 'w':
+		return now.AddDate(0, 0, -7*count), nil
+	case 'd':
+		return now.AddDate(0, 0, -count), nil
+	default:
+		return time.Time{}, fmt.Errorf("%w: %q", errInvalidAge, text)
+	}
+}
+
+// option returns a comment remover option restricting removal to the
+// comments of the file at inputPath that filter selects.
+func (filter *blameFilter) option(inputPath string) (commentremover.Option, error) {
+	lines, err := gitBlame(inputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return commentremover.RemoveIf(func(first, last int) bool {
+		newest, ok := blameLine{}, false
+
+		for line := first; line <= last && line <= len(lines); line++ {
+			if !ok || lines[line-1].time.After(newest.time) {
+				newest, ok = lines[line-1], true
+			}
+		}
+
+		return ok && filter.selects(newest)
+	}), nil
+}
+
+// selects reports whether filter selects the line annotated by line.
+func (filter *blameFilter) selects(line blameLine) bool {
+	switch {
+	case line.commit == uncommitted:
+		return false
+	case !filter.cutoff.IsZero() && !line.time.Before(filter.cutoff):
+		return false
+	case filter.author != nil && !filter.author.MatchString(line.author):
+		return false
+	default:
+		return true
+	}
+}
+
+// gitBlame annotates each line of the file at path with the commit that
+// last changed it.
+func gitBlame(path string) ([]blameLine, error) {
+	gitCmd := exec.Command("git", "-C", filepath.Dir(path), "blame", "--line-porcelain", "--", filepath.Base(path))
+
+	var stderr bytes.Buffer
+
+	gitCmd.Stderr = &stderr
+
+	release := acquireFile()
+	out, err := gitCmd.Output()
+
+	release()
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", errBlame, path, strings.TrimSpace(stderr.String()))
+	}
+
+	return parseBlame(out), nil
+}
+
+// parseBlame reads the --line-porcelain output of git blame. Each line of
+// the file is described by a header naming its commit, followed by
+// key-value lines about the commit and by the line itself after a tab.
+func parseBlame(out []byte) []blameLine {
+	var (
+		lines   []blameLine
+		current blameLine
+		name    string
+		header  = true
+	)
+
+	for text := range strings.SplitSeq(string(out), "\n") {
+		key, value, _ := strings.Cut(text, " ")
+
+		switch {
+		case strings.HasPrefix(text, "\t"):
+			lines = append(lines, current)
+			current, header = blameLine{}, true
+		case header:
+			current.commit, header = key, false
+		case key == "author":
+			name = value
+		case key == "author-mail":
+			current.author = name + " " + value
+		case key == "author-time":
+			seconds, _ := strconv.ParseInt(value, 10, 64)
+			current.time = time.Unix(seconds, 0)
+		}
+	}
+
+	return lines
+}
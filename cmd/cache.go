@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+
+	"github.com/pierow2k/nogocomments/pkg/commentremover"
+	"github.com/spf13/pflag"
+)
+
+// cacheDirMode is the permission of the cache directory.
+const cacheDirMode = 0o700
+
+// resultCache keeps the results of removing comments in a directory, one
+// file per input, keyed by the content of the input and the options of the
+// run, so that a later run over unchanged files need not process them
+// again. The cache is best effort: an entry that cannot be read or written
+// is treated as missing.
+type resultCache struct {
+	dir         string // dir is the directory holding the entries.
+	fingerprint string // fingerprint identifies the build of the tool and the options of the run.
+}
+
+// newResultCache returns the cache in dir for runs with the options of
+// flags. Flags that only select inputs and destinations are left out of
+// the key, so that, say, a run with --silent fills the cache for a run
+// with --write. --verify is kept in, since a result is only verified once.
+func newResultCache(dir string, flags *pflag.FlagSet) *resultCache {
+	ignored := func(name string) bool {
+		return presentationFlags[name] || runFlags[name] && name != "verify"
+	}
+
+	return &resultCache{
+		dir:         dir,
+		fingerprint: Version + "\n" + BuildDate + "\n" + buildIdentity() + "\n" + settingsFingerprint(flags, ignored),
+	}
+}
+
+// buildIdentity identifies the code of the running binary, since Version
+// and BuildDate are placeholders in all but release builds: the commit it
+// was built from, if the build recorded one and the work tree was clean,
+// or else a hash of the executable. It is empty if neither can be had.
+var buildIdentity = sync.OnceValue(func() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		var revision, modified string
+
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				revision = setting.Value
+			case "vcs.modified":
+				modified = setting.Value
+			}
+		}
+
+		if revision != "" && modified == "false" {
+			return "vcs:" + revision
+		}
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+
+	file, err := os.Open(executable)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return ""
+	}
+
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil))
+})
+
+// entryPath returns the file holding the entry for sourceCode, the content
+// of the input at inputPath. Which path-specific overrides apply depends on
+// the path, so it is part of the key if there are any.
+func (cache *resultCache) entryPath(inputPath, sourceCode string) string {
+	hash := sha256.New()
+	_, _ = hash.Write([]byte(cache.fingerprint + "\n"))
+
+	if len(overrides) > 0 {
+		_, _ = hash.Write([]byte(slashPath(inputPath) + "\n"))
+	}
+
+	_, _ = hash.Write([]byte(sourceCode))
+
+	return filepath.Join(cache.dir, hex.EncodeToString(hash.Sum(nil))+".json")
+}
+
+// load returns the cached result for sourceCode, the content of the input
+// at inputPath, if there is one.
+func (cache *resultCache) load(inputPath, sourceCode string) (commentremover.Result, bool) {
+	entryPath := cache.entryPath(inputPath, sourceCode)
+
+	release := acquireFile()
+	data, err := os.ReadFile(entryPath)
+
+	release()
+
+	if err != nil {
+		return commentremover.Result{}, false
+	}
+
+	var result commentremover.Result
+	if err := json.Unmarshal(data, &result); err != nil {
+		return commentremover.Result{}, false
+	}
+
+	return result, true
+}
+
+// store records result as the result for sourceCode, the content of the
+// input at inputPath. The entry is written to a temporary file that is
+// then renamed, so a concurrent or interrupted run never sees a partial
+// entry.
+func (cache *resultCache) store(inputPath, sourceCode string, result commentremover.Result) {
+	data, err := json.Marshal(result)
+	if err != nil || os.MkdirAll(cache.dir, cacheDirMode) != nil {
+		return
+	}
+
+	defer acquireFile()()
+
+	temp, err := os.CreateTemp(cache.dir, "entry-*")
+	if err != nil {
+		return
+	}
+
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(temp.Name(), cache.entryPath(inputPath, sourceCode))
+	}
+
+	if err != nil {
+		_ = os.Remove(temp.Name())
+	}
+}
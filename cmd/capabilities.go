@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// capabilitiesSchema is the version of the capabilities document. It is
+// incremented whenever a field changes meaning or is removed; new fields may
+// be added without changing it.
+const capabilitiesSchema = 1
+
+// capabilitiesLong is the long description of the capabilities command. It
+// doubles as a message catalog key.
+const capabilitiesLong = `capabilities describes the commands, options, output formats, protocols,
+and message languages this build supports. Wrappers and editor plugins can
+read it with --json to detect features instead of parsing version strings.`
+
+// capabilitiesCmd describes what the tool supports.
+var capabilitiesCmd = &cobra.Command{
+	Use:   "capabilities",
+	Short: "Describe the supported options, formats, and protocols.",
+	Long:  capabilitiesLong,
+	Args:  cobra.NoArgs,
+	RunE:  runCapabilities,
+}
+
+// init registers the capabilities command and its flags.
+func init() {
+	capabilitiesCmd.Flags().BoolVar(&cfg.capabilitiesJSON, "json", false, "Print the capabilities as JSON")
+	rootCmd.AddCommand(capabilitiesCmd)
+}
+
+// capabilities is the document printed by the capabilities command.
+type capabilities struct {
+	Schema    int                 `json:"schema"`
+	Version   string              `json:"version"`
+	Commands  []commandCapability `json:"commands"`
+	Formats   map[string][]string `json:"formats"`
+	Protocols map[string]int      `json:"protocols"`
+	Languages []string            `json:"languages"`
+}
+
+// commandCapability lists the options of a command. The options of the
+// root command include the global options accepted by every command.
+type commandCapability struct {
+	Name    string             `json:"name"`
+	Options []optionCapability `json:"options"`
+}
+
+// optionCapability describes a command-line option by its long name, its
+// shorthand letter, if any, the pflag type of its value, and its default.
+type optionCapability struct {
+	Name      string `json:"name"`
+	Shorthand string `json:"shorthand,omitempty"`
+	Type      string `json:"type"`
+	Default   string `json:"default"`
+}
+
+// describeCapabilities returns the capabilities of the tool, with the
+// commands of root listed in order below it.
+func describeCapabilities(root *cobra.Command) capabilities {
+	caps := capabilities{
+		Schema:  capabilitiesSchema,
+		Version: Version,
+		Formats: map[string][]string{
+			root.Name():        reportFormats,
+			statsCmd.Name():    reportFormats,
+			benchCmd.Name():    reportFormats,
+			licensesCmd.Name(): reportFormats,
+			checkCmd.Name():    reportFormats,
+		},
+		Protocols: map[string]int{"serve-stdio": serveProtocolVersion},
+	}
+
+	for _, command := range append([]*cobra.Command{root}, root.Commands()...) {
+		if !command.IsAvailableCommand() && command != root {
+			continue
+		}
+
+		commandCaps := commandCapability{Name: command.Name(), Options: []optionCapability{}}
+
+		command.NonInheritedFlags().VisitAll(func(flag *pflag.Flag) {
+			commandCaps.Options = append(commandCaps.Options, optionCapability{
+				Name:      flag.Name,
+				Shorthand: flag.Shorthand,
+				Type:      flag.Value.Type(),
+				Default:   flag.DefValue,
+			})
+		})
+
+		caps.Commands = append(caps.Commands, commandCaps)
+	}
+
+	for _, tag := range supportedLanguages {
+		caps.Languages = append(caps.Languages, tag.String())
+	}
+
+	return caps
+}
+
+// runCapabilities implements the capabilities command.
+func runCapabilities(command *cobra.Command, _ []string) error {
+	caps := describeCapabilities(command.Root())
+
+	if cfg.capabilitiesJSON {
+		encoder := json.NewEncoder(command.OutOrStdout())
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(caps) //nolint:wrapcheck // Writing to standard output.
+	}
+
+	var out strings.Builder
+
+	_, _ = fmt.Fprintf(&out, "%s %s\n", tr("Version:"), caps.Version)
+
+	for _, commandCaps := range caps.Commands {
+		names := make([]string, 0, len(commandCaps.Options))
+		for _, option := range commandCaps.Options {
+			names = append(names, "--"+option.Name)
+		}
+
+		_, _ = fmt.Fprintf(&out, "%s %s: %s\n", tr("Command:"), commandCaps.Name, strings.Join(names, " "))
+	}
+
+	reporters := []string{command.Root().Name(), statsCmd.Name(), benchCmd.Name(), licensesCmd.Name(), checkCmd.Name()}
+	for _, name := range reporters {
+		_, _ = fmt.Fprintf(&out, "%s %s: %s\n", tr("Formats:"), name, strings.Join(caps.Formats[name], " "))
+	}
+	_, _ = fmt.Fprintf(&out, "%s serve-stdio/%d\n", tr("Protocols:"), caps.Protocols["serve-stdio"])
+	_, _ = fmt.Fprintf(&out, "%s %s\n", tr("Languages:"), strings.Join(caps.Languages, " "))
+	_, err := fmt.Fprint(command.OutOrStdout(), out.String())
+
+	return err //nolint:wrapcheck // Writing to standard output.
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pierow2k/nogocomments/pkg/commentremover"
+	"github.com/spf13/cobra"
+)
+
+// errCommentsFound is returned by the check command when input files hold
+// comments that the root command would remove.
+var errCommentsFound = errors.New("comments found in some files")
+
+// checkColumns are the columns of the check command in the csv and
+// markdown formats.
+var checkColumns = []string{"path", "line", "column", "comment"}
+
+// checkLong is the long description of the check command. It doubles as a
+// message catalog key.
+const checkLong = `check lists the comments in the Go source files under the given files and
+directories that the root command would remove, without changing anything,
+and fails if there are any, to keep generated code and vendored mirrors
+free of comments. Directives and license headers are allowed, the latter
+unless --strip-license-header is given. Directories are walked like the
+root command walks them.`
+
+// checkCmd fails if comments are present.
+var checkCmd = &cobra.Command{
+	Use:   "check [INPUT_FILE|DIR...]",
+	Short: "Fail if Go source code holds comments.",
+	Long:  checkLong,
+	Args:  cobra.ArbitraryArgs,
+	RunE:  runCheck,
+}
+
+// init registers the check command and its flags.
+func init() {
+	checkCmd.Flags().BoolVar(&cfg.stripLicenseHeader, "strip-license-header", false,
+		"Report a leading copyright or license header as well")
+	rootCmd.AddCommand(checkCmd)
+}
+
+// commentLocation is a comment found by the check command.
+type commentLocation struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Comment string `json:"comment"` // Comment is the first line of the comment.
+}
+
+// runCheck implements the check command, printing the location of each
+// removable comment of the inputs to standard output in the
+// --report-format. It fails with errCommentsFound if there are any, or
+// with errFilesFailed if an input does not parse.
+func runCheck(command *cobra.Command, args []string) error {
+	if err := validateReportFormat(); err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+
+	inputPaths, err := expandInputs(args)
+	if err != nil {
+		return err
+	}
+
+	var locations []commentLocation
+
+	offending, failed := 0, 0
+
+	for _, inputPath := range inputPaths {
+		found, err := findComments(inputPath)
+		if err != nil {
+			notef("%s: %s", inputPath, localizeMessage(err.Error()))
+
+			failed++
+
+			continue
+		}
+
+		if len(found) > 0 {
+			offending++
+		}
+
+		locations = append(locations, found...)
+	}
+
+	if !cfg.silent {
+		if err := writeCommentLocations(command, locations); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case failed > 0:
+		return fmt.Errorf("%w: %d/%d", errFilesFailed, failed, len(inputPaths))
+	case offending > 0:
+		return fmt.Errorf("%w: %d/%d", errCommentsFound, offending, len(inputPaths))
+	}
+
+	return nil
+}
+
+// findComments returns the comments of the file at inputPath that the root
+// command would remove with the default options, but for
+// --strip-license-header.
+func findComments(inputPath string) ([]commentLocation, error) {
+	src, err := os.ReadFile(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("file read failed: %w", err)
+	}
+
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", commentremover.ErrParse, err)
+	}
+
+	removable := commentremover.RemovableComments(fset, file,
+		commentremover.KeepLicenseHeader(!cfg.stripLicenseHeader))
+	locations := make([]commentLocation, 0, len(removable))
+
+	for _, c := range removable {
+		pos := fset.Position(c.Pos())
+		text, _, _ := strings.Cut(c.Text, "\n")
+
+		locations = append(locations, commentLocation{
+			Path:    slashPath(inputPath),
+			Line:    pos.Line,
+			Column:  pos.Column,
+			Comment: strings.TrimSpace(text),
+		})
+	}
+
+	return locations, nil
+}
+
+// writeCommentLocations writes locations to the standard output of command
+// in the --report-format, in text one per line as path:line:column, like
+// the diagnostics of compilers and go vet.
+func writeCommentLocations(command *cobra.Command, locations []commentLocation) error {
+	switch cfg.format {
+	case formatJSON:
+		if locations == nil {
+			locations = []commentLocation{}
+		}
+
+		return writeJSON(command.OutOrStdout(), locations)
+	case formatCSV, formatMarkdown:
+		rows := make([][]string, 0, len(locations))
+		for _, location := range locations {
+			rows = append(rows, []string{
+				location.Path, strconv.Itoa(location.Line), strconv.Itoa(location.Column), location.Comment,
+			})
+		}
+
+		return writeTable(command.OutOrStdout(), checkColumns, rows)
+	}
+
+	var out strings.Builder
+
+	for _, location := range locations {
+		_, _ = fmt.Fprintf(&out, "%s:%d:%d: %s\n", location.Path, location.Line, location.Column, location.Comment)
+	}
+
+	_, err := fmt.Fprint(command.OutOrStdout(), out.String())
+
+	return err //nolint:wrapcheck // Writing to standard output.
+}
@@ -0,0 +1,481 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pierow2k/nogocomments/pkg/commentremover"
+	"github.com/spf13/pflag"
+)
+
+// writeFiles writes files, keyed by slash-separated path, to a temporary
+// directory and returns it.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	root := t.TempDir()
+
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return root
+}
+
+// TestInputPoolStop verifies that once its context is done, the worker pool
+// hands out an outcome for every input, those that were never started
+// stopped, instead of waiting for them forever.
+func TestInputPoolStop(t *testing.T) {
+	const inputs = 50
+
+	files := map[string]string{}
+	for i := range inputs {
+		files[fmt.Sprintf("f%02d.go", i)] = "package p\n\n// x is x.\nvar x = 1\n"
+	}
+
+	root := writeFiles(t, files)
+	inputPaths := make([]string, inputs)
+
+	for i := range inputPaths {
+		inputPaths[i] = filepath.Join(root, fmt.Sprintf("f%02d.go", i))
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	pool := startInputPool(ctx, rootCmd.Flags(), inputPaths, 2)
+
+	if outcome := pool.outcome(0); outcome.err != nil {
+		t.Fatalf("outcome(0) failed: %v", outcome.err)
+	}
+
+	cancel()
+
+	done := make(chan inputOutcome)
+
+	go func() {
+		var last inputOutcome
+
+		for i := 1; i < inputs; i++ {
+			last = pool.outcome(i)
+		}
+
+		pool.wait()
+		done <- last
+	}()
+
+	select {
+	case last := <-done:
+		if !errors.Is(last.err, errStopped) {
+			t.Errorf("outcome(%d) error = %v, want %v", inputs-1, last.err, errStopped)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("outcomes not handed out after the context was done")
+	}
+}
+
+// TestResultCache verifies that a cached result is found again for the
+// same input and options, and missed once either changes.
+func TestResultCache(t *testing.T) {
+	dir := t.TempDir()
+	newFlags := func(keepDoc string) *pflag.FlagSet {
+		flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		flags.Bool("keep-doc", false, "")
+		_ = flags.Set("keep-doc", keepDoc)
+
+		return flags
+	}
+
+	const input = "package p\n\n// x is x.\nvar x = 1\n"
+
+	newResultCache(dir, newFlags("false")).store("p.go", input, commentremover.Result{Source: "package p\n"})
+
+	tests := []struct {
+		name    string
+		keepDoc string
+		input   string
+		want    bool
+	}{
+		{name: "same", keepDoc: "false", input: input, want: true},
+		{name: "changed content", keepDoc: "false", input: input + "\nvar y = 2\n", want: false},
+		{name: "changed options", keepDoc: "true", input: input, want: false},
+	}
+
+	for _, testCase := range tests {
+		result, ok := newResultCache(dir, newFlags(testCase.keepDoc)).load("p.go", testCase.input)
+		if ok != testCase.want || ok && result.Source != "package p\n" {
+			t.Errorf("%s: load() = %q, %v, want a hit %v", testCase.name, result.Source, ok, testCase.want)
+		}
+	}
+}
+
+// TestServeStdio verifies that requests of the --serve-stdio protocol are
+// answered in order, that a bad request is answered with an error, and that
+// a bad header ends the session without reading a body it cannot hold.
+func TestServeStdio(t *testing.T) {
+	message := func(body string) string {
+		return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+
+	strip := message(`{"id":1,"filename":"p.go","content":"package p\n\n// c\nvar x = 1\n"}`)
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []serveResponse
+		wantErr error
+	}{
+		{
+			name:  "round trip",
+			input: strip,
+			want:  []serveResponse{{ID: []byte("1"), Edits: []textEdit{{Offset: 11, Length: 5, NewText: ""}}}},
+		},
+		{
+			name:  "bad request",
+			input: message(`{"id":`) + strip,
+			want: []serveResponse{
+				{Edits: []textEdit{}, Error: "invalid request: unexpected end of JSON input"},
+				{ID: []byte("1"), Edits: []textEdit{{Offset: 11, Length: 5, NewText: ""}}},
+			},
+		},
+		{
+			name:    "huge length",
+			input:   strip + "Content-Length: 99999999999\r\n\r\n{}",
+			want:    []serveResponse{{ID: []byte("1"), Edits: []textEdit{{Offset: 11, Length: 5, NewText: ""}}}},
+			wantErr: errInvalidHeader,
+		},
+		{
+			name:    "bad length",
+			input:   "Content-Length: many\r\n\r\n{}",
+			wantErr: errInvalidHeader,
+		},
+	}
+
+	for _, testCase := range tests {
+		var out strings.Builder
+
+		err := serveStdio(t.Context(), rootCmd.Flags(), strings.NewReader(testCase.input), &out)
+		if !errors.Is(err, testCase.wantErr) || testCase.wantErr == nil && err != nil {
+			t.Errorf("%s: serveStdio() error = %v, want %v", testCase.name, err, testCase.wantErr)
+		}
+
+		var got []serveResponse
+
+		reader := bufio.NewReader(strings.NewReader(out.String()))
+
+		for {
+			body, err := readMessage(reader)
+			if err != nil {
+				break
+			}
+
+			var response serveResponse
+			if err := json.Unmarshal(body, &response); err != nil {
+				t.Fatalf("%s: response %s: %v", testCase.name, body, err)
+			}
+
+			got = append(got, response)
+		}
+
+		if !reflect.DeepEqual(got, testCase.want) {
+			t.Errorf("%s: responses = %+v, want %+v", testCase.name, got, testCase.want)
+		}
+	}
+}
+
+// TestWriteFile verifies that writing a file replaces its content, keeps
+// its permissions, writes a symbolic link through, and leaves no temporary
+// file behind.
+func TestWriteFile(t *testing.T) {
+	root := writeFiles(t, map[string]string{"a.go": "package a // a\n"})
+	target := filepath.Join(root, "a.go")
+	link := filepath.Join(root, "link.go")
+
+	if err := os.Chmod(target, 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink("a.go", link); err != nil {
+		t.Skipf("symbolic links unsupported: %v", err)
+	}
+
+	for _, write := range []struct{ path, content string }{{target, "package b\n"}, {link, "package a\n"}} {
+		if err := writeFile(write.path, write.content); err != nil {
+			t.Fatalf("writeFile(%s) error = %v", write.path, err)
+		}
+	}
+
+	if got, err := os.ReadFile(target); err != nil || string(got) != "package a\n" {
+		t.Errorf("content = %q, %v, want %q", got, err, "package a\n")
+	}
+
+	if info, err := os.Stat(target); err != nil || info.Mode().Perm() != 0o640 {
+		t.Errorf("mode = %v, %v, want %v", info.Mode().Perm(), err, os.FileMode(0o640))
+	}
+
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("link.go is no longer a symbolic link: %v", err)
+	}
+
+	if entries, _ := os.ReadDir(root); len(entries) != 2 {
+		t.Errorf("directory holds %d entries, want 2", len(entries))
+	}
+}
+
+// TestConfigPrecedence verifies that the configuration sets the flags not
+// given on the command line, that path-specific overrides apply, in order,
+// to the inputs matching their globs, absolute or not, and that the
+// command line wins over both.
+func TestConfigPrecedence(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recordFlags(rootCmd)
+
+	const source = "package p\n\n// X is x.\nvar X = 1\n"
+
+	genDoc := func(keepDoc bool) string {
+		return fmt.Sprintf(`{"paths": ["gen/**"], "settings": {"keep-doc": %v}}`, keepDoc)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		config   string
+		path     string
+		wantKept bool
+		wantErr  error
+	}{
+		{name: "default", path: "a.go", wantKept: false},
+		{name: "config", config: `{"keep-doc": true}`, path: "a.go", wantKept: true},
+		{name: "command line over config", args: []string{"--keep-doc=false"}, config: `{"keep-doc": true}`,
+			path: "a.go", wantKept: false},
+		{name: "override", config: `{"overrides": [` + genDoc(true) + `]}`, path: "gen/a.go", wantKept: true},
+		{name: "override elsewhere", config: `{"overrides": [` + genDoc(true) + `]}`, path: "lib/a.go",
+			wantKept: false},
+		{name: "override of absolute path", config: `{"overrides": [` + genDoc(true) + `]}`,
+			path: filepath.Join(wd, "gen", "a.go"), wantKept: true},
+		{name: "unclean path", config: `{"overrides": [` + genDoc(true) + `]}`, path: "lib/../gen/a.go",
+			wantKept: true},
+		{name: "later override wins", config: `{"overrides": [` + genDoc(true) + `, ` + genDoc(false) + `]}`,
+			path: "gen/a.go", wantKept: false},
+		{name: "override over config", config: `{"keep-doc": true, "overrides": [` + genDoc(false) + `]}`,
+			path: "gen/a.go", wantKept: false},
+		{name: "command line over override", args: []string{"--keep-doc"},
+			config: `{"overrides": [` + genDoc(false) + `]}`, path: "gen/a.go", wantKept: true},
+		{name: "run flag in override", config: `{"overrides": [{"paths": ["**"], "settings": {"write": true}}]}`,
+			wantErr: errUnknownConfigKey},
+		{name: "override without paths", config: `{"overrides": [{"settings": {"keep-doc": true}}]}`,
+			wantErr: errInvalidOverride},
+		{name: "unknown key", config: `{"keep-everything": true}`, wantErr: errUnknownConfigKey},
+		{name: "invalid value", config: `{"keep-doc": "often"}`, wantErr: errInvalidConfigValue},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			saved := cfg
+
+			t.Cleanup(func() { cfg, overrides = saved, nil })
+
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			flags.BoolVar(&cfg.keepDoc, "keep-doc", false, "")
+			flags.BoolVar(&cfg.write, "write", false, "")
+
+			if err := flags.Parse(testCase.args); err != nil {
+				t.Fatal(err)
+			}
+
+			err := applyConfig(flags, []byte(testCase.config), "test.json")
+			if !errors.Is(err, testCase.wantErr) || testCase.wantErr == nil && err != nil {
+				t.Fatalf("applyConfig() error = %v, want %v", err, testCase.wantErr)
+			}
+
+			if testCase.wantErr != nil {
+				return
+			}
+
+			keepDoc := cfg.keepDoc
+
+			opts, err := optionsFor(flags, testCase.path)
+			if err != nil {
+				t.Fatalf("optionsFor() error = %v", err)
+			}
+
+			if cfg.keepDoc != keepDoc {
+				t.Errorf("optionsFor() left keep-doc %v, want %v", cfg.keepDoc, keepDoc)
+			}
+
+			result, err := commentremover.Process(source, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if kept := strings.Contains(result.Source, "// X is x."); kept != testCase.wantKept {
+				t.Errorf("doc comment kept = %v, want %v", kept, testCase.wantKept)
+			}
+		})
+	}
+}
+
+// TestSlashPath verifies that inputs are made slash-separated, clean, and
+// relative to the working directory when they lie below it, the form that
+// override globs are matched against.
+func TestSlashPath(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "a.go", want: "a.go"},
+		{path: "./gen/../lib/a.go", want: "lib/a.go"},
+		{path: filepath.Join("gen", "x", "a.go"), want: "gen/x/a.go"},
+		{path: filepath.Join(wd, "gen", "a.go"), want: "gen/a.go"},
+		{path: wd, want: "."},
+	}
+
+	for _, testCase := range tests {
+		if got := slashPath(testCase.path); got != testCase.want {
+			t.Errorf("slashPath(%q) = %q, want %q", testCase.path, got, testCase.want)
+		}
+	}
+}
+
+// TestCheck verifies that the check command passes code without removable
+// comments, allowing directives and license headers, and fails, listing
+// where they are, otherwise.
+func TestCheck(t *testing.T) {
+	root := writeFiles(t, map[string]string{
+		"clean.go":     "// Copyright 2026 Someone. All rights reserved.\n\npackage p\n\n//go:generate true\nvar x = 1\n",
+		"comment.go":   "package p\n\nvar y = 2 // two\n",
+		"broken.go":    "package p\n\nfunc (\n",
+		"license.go":   "// Copyright 2026 Someone. All rights reserved.\n\npackage p\n",
+		"directive.go": "package p\n\n//go:noinline\nfunc f() {}\n",
+	})
+
+	tests := []struct {
+		name         string
+		files        []string
+		stripLicense bool
+		want         string
+		wantErr      error
+	}{
+		{name: "clean", files: []string{"clean.go", "directive.go", "license.go"}},
+		{name: "comment", files: []string{"clean.go", "comment.go"}, want: "comment.go:3:11: // two\n",
+			wantErr: errCommentsFound},
+		{name: "license header", files: []string{"license.go"}, stripLicense: true,
+			want: "license.go:1:1: // Copyright 2026 Someone. All rights reserved.\n", wantErr: errCommentsFound},
+		{name: "broken", files: []string{"comment.go", "broken.go"}, want: "comment.go:3:11: // two\n",
+			wantErr: errFilesFailed},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			saved := cfg
+
+			t.Cleanup(func() { cfg = saved })
+
+			cfg.stripLicenseHeader = testCase.stripLicense
+
+			var out strings.Builder
+
+			checkCmd.SetOut(&out)
+
+			args := make([]string, len(testCase.files))
+			for i, name := range testCase.files {
+				args[i] = filepath.Join(root, name)
+			}
+
+			err := runCheck(checkCmd, args)
+			if !errors.Is(err, testCase.wantErr) || testCase.wantErr == nil && err != nil {
+				t.Errorf("runCheck() error = %v, want %v", err, testCase.wantErr)
+			}
+
+			if got := strings.ReplaceAll(out.String(), slashPath(root)+"/", ""); got != testCase.want {
+				t.Errorf("runCheck() output = %q, want %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+// TestIncrementalState verifies that --state starts with every file, that a
+// recorded run makes the next one skip the files not modified since it
+// started and those it rewrote, unless they were modified again, and that
+// a state file not holding a time is rejected.
+func TestIncrementalState(t *testing.T) {
+	saved := cfg
+
+	t.Cleanup(func() { cfg = saved })
+
+	root := writeFiles(t, map[string]string{
+		"old.go": "package p\n", "new.go": "package p\n", "written.go": "package p\n", "edited.go": "package p\n",
+		"bad": "yesterday\n",
+	})
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	var inputPaths []string
+
+	for _, name := range []string{"old.go", "new.go", "written.go", "edited.go", "gone.go"} {
+		inputPaths = append(inputPaths, filepath.Join(root, name))
+	}
+
+	for i, modified := range []time.Time{start.Add(-time.Hour), start, start, start} {
+		if err := os.Chtimes(inputPaths[i], modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg.statePath = filepath.Join(root, "state")
+
+	since, rewritten, err := modifiedSince(start)
+	if err != nil || !since.IsZero() || rewritten != nil {
+		t.Errorf("modifiedSince() without a state file = %v, %v, %v, want the zero time", since, rewritten, err)
+	}
+
+	noteRewritten(inputPaths[2])
+	noteRewritten(inputPaths[3])
+
+	if err := recordRun(start.Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	edited := start.Add(time.Second)
+	if err := os.Chtimes(inputPaths[3], edited, edited); err != nil {
+		t.Fatal(err)
+	}
+
+	since, rewritten, err = modifiedSince(start)
+	if err != nil || !since.Equal(start.Add(-time.Minute)) || len(rewritten) != 2 {
+		t.Errorf("modifiedSince() = %v, %v, %v, want %v and 2 files", since, rewritten, err, start.Add(-time.Minute))
+	}
+
+	got, want := changedSince(inputPaths, since, rewritten), []string{inputPaths[1], inputPaths[3], inputPaths[4]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changedSince() = %q, want %q", got, want)
+	}
+
+	cfg.statePath = filepath.Join(root, "bad")
+
+	if _, _, err := modifiedSince(start); !errors.Is(err, errInvalidState) {
+		t.Errorf("modifiedSince() with a bad state file error = %v, want %v", err, errInvalidState)
+	}
+}
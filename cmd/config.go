@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"bytes"
+	_ "embed" // Required for embedding the default configuration.
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// DefaultConfig is the built-in configuration, in the same JSON format as
+// a --config file. It is empty in official releases. Organizations can ship
+// a pre-configured binary by replacing default_config.json before building,
+// or by assigning DefaultConfig from their own main package before calling
+// Execute.
+//
+//go:embed default_config.json
+var DefaultConfig []byte
+
+// defaultConfigName identifies DefaultConfig in error messages.
+const defaultConfigName = "built-in configuration"
+
+var (
+	// errInvalidConfig is returned when a configuration is not a JSON object.
+	errInvalidConfig = errors.New("invalid configuration")
+
+	// errUnknownConfigKey is returned when a configuration names a flag that
+	// does not exist or cannot be configured.
+	errUnknownConfigKey = errors.New("unknown configuration key")
+
+	// errInvalidConfigValue is returned when a configuration value has a type
+	// that cannot be assigned to its flag.
+	errInvalidConfigValue = errors.New("invalid configuration value")
+)
+
+// unconfigurableFlags are flags that only make sense on the command line.
+var unconfigurableFlags = map[string]bool{
+	"config": true, "help": true, "interactive": true, "review": true,
+	"serve-stdio": true, "version": true,
+}
+
+// definedFlags holds the names of the flags of every command. A single
+// configuration serves all commands, so keys for the flags of another
+// command are skipped rather than rejected.
+var definedFlags = map[string]bool{}
+
+// loadConfig applies DefaultConfig and then the --config file, if any, to
+// the flags of command. It is installed as the persistent pre-run hook so
+// it runs after the command line has been parsed.
+func loadConfig(command *cobra.Command, _ []string) error {
+	recordFlags(command.Root())
+
+	if err := applyConfig(command.Flags(), DefaultConfig, defaultConfigName); err != nil {
+		return err
+	}
+
+	if cfg.configPath == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(cfg.configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read configuration: %w", err)
+	}
+
+	return applyConfig(command.Flags(), data, cfg.configPath)
+}
+
+// recordFlags adds the flags of command and its subcommands to
+// definedFlags.
+func recordFlags(command *cobra.Command) {
+	for _, flags := range []*pflag.FlagSet{command.Flags(), command.PersistentFlags()} {
+		flags.VisitAll(func(flag *pflag.Flag) {
+			definedFlags[flag.Name] = true
+		})
+	}
+
+	for _, sub := range command.Commands() {
+		recordFlags(sub)
+	}
+}
+
+// applyConfig sets flag values from data, a JSON object keyed by long flag
+// name. Flags given on the command line keep their values, so the command
+// line always takes precedence over configuration. Values are assigned
+// without marking flags as changed, letting a later configuration override
+// an earlier one. The "overrides" key holds path-specific settings, which
+// are recorded for later; see loadOverrides. The name of the configuration
+// is used in error messages.
+func applyConfig(flags *pflag.FlagSet, data []byte, source string) error {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+
+	var settings map[string]json.RawMessage
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("%s: %w: %w", source, errInvalidConfig, err)
+	}
+
+	if raw, ok := settings[overridesKey]; ok {
+		delete(settings, overridesKey)
+
+		if err := loadOverrides(raw, source); err != nil {
+			return err
+		}
+	}
+
+	return applySettings(flags, settings, source, configurable)
+}
+
+// applySettings sets the flags named in settings to their values, skipping
+// flags given on the command line and flags of other commands. Names for
+// which allowed reports false are rejected.
+func applySettings(
+	flags *pflag.FlagSet, settings map[string]json.RawMessage, source string, allowed func(string) bool,
+) error {
+	for name, raw := range settings {
+		if !definedFlags[name] || !allowed(name) {
+			return fmt.Errorf("%s: %w: %q", source, errUnknownConfigKey, name)
+		}
+
+		flag := flags.Lookup(name)
+		if flag == nil || flag.Changed {
+			continue
+		}
+
+		var value any
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return fmt.Errorf("%s: %w: %s: %w", source, errInvalidConfigValue, name, err)
+		}
+
+		if err := setFlagValue(flag, value); err != nil {
+			return fmt.Errorf("%s: %w: %s: %w", source, errInvalidConfigValue, name, err)
+		}
+	}
+
+	return nil
+}
+
+// configurable reports whether the flag called name may be set by a
+// configuration.
+func configurable(name string) bool {
+	return !unconfigurableFlags[name]
+}
+
+// setFlagValue assigns a decoded JSON value to flag. Arrays replace the
+// contents of slice flags; scalars are converted to their flag syntax.
+func setFlagValue(flag *pflag.Flag, value any) error {
+	if items, ok := value.([]any); ok {
+		sliceValue, ok := flag.Value.(pflag.SliceValue)
+		if !ok {
+			return errInvalidConfigValue
+		}
+
+		values := make([]string, 0, len(items))
+
+		for _, item := range items {
+			text, err := configScalar(item)
+			if err != nil {
+				return err
+			}
+
+			values = append(values, text)
+		}
+
+		return sliceValue.Replace(values) //nolint:wrapcheck // Wrapped by applyConfig.
+	}
+
+	text, err := configScalar(value)
+	if err != nil {
+		return err
+	}
+
+	return flag.Value.Set(text) //nolint:wrapcheck // Wrapped by applyConfig.
+}
+
+// configScalar converts a decoded JSON scalar to the text of a flag value.
+func configScalar(value any) (string, error) {
+	switch typed := value.(type) {
+	case string:
+		return typed, nil
+	case bool:
+		return strconv.FormatBool(typed), nil
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64), nil
+	default:
+		return "", errInvalidConfigValue
+	}
+}
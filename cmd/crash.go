@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"os"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"unicode"
+
+	"github.com/pierow2k/nogocomments/pkg/commentremover"
+)
+
+// exitCrashed is the exit status of a run in which removing the comments
+// of an input crashed, to tell it from ordinary failures, which exit with
+// status 1.
+const exitCrashed = 3
+
+// crashed is set once removing the comments of an input crashed. Inputs
+// are processed concurrently, hence the atomic.
+var crashed atomic.Bool
+
+// reportCrash writes a crash report for panicErr, the crash that err
+// carries, while removing the comments of source, the input called
+// inputName. It returns err with the path of the report added, so that
+// the user knows what to attach to an issue.
+func reportCrash(inputName, source string, err error, panicErr *commentremover.PanicError) error {
+	crashed.Store(true)
+
+	path, writeErr := writeCrashReport(inputName, source, panicErr)
+	if writeErr != nil {
+		return fmt.Errorf("%w (%s: %w)", err, tr("failed to write crash report"), writeErr)
+	}
+
+	return fmt.Errorf("%w (%s)", err, tr("crash report written to %s", path))
+}
+
+// writeCrashReport writes the details of panicErr, the stack trace and the
+// input among them, to a new file in the temporary directory and returns
+// its path. With --redact-crashes, the input is redacted by redactSource.
+func writeCrashReport(inputName, source string, panicErr *commentremover.PanicError) (string, error) {
+	report, err := os.CreateTemp("", "nogocomments-crash-*.txt")
+	if err != nil {
+		return "", err //nolint:wrapcheck // Wrapped by reportCrash.
+	}
+
+	inputKind := "as given"
+	if cfg.redactCrashes {
+		source, inputKind = redactSource(source), "redacted"
+	}
+
+	_, err = fmt.Fprintf(report, `nogocomments crashed while removing the comments of %s.
+Please report it at https://github.com/pierow2k/nogocomments/issues with
+this file attached, after checking that it holds nothing confidential.
+
+Version: %s (%s)
+Go: %s %s/%s
+Arguments: %q
+Panic: %v
+
+%s
+Input (%s):
+%s`,
+		inputName, Version, BuildDate, runtime.Version(), runtime.GOOS, runtime.GOARCH, os.Args[1:],
+		panicErr.Value, panicErr.Stack, inputKind, source)
+	if closeErr := report.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return "", err //nolint:wrapcheck // Wrapped by reportCrash.
+	}
+
+	return report.Name(), nil
+}
+
+// redactSource returns source with the text of its identifiers, literals,
+// and comments masked by maskText, keeping the keywords, operators, and
+// layout that a crash most likely depends on. Comments without a space
+// after the "//", such as //go:build, are directives and kept as they are.
+func redactSource(source string) string {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(source))
+
+	var scan scanner.Scanner
+
+	scan.Init(file, []byte(source), nil, scanner.ScanComments)
+
+	var out strings.Builder
+
+	cursor := 0
+
+	for {
+		pos, tok, lit := scan.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		start := file.Offset(pos)
+
+		switch tok {
+		case token.IDENT, token.INT, token.FLOAT, token.IMAG, token.CHAR, token.STRING, token.COMMENT:
+			end := literalEnd(source, start, lit)
+			out.WriteString(source[cursor:start])
+			out.WriteString(maskText(tok, source[start:end]))
+			cursor = end
+		default:
+		}
+	}
+
+	out.WriteString(source[cursor:])
+
+	return out.String()
+}
+
+// literalEnd returns the offset in source of the end of lit, the text of
+// the token starting at start. The scanner drops the carriage returns
+// from comments and raw strings, so they are skipped in the count.
+func literalEnd(source string, start int, lit string) int {
+	end := start
+
+	for matched := 0; matched < len(lit) && end < len(source); end++ {
+		if source[end] != '\r' || lit[matched] == '\r' {
+			matched++
+		}
+	}
+
+	return end
+}
+
+// maskText returns lit, the text of a token of kind tok, with its letters
+// replaced by "x", or "X" if upper case, and its digits by "0", so that it
+// still scans as the same kind of token. The escape letters of string and
+// character literals are kept, as are the "//" or "/*" of comments and the
+// whole of directives.
+func maskText(tok token.Token, lit string) string {
+	switch {
+	case tok == token.COMMENT && strings.HasPrefix(lit, "//") && len(lit) > 2 && !unicode.IsSpace(rune(lit[2])):
+		return lit
+	case tok == token.COMMENT:
+		return lit[:2] + maskRunes(lit[2:], false)
+	case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+		// Letters in number literals are prefixes, exponents, and hex
+		// digits, none of which gives anything away.
+		return strings.Map(func(char rune) rune {
+			if char >= '1' && char <= '9' {
+				return '0'
+			}
+
+			return char
+		}, lit)
+	default:
+		return maskRunes(lit, tok == token.STRING && lit[0] == '"' || tok == token.CHAR)
+	}
+}
+
+// maskRunes masks the letters and digits of text as maskText describes.
+// If escapes is set, a backslash escape keeps its letter, and the digits
+// of a numeric escape become "0".
+func maskRunes(text string, escapes bool) string {
+	var out strings.Builder
+
+	afterBackslash, numeric := false, false
+
+	for _, char := range text {
+		switch {
+		case afterBackslash:
+			afterBackslash, numeric = false, strings.ContainsRune("xuU01234567", char)
+			if char >= '0' && char <= '7' {
+				char = '0'
+			}
+
+			out.WriteRune(char)
+		case numeric && unicode.Is(unicode.ASCII_Hex_Digit, char):
+			out.WriteByte('0')
+		case escapes && char == '\\':
+			afterBackslash, numeric = true, false
+
+			out.WriteRune(char)
+		case unicode.IsUpper(char):
+			numeric = false
+
+			out.WriteByte('X')
+		case unicode.IsLetter(char):
+			numeric = false
+
+			out.WriteByte('x')
+		case unicode.IsDigit(char):
+			numeric = false
+
+			out.WriteByte('0')
+		default:
+			numeric = false
+
+			out.WriteRune(char)
+		}
+	}
+
+	return out.String()
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"go/scanner"
+	"strings"
+
+	"github.com/pierow2k/nogocomments/pkg/commentremover"
+)
+
+// parseFailure is the failure to parse an input, reported with every
+// diagnostic of the Go parser and a copy of the line that each points at.
+type parseFailure struct {
+	err    error             // err is the error of commentremover, wrapping commentremover.ErrParse.
+	name   string            // name is the input as called in messages.
+	source string            // source is the input.
+	list   scanner.ErrorList // list holds the diagnostics that err carries.
+}
+
+// withSourceContext returns err, the failure of commentremover to remove
+// the comments of source, the input called inputName, as a parseFailure if
+// it carries parser diagnostics, with the path of a crash report if it is
+// a crash, or else unchanged.
+func withSourceContext(inputName, source string, err error) error {
+	var panicErr *commentremover.PanicError
+	if errors.As(err, &panicErr) {
+		return reportCrash(inputName, source, err, panicErr)
+	}
+
+	var list scanner.ErrorList
+	if !errors.As(err, &list) || len(list) == 0 {
+		return err
+	}
+
+	return &parseFailure{err: err, name: inputName, source: source, list: list}
+}
+
+// Error lists the diagnostics in the form "name:line:column: message", or
+// "line:column: message" for an input without a name, each followed by
+// the line it points at and a caret under the column, as in
+//
+//	1 syntax error:
+//	main.go:3:17: string literal not terminated
+//		x := "abc
+//		     ^
+func (failure *parseFailure) Error() string {
+	var out strings.Builder
+
+	if len(failure.list) == 1 {
+		out.WriteString(tr("1 syntax error") + ":")
+	} else {
+		out.WriteString(tr("%d syntax errors", len(failure.list)) + ":")
+	}
+
+	lines := strings.Split(failure.source, "\n")
+
+	for _, diagnostic := range failure.list {
+		position := diagnostic.Pos
+		position.Filename = failure.name
+		_, _ = fmt.Fprintf(&out, "\n%s: %s", position, diagnostic.Msg)
+
+		if position.Line < 1 || position.Line > len(lines) {
+			continue
+		}
+
+		line := strings.TrimSuffix(lines[position.Line-1], "\r")
+		column := min(max(position.Column, 1), len(line)+1)
+		_, _ = fmt.Fprintf(&out, "\n\t%s\n\t%s^", line, caretIndent(line[:column-1]))
+	}
+
+	return out.String()
+}
+
+// Unwrap returns the error of commentremover, so that errors.Is finds
+// commentremover.ErrParse.
+func (failure *parseFailure) Unwrap() error {
+	return failure.err
+}
+
+// caretIndent returns the blanks that put a caret under the character that
+// follows prefix, the start of a line: a tab for each tab in prefix, so the
+// two line up however wide a tab is shown, and a space for every other
+// character.
+func caretIndent(prefix string) string {
+	var indent strings.Builder
+
+	for _, char := range prefix {
+		if char == '\t' {
+			indent.WriteByte('\t')
+		} else {
+			indent.WriteByte(' ')
+		}
+	}
+
+	return indent.String()
+}
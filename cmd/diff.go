@@ -0,0 +1,104 @@
+package cmd
+
+// diffOp is the kind of a line in a line diff: one of ' ', '-', or '+'.
+type diffOp byte
+
+// Kinds of diffLine.
+const (
+	diffSame   diffOp = ' ' // diffSame marks a line found in both inputs.
+	diffDelete diffOp = '-' // diffDelete marks a line found only in the old input.
+	diffInsert diffOp = '+' // diffInsert marks a line found only in the new input.
+)
+
+// maxDiffCells bounds the size of the table that lineDiff builds for the
+// lines between the common prefix and suffix of its inputs.
+const maxDiffCells = 1 << 22
+
+// diffLine is a single line of a line diff.
+type diffLine struct {
+	op   diffOp // op tells which of the inputs hold the line.
+	text string // text is the line, without its newline.
+}
+
+// lineDiff returns a diff turning the lines before into the lines after. The
+// common prefix and suffix are matched first; the lines in between are
+// diffed by their longest common subsequence.
+func lineDiff(before, after []string) []diffLine {
+	prefix := 0
+	for prefix < len(before) && prefix < len(after) && before[prefix] == after[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(before)-prefix && suffix < len(after)-prefix &&
+		before[len(before)-1-suffix] == after[len(after)-1-suffix] {
+		suffix++
+	}
+
+	diff := make([]diffLine, 0, len(before)+len(after)-prefix-suffix)
+
+	for _, text := range before[:prefix] {
+		diff = append(diff, diffLine{op: diffSame, text: text})
+	}
+
+	diff = append(diff, middleDiff(before[prefix:len(before)-suffix], after[prefix:len(after)-suffix])...)
+
+	for _, text := range before[len(before)-suffix:] {
+		diff = append(diff, diffLine{op: diffSame, text: text})
+	}
+
+	return diff
+}
+
+// middleDiff diffs the lines before and after by their longest common
+// subsequence. If they are too long for that, all of before is deleted and all
+// of after inserted instead.
+func middleDiff(before, after []string) []diffLine {
+	diff := make([]diffLine, 0, len(before)+len(after))
+
+	if len(before)*len(after) > maxDiffCells {
+		for _, text := range before {
+			diff = append(diff, diffLine{op: diffDelete, text: text})
+		}
+
+		for _, text := range after {
+			diff = append(diff, diffLine{op: diffInsert, text: text})
+		}
+
+		return diff
+	}
+
+	// common[i*width+j] is the length of the longest common subsequence of
+	// before[i:] and after[j:].
+	width := len(after) + 1
+	common := make([]int, (len(before)+1)*width)
+
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				common[i*width+j] = common[(i+1)*width+j+1] + 1
+			} else {
+				common[i*width+j] = max(common[(i+1)*width+j], common[i*width+j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i] == after[j]:
+			diff = append(diff, diffLine{op: diffSame, text: before[i]})
+			i++
+			j++
+		case j == len(after) || i < len(before) && common[(i+1)*width+j] >= common[i*width+j+1]:
+			diff = append(diff, diffLine{op: diffDelete, text: before[i]})
+			i++
+		default:
+			diff = append(diff, diffLine{op: diffInsert, text: after[j]})
+			j++
+		}
+	}
+
+	return diff
+}
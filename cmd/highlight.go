@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"go/scanner"
+	"go/token"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ANSI escape sequences used to colorize highlighted output.
+const (
+	ansiReset   = "\x1b[0m"
+	ansiKeyword = "\x1b[35m" // ansiKeyword colors Go keywords magenta.
+	ansiString  = "\x1b[32m" // ansiString colors string and rune literals green.
+	ansiNumber  = "\x1b[36m" // ansiNumber colors numeric literals cyan.
+	ansiComment = "\x1b[90m" // ansiComment colors retained comments gray.
+)
+
+// colorEnabled reports whether standard output should receive ANSI color:
+// it must be a terminal, and the NO_COLOR convention must not be in effect.
+func colorEnabled() bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+
+	return term.IsTerminal(int(os.Stdout.Fd())) //nolint:gosec // File descriptors fit in an int.
+}
+
+// tokenColor returns the escape sequence used for tok, or "" if the token
+// is printed uncolored.
+func tokenColor(tok token.Token) string {
+	switch {
+	case tok.IsKeyword():
+		return ansiKeyword
+	case tok == token.STRING || tok == token.CHAR:
+		return ansiString
+	case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+		return ansiNumber
+	case tok == token.COMMENT:
+		return ansiComment
+	default:
+		return ""
+	}
+}
+
+// highlight returns src with ANSI colors applied to its tokens. The text
+// of a token is taken to run from its offset to the start of the next one,
+// minus trailing whitespace, so the original bytes are reproduced exactly
+// even for literals the scanner normalizes. Input that does not tokenize
+// cleanly is colored on a best-effort basis.
+func highlight(src string) string {
+	type span struct {
+		offset int
+		tok    token.Token
+	}
+
+	var (
+		sourceScanner scanner.Scanner
+		spans         []span
+	)
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	sourceScanner.Init(file, []byte(src), nil, scanner.ScanComments)
+
+	for {
+		pos, tok, lit := sourceScanner.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		// Automatically inserted semicolons have no source text.
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue
+		}
+
+		spans = append(spans, span{offset: file.Offset(pos), tok: tok})
+	}
+
+	var out strings.Builder
+
+	cursor := 0
+
+	for i, current := range spans {
+		end := len(src)
+		if i+1 < len(spans) {
+			end = spans[i+1].offset
+		}
+
+		text := strings.TrimRight(src[current.offset:end], " \t\r\n")
+		textEnd := current.offset + len(text)
+
+		out.WriteString(src[cursor:current.offset])
+
+		if color := tokenColor(current.tok); color != "" {
+			out.WriteString(color)
+			out.WriteString(text)
+			out.WriteString(ansiReset)
+		} else {
+			out.WriteString(text)
+		}
+
+		cursor = textEnd
+	}
+
+	out.WriteString(src[cursor:])
+
+	return out.String()
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// supportedLanguages lists the languages that have a message catalog. The
+// first entry is the fallback used when no other language matches.
+var supportedLanguages = []language.Tag{language.English, language.German}
+
+// translations holds the message catalog for each non-English language.
+// Messages are keyed by their English text, so a missing translation falls
+// back to English. Keys may contain fmt verbs, which the translation must
+// reproduce.
+var translations = map[language.Tag]map[string]string{
+	language.German: messagesDE,
+}
+
+// usageHeadings are the fragments of cobra's usage template that are
+// replaced with their translations, in order. "Global Flags:" precedes
+// "Flags:", which it contains.
+var usageHeadings = []string{
+	"Usage:", "Aliases:", "Examples:", "Available Commands:", "Additional Commands:",
+	"Global Flags:", "Flags:", "Additional help topics:",
+	`Use "{{.CommandPath}} [command] --help" for more information about a command.`,
+}
+
+var (
+	currentLanguage = language.English                     // currentLanguage is the selected message language.
+	printer         = message.NewPrinter(language.English) // printer formats messages in currentLanguage.
+)
+
+// init registers the message catalogs with x/text.
+func init() {
+	for tag, messages := range translations {
+		for key, msg := range messages {
+			_ = message.SetString(tag, key, msg)
+		}
+	}
+}
+
+// tr returns the translation of the English message key, formatted with
+// args like fmt.Sprintf.
+func tr(key string, args ...any) string {
+	return printer.Sprintf(key, args...)
+}
+
+// localizeMessage translates a colon-separated error chain, such as the
+// text of a wrapped error, one segment at a time. Segments without a
+// catalog entry, such as file names or parser diagnostics, are kept as-is.
+func localizeMessage(text string) string {
+	messages := translations[currentLanguage]
+	if messages == nil {
+		return text
+	}
+
+	segments := strings.Split(text, ": ")
+	for i, segment := range segments {
+		if msg, ok := messages[segment]; ok {
+			segments[i] = msg
+		}
+	}
+
+	return strings.Join(segments, ": ")
+}
+
+// requestedLanguage returns the language asked for by the --lang flag in
+// args, or else by the LC_ALL, LC_MESSAGES, or LANG environment variables,
+// and whether it came from the flag. The flag is looked up before cobra
+// parses the command line so that help text and flag errors are already
+// localized.
+func requestedLanguage(args []string) (string, bool) {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+
+		if value, ok := strings.CutPrefix(arg, "--lang="); ok {
+			return value, true
+		}
+
+		if arg == "--lang" && i+1 < len(args) {
+			return args[i+1], true
+		}
+	}
+
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return value, false
+		}
+	}
+
+	return "", false
+}
+
+// setLanguage selects the message language from a locale name such as
+// "de", "de-DE", or "de_DE.UTF-8". It reports whether a catalog matched;
+// unknown or empty names select English.
+func setLanguage(name string) bool {
+	name, _, _ = strings.Cut(name, ".")
+	name, _, _ = strings.Cut(name, "@")
+
+	currentLanguage = language.English
+	printer = message.NewPrinter(language.English)
+
+	if name == "" || name == "C" || name == "POSIX" {
+		return true
+	}
+
+	tag, err := language.Parse(strings.ReplaceAll(name, "_", "-"))
+	if err != nil {
+		return false
+	}
+
+	_, index, confidence := language.NewMatcher(supportedLanguages).Match(tag)
+	if confidence == language.No {
+		return false
+	}
+
+	currentLanguage = supportedLanguages[index]
+	printer = message.NewPrinter(currentLanguage)
+
+	return true
+}
+
+// localizeCommand translates the help text, flag descriptions, and usage
+// template of command and its subcommands into the current language.
+func localizeCommand(command *cobra.Command) {
+	if currentLanguage == language.English {
+		return
+	}
+
+	command.Short = tr(command.Short)
+	command.Long = tr(command.Long)
+	command.Example = tr(command.Example)
+
+	command.Flags().VisitAll(func(flag *pflag.Flag) {
+		flag.Usage = tr(flag.Usage)
+	})
+
+	if !command.HasParent() {
+		template := command.UsageTemplate()
+		for _, heading := range usageHeadings {
+			template = strings.ReplaceAll(template, heading, tr(heading))
+		}
+
+		command.SetUsageTemplate(template)
+	}
+
+	for _, sub := range command.Commands() {
+		localizeCommand(sub)
+	}
+}
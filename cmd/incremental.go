@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	// errIncrementalNeedsFile is returned when --since or --state is used
+	// with input that does not come from files.
+	errIncrementalNeedsFile = errors.New("--since and --state require input files")
+
+	// errIncrementalConflict is returned when both --since and --state are
+	// specified.
+	errIncrementalConflict = errors.New("--since and --state are mutually exclusive")
+
+	// errInvalidState is returned when a --state file does not hold a time.
+	errInvalidState = errors.New("invalid state file (want an RFC 3339 time)")
+)
+
+// modifiedSince returns the time before which input files are considered
+// unchanged: the time given by --since, or the time of the last successful
+// run recorded in the --state file. It returns the zero time if neither is
+// set or the state file does not exist yet, so that every file is processed.
+// From the state file, it also returns the modification times of the files
+// that the last run rewrote, by path.
+func modifiedSince(now time.Time) (time.Time, map[string]time.Time, error) {
+	if cfg.statePath != "" {
+		return readState(cfg.statePath)
+	}
+
+	if cfg.since == "" {
+		return time.Time{}, nil, nil
+	}
+
+	since, err := parseSince(cfg.since, now)
+
+	return since, nil, err
+}
+
+// readState reads the --state file at path: a line holding the start of
+// the last successful run, followed by a line for each input file the run
+// rewrote, holding its modification time and path separated by a tab.
+func readState(path string) (time.Time, map[string]time.Time, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return time.Time{}, nil, nil
+	} else if err != nil {
+		return time.Time{}, nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+
+	since, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(lines[0]))
+	if err != nil {
+		return time.Time{}, nil, fmt.Errorf("%w: %s", errInvalidState, path)
+	}
+
+	rewritten := make(map[string]time.Time, len(lines)-1)
+
+	for _, line := range lines[1:] {
+		text, inputPath, found := strings.Cut(strings.TrimRight(line, "\r"), "\t")
+
+		modified, err := time.Parse(time.RFC3339Nano, text)
+		if !found || err != nil {
+			return time.Time{}, nil, fmt.Errorf("%w: %s", errInvalidState, path)
+		}
+
+		rewritten[inputPath] = modified
+	}
+
+	return since, rewritten, nil
+}
+
+// parseSince converts a --since value to the time it denotes: an RFC 3339
+// time, the modification time of the file of that name, or an age or date
+// as for --older-than.
+func parseSince(text string, now time.Time) (time.Time, error) {
+	if since, err := time.Parse(time.RFC3339Nano, text); err == nil {
+		return since, nil
+	}
+
+	if info, err := os.Stat(text); err == nil {
+		return info.ModTime(), nil
+	}
+
+	return parseAge(text, now)
+}
+
+// changedSince returns the paths among inputPaths of the files modified
+// after since, but for those still as rewritten, whose modification time is
+// the one in rewritten. A file that cannot be examined is kept, so that
+// reading it reports the error.
+func changedSince(inputPaths []string, since time.Time, rewritten map[string]time.Time) []string {
+	changed := make([]string, 0, len(inputPaths))
+
+	for _, inputPath := range inputPaths {
+		info, err := os.Stat(inputPath)
+		if err != nil {
+			changed = append(changed, inputPath)
+
+			continue
+		}
+
+		if modified, ok := rewritten[inputPath]; ok && info.ModTime().Equal(modified) {
+			continue
+		}
+
+		if info.ModTime().After(since) {
+			changed = append(changed, inputPath)
+		}
+	}
+
+	return changed
+}
+
+// noteRewritten notes the modification time of the input file at inputPath,
+// which --write just rewrote, for recordRun, so that the next run does not
+// take the rewrite for a change.
+func noteRewritten(inputPath string) {
+	if cfg.statePath == "" {
+		return
+	}
+
+	if info, err := os.Stat(inputPath); err == nil {
+		if cfg.rewritten == nil {
+			cfg.rewritten = make(map[string]time.Time)
+		}
+
+		cfg.rewritten[inputPath] = info.ModTime()
+	}
+}
+
+// recordRun records start, the time a successful run started, in the
+// --state file, along with the input files the run rewrote, so that the
+// next run skips the files not modified since. Other files modified while
+// the run was under way are thus processed again.
+func recordRun(start time.Time) error {
+	var state strings.Builder
+
+	state.WriteString(start.Format(time.RFC3339Nano) + "\n")
+
+	for _, inputPath := range slices.Sorted(maps.Keys(cfg.rewritten)) {
+		state.WriteString(cfg.rewritten[inputPath].Format(time.RFC3339Nano) + "\t" + inputPath + "\n")
+	}
+
+	if err := os.WriteFile(cfg.statePath, []byte(state.String()), outputFileMode); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+
+	return nil
+}
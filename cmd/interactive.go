@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pierow2k/nogocomments/pkg/commentremover"
+)
+
+// errInteractiveConflict is returned when --interactive is combined with a
+// mode that leaves no terminal to ask on.
+var errInteractiveConflict = errors.New("--interactive cannot be combined with --serve-stdio or --silent")
+
+// contextLines is the number of lines shown before and after a comment
+// when asking about it.
+const contextLines = 3
+
+// interactiveHelp explains the answers accepted by a reviewer.
+const interactiveHelp = `y - remove this comment
+n - keep this comment
+a - remove this and all remaining comments in the file
+d - keep this and all remaining comments in the file
+q - keep this and all remaining comments
+? - print help
+`
+
+// reviewer asks, comment by comment, whether to remove each comment, in the
+// manner of git add -p. Comments it is not asked about, because another
+// option keeps them, are never shown.
+type reviewer struct {
+	in   *bufio.Reader // in supplies the answers.
+	out  io.Writer     // out receives the comments and prompts.
+	quit bool          // quit is set once every remaining comment is to be kept.
+}
+
+// newReviewer returns a reviewer that reads answers from in and writes
+// prompts to out.
+func newReviewer(in io.Reader, out io.Writer) *reviewer {
+	return &reviewer{in: bufio.NewReader(in), out: out}
+}
+
+// option returns a comment remover option that asks about each comment of
+// source, the input called inputName in prompts, before removing it.
+func (r *reviewer) option(inputName, source string) commentremover.Option {
+	lines := strings.Split(source, "\n")
+
+	var rest string // rest is "a" or "d" once an answer covers the rest of the file.
+
+	return commentremover.RemoveIf(func(first, last int) bool {
+		switch {
+		case r.quit || rest == "d":
+			return false
+		case rest == "a":
+			return true
+		}
+
+		r.show(inputName, lines, first, last)
+
+		for {
+			_, _ = fmt.Fprint(r.out, tr("Remove this comment [y,n,a,d,q,?]? "))
+
+			answer, err := r.in.ReadString('\n')
+			if err != nil && answer == "" {
+				_, _ = fmt.Fprintln(r.out)
+
+				r.quit = true
+
+				return false
+			}
+
+			switch strings.TrimSpace(answer) {
+			case "y":
+				return true
+			case "n":
+				return false
+			case "a", "d":
+				rest = strings.TrimSpace(answer)
+
+				return rest == "a"
+			case "q":
+				r.quit = true
+
+				return false
+			default:
+				_, _ = fmt.Fprint(r.out, tr(interactiveHelp))
+			}
+		}
+	})
+}
+
+// show prints the comment on lines first through last of lines, counting
+// from 1, with a few lines of context around it. The lines of the comment
+// are marked with a "-".
+func (r *reviewer) show(inputName string, lines []string, first, last int) {
+	_, _ = fmt.Fprintf(r.out, "%s:%d\n", inputName, first)
+
+	for line := max(first-contextLines, 1); line <= min(last+contextLines, len(lines)); line++ {
+		mark := ' '
+		if line >= first && line <= last {
+			mark = '-'
+		}
+
+		_, _ = fmt.Fprintf(r.out, "%c%5d  %s\n", mark, line, lines[line-1])
+	}
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/pierow2k/nogocomments/pkg/commentremover"
+	"github.com/spf13/pflag"
+)
+
+// inputPool removes the comments of several inputs at a time, on --jobs
+// worker goroutines, and hands out the outcomes in the order of the inputs.
+type inputPool struct {
+	ctx      context.Context     // ctx stops the pool once it is done.
+	paths    []string            // paths are the inputs.
+	outcomes []chan inputOutcome // outcomes deliver the outcome of each input.
+	slots    chan struct{}       // slots limit how far the workers run ahead of the outcomes taken.
+	group    sync.WaitGroup      // group waits for the workers and the goroutine feeding them.
+}
+
+// inputOptions are the options for an input, or the error from determining
+// them.
+type inputOptions struct {
+	opts []commentremover.Option
+	err  error
+}
+
+// startInputPool starts removing the comments of inputPaths on jobs
+// workers. The options of every input are determined first, since applying
+// the overrides for a file changes cfg for the time being. Once ctx is done,
+// no further inputs are started.
+func startInputPool(ctx context.Context, flags *pflag.FlagSet, inputPaths []string, jobs int) *inputPool {
+	options := make([]inputOptions, len(inputPaths))
+
+	for i, inputPath := range inputPaths {
+		options[i].opts, options[i].err = optionsFor(flags, inputPath)
+	}
+
+	pool := &inputPool{
+		ctx:      ctx,
+		paths:    inputPaths,
+		outcomes: make([]chan inputOutcome, len(inputPaths)),
+		slots:    make(chan struct{}, 2*jobs),
+	}
+
+	for i := range pool.outcomes {
+		pool.outcomes[i] = make(chan inputOutcome, 1)
+	}
+
+	next := make(chan int)
+
+	for range min(jobs, len(inputPaths)) {
+		pool.group.Go(func() {
+			for i := range next {
+				pool.outcomes[i] <- stripInput(inputPaths[i], options[i].opts, options[i].err)
+			}
+		})
+	}
+
+	pool.group.Go(func() {
+		defer close(next)
+
+		for i := range inputPaths {
+			select {
+			case pool.slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			next <- i
+		}
+	})
+
+	return pool
+}
+
+// outcome returns the outcome of the input with index i, waiting for it if
+// need be, and lets the workers start another input. Once the context of
+// the pool is done, an input that may never be started has an outcome
+// whose error wraps errStopped.
+func (pool *inputPool) outcome(i int) inputOutcome {
+	select {
+	case outcome := <-pool.outcomes[i]:
+		<-pool.slots
+
+		return outcome
+	case <-pool.ctx.Done():
+		return inputOutcome{
+			path: pool.paths[i],
+			name: pool.paths[i],
+			err:  fmt.Errorf("%w: %w", errStopped, context.Cause(pool.ctx)),
+		}
+	}
+}
+
+// wait waits for the workers to finish the inputs they started.
+func (pool *inputPool) wait() {
+	pool.group.Wait()
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pierow2k/nogocomments/pkg/commentremover"
+	"github.com/spf13/cobra"
+)
+
+// licensesColumns are the columns of the licenses command in the csv and
+// markdown formats.
+var licensesColumns = []string{"path", "header", "license", "first line", "last line"}
+
+// licensesLong is the long description of the licenses command. It doubles
+// as a message catalog key.
+const licensesLong = `licenses reports which of the Go source files under the given files and
+directories start with a copyright or license header, the header that is
+kept unless --strip-license-header is given, and which license it names,
+without changing anything. Directories are walked like the root command
+walks them. With --missing, only the files without a header are listed.`
+
+// licensesCmd audits license headers.
+var licensesCmd = &cobra.Command{
+	Use:   "licenses [INPUT_FILE|DIR...]",
+	Short: "Report which Go source files have a license header.",
+	Long:  licensesLong,
+	Args:  cobra.ArbitraryArgs,
+	RunE:  runLicenses,
+}
+
+// init registers the licenses command and its flags.
+func init() {
+	licensesCmd.Flags().BoolVar(&cfg.licensesMissing, "missing", false, "List only the files without a license header")
+	rootCmd.AddCommand(licensesCmd)
+}
+
+// licenseReport is the license header of an input, as printed by the
+// licenses command. The lines and license are empty if it has none.
+type licenseReport struct {
+	Path      string `json:"path"`
+	Found     bool   `json:"found"`
+	License   string `json:"license,omitempty"`
+	FirstLine int    `json:"firstLine,omitempty"`
+	LastLine  int    `json:"lastLine,omitempty"`
+}
+
+// runLicenses implements the licenses command, finding the license header
+// of each input and printing them to standard output in the
+// --report-format, along with, in text, how many of the inputs have one.
+func runLicenses(command *cobra.Command, args []string) error {
+	if err := validateReportFormat(); err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+
+	inputPaths, err := expandInputs(args)
+	if err != nil {
+		return err
+	}
+
+	reports := make([]licenseReport, 0, len(inputPaths))
+	found := 0
+
+	for _, inputPath := range inputPaths {
+		src, err := os.ReadFile(inputPath)
+		if err != nil {
+			return fmt.Errorf("file read failed: %w", err)
+		}
+
+		header, ok := commentremover.FindLicenseHeader(string(src))
+		if ok {
+			found++
+		}
+
+		if ok && cfg.licensesMissing {
+			continue
+		}
+
+		reports = append(reports, licenseReport{
+			Path:      slashPath(inputPath),
+			Found:     ok,
+			License:   header.License,
+			FirstLine: header.FirstLine,
+			LastLine:  header.LastLine,
+		})
+	}
+
+	switch cfg.format {
+	case formatJSON:
+		return writeJSON(command.OutOrStdout(), reports)
+	case formatCSV, formatMarkdown:
+		return writeTable(command.OutOrStdout(), licensesColumns, licensesRows(reports))
+	}
+
+	var out strings.Builder
+
+	_, _ = fmt.Fprintf(&out, "%-8s %-14s %-9s %s\n", tr("HEADER"), tr("LICENSE"), tr("LINES"), tr("FILE"))
+
+	for _, report := range reports {
+		status, license, lines := tr("missing"), "-", "-"
+		if report.Found {
+			status, license = tr("found"), cmp.Or(report.License, "?")
+			lines = fmt.Sprintf("%d-%d", report.FirstLine, report.LastLine)
+		}
+
+		_, _ = fmt.Fprintf(&out, "%-8s %-14s %-9s %s\n", status, license, lines, report.Path)
+	}
+
+	_, _ = fmt.Fprintln(&out, tr("%d files: %d with a license header, %d without",
+		len(inputPaths), found, len(inputPaths)-found))
+
+	_, err = fmt.Fprint(command.OutOrStdout(), out.String())
+
+	return err //nolint:wrapcheck // Writing to standard output.
+}
+
+// licensesRows returns the rows of reports in the csv and markdown formats,
+// with the lines left empty for the files without a header.
+func licensesRows(reports []licenseReport) [][]string {
+	rows := make([][]string, 0, len(reports))
+
+	for _, report := range reports {
+		firstLine, lastLine := "", ""
+		if report.Found {
+			firstLine, lastLine = strconv.Itoa(report.FirstLine), strconv.Itoa(report.LastLine)
+		}
+
+		rows = append(rows, []string{
+			report.Path, strconv.FormatBool(report.Found), report.License, firstLine, lastLine,
+		})
+	}
+
+	return rows
+}
@@ -0,0 +1,427 @@
+package cmd
+
+// messagesDE is the German message catalog.
+var messagesDE = map[string]string{
+	// Command help.
+	"Remove comments from Go source code.": "Entfernt Kommentare aus Go-Quellcode.",
+	rootLong: `nogocomments entfernt Kommentare aus Go-Quellcode.
+Es liest Go-Code aus einer Datei oder der Zwischenablage und schreibt
+das Ergebnis auf die Standardausgabe. Es unterstützt sowohl vollständige
+Pakete als auch einzelne Codeausschnitte.`,
+	rootExample: `  # Kommentare aus einer Datei entfernen
+  nogocomments somecode.go
+
+  # Kommentare aus allen Go-Dateien unter einem Verzeichnis direkt entfernen
+  nogocomments --write --exclude '**/*_test.go' ./pkg
+
+  # Kommentare aus Code in der Zwischenablage entfernen
+  nogocomments --paste`,
+
+	"Report where comments are in Go source code.": "Zeigt, wo sich Kommentare im Go-Quellcode befinden.",
+	"Describe the supported options, formats, and protocols.": "Beschreibt die unterstützten Optionen, " +
+		"Formate und Protokolle.",
+	capabilitiesLong: `capabilities beschreibt die Befehle, Optionen, Ausgabeformate, Protokolle
+und Sprachen, die dieser Build unterstützt. Wrapper und Editor-Plugins können
+die Ausgabe mit --json lesen, um Funktionen zu erkennen, statt Versionsangaben
+zu parsen.`,
+	"Print the capabilities as JSON": "Die Fähigkeiten als JSON ausgeben",
+	"Measure how fast comments are removed from Go source code.": "Misst, wie schnell Kommentare aus " +
+		"Go-Quellcode entfernt werden.",
+	benchLong: `bench entfernt die Kommentare aus den Go-Dateien unter den angegebenen
+Dateien und Verzeichnissen mehrmals hintereinander, ohne etwas zu ändern, und
+zeigt den Durchsatz jeder Engine und jedes Optionssatzes. Verzeichnisse werden
+wie beim Hauptbefehl durchlaufen. Jedes --set ist ein JSON-Objekt mit
+Einstellungen, nach langem Optionsnamen wie in der Konfiguration, etwa
+'{"keep-doc": true}'.`,
+	statsLong: `stats zeigt, welcher Anteil des Go-Quellcodes unter den angegebenen Dateien
+und Verzeichnissen aus Kommentaren besteht, ohne etwas zu ändern.
+Verzeichnisse werden wie beim Hauptbefehl durchlaufen. Mit --heatmap werden
+die Summen nach Verzeichnissen aufgeschlüsselt, die größte Häufung von
+Kommentarzeilen zuerst; mit --packages nach Paketen und mit --files nach
+Dateien.`,
+	"Report which Go source files have a license header.": "Zeigt, welche Go-Quelldateien " +
+		"einen Lizenzkopf haben.",
+	licensesLong: `licenses zeigt, welche der Go-Quelldateien unter den angegebenen Dateien und
+Verzeichnissen mit einem Copyright- oder Lizenzkopf beginnen, dem Kopf, der
+ohne --strip-license-header erhalten bleibt, und welche Lizenz er nennt, ohne
+etwas zu ändern. Verzeichnisse werden wie beim Hauptbefehl durchlaufen. Mit
+--missing werden nur die Dateien ohne Kopf aufgeführt.`,
+	"Fail if Go source code holds comments.": "Schlägt fehl, wenn der Go-Quellcode " +
+		"Kommentare enthält.",
+	checkLong: `check listet die Kommentare der Go-Quelldateien unter den angegebenen Dateien
+und Verzeichnissen auf, die der Hauptbefehl entfernen würde, ohne etwas zu
+ändern, und schlägt fehl, wenn es welche gibt, damit generierter Code und
+gespiegelte Abhängigkeiten frei von Kommentaren bleiben. Direktiven und
+Lizenzköpfe sind erlaubt, Letztere nur ohne --strip-license-header.
+Verzeichnisse werden wie beim Hauptbefehl durchlaufen.`,
+
+	"Help about any command": "Hilfe zu einem Befehl",
+	"Help provides help for any command in the application.\n" +
+		"Simply type nogocomments help [path to command] for full details.": "help zeigt die Hilfe zu jedem " +
+		"Befehl der Anwendung an.\nGeben Sie nogocomments help [Befehlspfad] ein, um alle Details zu sehen.",
+
+	// Flag descriptions.
+	"Show help": "Hilfe anzeigen",
+	"Show version, build details, and license": "Version, Build-Details und Lizenz anzeigen",
+	"Read code from the system clipboard":      "Code aus der Zwischenablage lesen",
+	"Delete comments in place without reformatting the remaining code": "Kommentare direkt löschen, " +
+		"ohne den übrigen Code neu zu formatieren",
+	"Page long output through $PAGER: auto, never, or always": "Lange Ausgaben über $PAGER anzeigen: " +
+		"auto, never oder always",
+	"Colorize the output when writing to a terminal": "Ausgabe im Terminal farbig hervorheben",
+	"Language of messages (default taken from LANG)": "Sprache der Meldungen (Standard aus LANG)",
+	"Remove //go:build and // +build constraints":    "Build-Bedingungen (//go:build und // +build) entfernen",
+	"Remove //go:generate directives":                "//go:generate-Direktiven entfernen",
+	"Remove //go:embed directives":                   "//go:embed-Direktiven entfernen",
+	`Remove the cgo preamble preceding import "C"`:   `cgo-Präambel vor import "C" entfernen`,
+	"Remove //export directives from cgo files":      "//export-Direktiven aus cgo-Dateien entfernen",
+	"Remove //sys and //sysnb syscall generation directives": "//sys- und //sysnb-Direktiven " +
+		"zur Syscall-Generierung entfernen",
+	"Write output even if removing directives changes program behavior": "Ausgabe auch schreiben, " +
+		"wenn das Entfernen von Direktiven das Programmverhalten ändert",
+	"Keep the doc comments of exported types, functions, methods, constants, and variables": "Doc-Kommentare " +
+		"exportierter Typen, Funktionen, Methoden, Konstanten und Variablen behalten",
+	"Break the statistics down by directory": "Statistik nach Verzeichnissen aufschlüsseln",
+	"Break the statistics down by package":   "Statistik nach Paketen aufschlüsseln",
+	"Break the statistics down by file":      "Statistik nach Dateien aufschlüsseln",
+	"Output format: text or json":            "Ausgabeformat: text oder json",
+	"Keep the package doc comment preceding the package clause": "Paketdokumentation vor der " +
+		"package-Klausel behalten",
+	"Clean code that does not parse or tokenize as far as possible instead of failing": "Code, der sich " +
+		"nicht parsen oder zerlegen lässt, so weit wie möglich bereinigen, statt abzubrechen",
+	"Clean the declarations that parse, leaving those with syntax errors unchanged": "Die parsbaren " +
+		"Deklarationen bereinigen und solche mit Syntaxfehlern unverändert lassen",
+	"%s: lines %d-%d left unchanged, they do not parse: %s": "%s: Zeilen %d-%d unverändert gelassen, " +
+		"da sie sich nicht parsen lassen: %s",
+	"invalid format (want text, json, csv, or markdown)": "ungültiges Format (erwartet text, json, csv oder markdown)",
+	"Format of statistics, benchmarks, and the --summary: text, json, csv, or markdown": "Format von " +
+		"Statistiken, Benchmarks und der --summary: text, json, csv oder markdown",
+	"comments removed/kept by category: license %d/%d, directive %d/%d, todo %d/%d, doc %d/%d, " +
+		"inline %d/%d, block %d/%d, line %d/%d": "entfernte/behaltene Kommentare nach Kategorie: Lizenz %d/%d, " +
+		"Direktive %d/%d, Aufgabe %d/%d, Doku %d/%d, Zeilenende %d/%d, Block %d/%d, Zeile %d/%d",
+	"Print the bytes and lines saved in each input, largest savings first, to standard error after a run": "Nach " +
+		"einem Lauf die in jeder Eingabe gesparten Bytes und Zeilen auf der Standardfehlerausgabe ausgeben, " +
+		"die größten Einsparungen zuerst",
+	"Report a leading copyright or license header as well": "Auch einen Copyright- oder Lizenzkopf " +
+		"am Anfang melden",
+	"comments found in some files": "Kommentare in einigen Dateien gefunden",
+	"List only the files without a license header": "Nur die Dateien ohne Lizenzkopf " +
+		"aufführen",
+	"%d files: %d with a license header, %d without": "%d Dateien: %d mit Lizenzkopf, " +
+		"%d ohne",
+	"Mask the identifiers, literals, and comments of the input saved with a crash report": "Bezeichner, " +
+		"Literale und Kommentare der mit einem Absturzbericht gespeicherten Eingabe maskieren",
+	"Write a JSON run report to a file": "Einen JSON-Laufbericht in eine Datei schreiben",
+	"Fail unless the package of each input file still builds with the output in place of the file": "Fehlschlagen, " +
+		"sofern sich das Paket jeder Eingabedatei nicht mit der Ausgabe anstelle der Datei bauen lässt",
+	"Fail unless the output parses to the syntax tree of the input but for comments and positions": "Fehlschlagen, " +
+		"sofern die Ausgabe bis auf Kommentare und Positionen nicht den Syntaxbaum der Eingabe ergibt",
+	"1 syntax error":                           "1 Syntaxfehler",
+	"%d syntax errors":                         "%d Syntaxfehler",
+	"failed to write crash report":             "Absturzbericht konnte nicht geschrieben werden",
+	"crash report written to %s":               "Absturzbericht in %s geschrieben",
+	"Keep //nolint lint-suppression comments":  "//nolint-Kommentare zur Unterdrückung von Lint-Meldungen behalten",
+	"Reject input that contains invalid UTF-8": "Eingaben mit ungültigem UTF-8 zurückweisen",
+	"Encoding of the input: auto, utf-8, utf-16le, utf-16be, or latin1, decoded to UTF-8 before parsing": "Kodierung " +
+		"der Eingabe: auto, utf-8, utf-16le, utf-16be oder latin1, vor dem Parsen nach UTF-8 dekodiert",
+	"Write the output in the encoding of the input instead of UTF-8": "Die Ausgabe in der Kodierung der " +
+		"Eingabe statt in UTF-8 schreiben",
+	"%s: decoded from %s to UTF-8": "%s: von %s nach UTF-8 dekodiert",
+	"Leave output files that already hold the result untouched, keeping their modification times": "Ausgabedateien, " +
+		"die das Ergebnis bereits enthalten, unverändert lassen und ihre Änderungszeit erhalten",
+	"Rewrite output files even if they already hold the result, updating their modification times": "Ausgabedateien " +
+		"auch dann neu schreiben, wenn sie das Ergebnis bereits enthalten, und ihre Änderungszeit aktualisieren",
+	"Keep comments matching this regular expression (repeatable)": "Kommentare behalten, die diesem " +
+		"regulären Ausdruck entsprechen (wiederholbar)",
+	"Remove only comments matching this regular expression (repeatable)": "Nur Kommentare entfernen, die " +
+		"diesem regulären Ausdruck entsprechen (wiederholbar)",
+	`Keep "Deprecated:" notices in comments (implied by --keep-doc)`: "„Deprecated:“-Hinweise in " +
+		"Kommentaren behalten (durch --keep-doc impliziert)",
+	"Keep @ annotations for code generators, such as // @Router for swaggo": "@-Annotationen für " +
+		"Codegeneratoren behalten, etwa // @Router für swaggo",
+	"Remove only TODO, FIXME, and HACK comments, keeping all others": "Nur TODO-, FIXME- und " +
+		"HACK-Kommentare entfernen und alle anderen behalten",
+	"Keep TODO, FIXME, and HACK comments": "TODO-, FIXME- und HACK-Kommentare behalten",
+	"Remove only // line comments, keeping /* */ block comments": "Nur //-Zeilenkommentare entfernen und " +
+		"/* */-Blockkommentare behalten",
+	"Remove only /* */ block comments, keeping // line comments": "Nur /* */-Blockkommentare entfernen und " +
+		"//-Zeilenkommentare behalten",
+	"Remove only comments inside function and method bodies, keeping those of declarations": "Nur " +
+		"Kommentare in Funktions- und Methodenrümpfen entfernen und die der Deklarationen behalten",
+	"Remove only comments beginning on lines START:END, or on line N (repeatable)": "Nur Kommentare " +
+		"entfernen, die in den Zeilen START:END oder in Zeile N beginnen (wiederholbar)",
+	"Remove only comments last changed before an age such as 2y, 6m, or 3w, or a date, per git blame": "Nur " +
+		"Kommentare entfernen, die laut git blame vor einem Alter wie 2y, 6m oder 3w oder einem Datum " +
+		"zuletzt geändert wurden",
+	"Remove only comments last changed by authors matching a regular expression, per git blame": "Nur " +
+		"Kommentare entfernen, die laut git blame zuletzt von Autoren geändert wurden, auf die ein " +
+		"regulärer Ausdruck passt",
+	"Show each comment in context and ask whether to remove it, like git add -p": "Jeden Kommentar im " +
+		"Kontext zeigen und wie bei git add -p fragen, ob er entfernt werden soll",
+	"Remove this comment [y,n,a,d,q,?]? ": "Diesen Kommentar entfernen [y,n,a,d,q,?]? ",
+	interactiveHelp: `y - diesen Kommentar entfernen
+n - diesen Kommentar behalten
+a - diesen und alle weiteren Kommentare der Datei entfernen
+d - diesen und alle weiteren Kommentare der Datei behalten
+q - diesen und alle weiteren Kommentare behalten
+? - Hilfe anzeigen
+`,
+	"Fail unless a second pass over the input and a pass over the output give the same result": "Fehlschlagen, " +
+		"sofern ein zweiter Durchlauf über die Eingabe und ein Durchlauf über die Ausgabe nicht dasselbe Ergebnis liefern",
+	"Print the comments, lines, bytes, and estimated tokens removed from each input to standard error": "Die " +
+		"Anzahl der aus jeder Eingabe entfernten Kommentare, Zeilen, Bytes und geschätzten Token auf der " +
+		"Standardfehlerausgabe ausgeben",
+	"Print the totals of the run to standard error: files, comments, lines, bytes, and estimated tokens removed, " +
+		"and time taken": "Die Summen des Laufs auf der Standardfehlerausgabe ausgeben: Dateien, entfernte " +
+		"Kommentare, Zeilen, Bytes und geschätzte Token sowie die benötigte Zeit",
+	"Number of input files to process at the same time": "Anzahl der gleichzeitig verarbeiteten Eingabedateien",
+	"Do not show the number of files done on standard error when processing several files": "Beim " +
+		"Verarbeiten mehrerer Dateien die Zahl der fertigen Dateien nicht auf der Standardfehlerausgabe anzeigen",
+	"Reuse the results for files with unchanged content and options from a cache in this directory": "Ergebnisse " +
+		"für Dateien mit unverändertem Inhalt und gleichen Optionen aus einem Cache in diesem Verzeichnis übernehmen",
+	"Process only files modified after a time, the modification time of a file, an age, or a date": "Nur " +
+		"Dateien verarbeiten, die nach einer Zeit, der Änderungszeit einer Datei, einem Alter oder Datum geändert wurden",
+	"Process only files modified since the last successful run recorded in this file, then record this run": "Nur " +
+		"seit dem in dieser Datei festgehaltenen letzten erfolgreichen Lauf geänderte Dateien verarbeiten, " +
+		"dann diesen Lauf festhalten",
+	"Serve net/http/pprof at this address, such as localhost:6060, with --serve-stdio": "Mit --serve-stdio " +
+		"net/http/pprof unter dieser Adresse bereitstellen, etwa localhost:6060",
+	"Engine that removes comments: auto, ast, or scanner, which deletes them without reprinting the code": "Verfahren " +
+		"zum Entfernen der Kommentare: auto, ast oder scanner, das sie löscht, ohne den Code neu zu drucken",
+	"Select the comments to remove on a full-screen list with a diff preview": "Die zu entfernenden " +
+		"Kommentare in einer Vollbildliste mit Diff-Vorschau auswählen",
+	"%d/%d files, %d errors":                     "%d/%d Dateien, %d Fehler",
+	"%s: %d of %d comments selected for removal": "%s: %d von %d Kommentaren zum Entfernen ausgewählt",
+	"serving pprof at http://%s/debug/pprof/":    "pprof wird unter http://%s/debug/pprof/ bereitgestellt",
+	"↑/↓ scroll  d back  enter apply  q quit":    "↑/↓ blättern  d zurück  Enter anwenden  q beenden",
+	"↑/↓ move  space toggle  a all  n none  d diff  enter apply  q quit": "↑/↓ bewegen  Leertaste umschalten  " +
+		"a alle  n keine  d Diff  Enter anwenden  q beenden",
+	"(+%d lines)": "(+%d Zeilen)",
+	"No changes.": "Keine Änderungen.",
+	"Choose comments on the parsed code but delete them in place, leaving all other bytes unchanged": "Kommentare " +
+		"anhand des geparsten Codes auswählen, aber an Ort und Stelle löschen und alle anderen Bytes unverändert lassen",
+	"Collapse runs of blank lines in the output to a single blank line": "Folgen von Leerzeilen in der " +
+		"Ausgabe zu einer einzigen Leerzeile zusammenfassen",
+	"Leave lines that held only comments empty, so every line keeps its line number": "Zeilen, die nur " +
+		"Kommentare enthielten, leer lassen, damit jede Zeile ihre Zeilennummer behält",
+	"Write a JSON map from output to input positions to a file named by a template, " +
+		"e.g. '{{.Dir}}/{{.Name}}.map'": "Eine " +
+		"JSON-Zuordnung von Ausgabe- zu Eingabepositionen in eine per Vorlage benannte Datei schreiben, " +
+		"z. B. '{{.Dir}}/{{.Name}}.map'",
+	"Line breaks of the output: lf, crlf, or preserve to keep those of the input": "Zeilenumbrüche der " +
+		"Ausgabe: lf, crlf oder preserve, um die der Eingabe beizubehalten",
+	"Formatting rules of reprinted code: gofmt or the stricter gofumpt": "Formatierungsregeln für neu " +
+		"ausgegebenen Code: gofmt oder das strengere gofumpt",
+	"Indentation of reprinted code: tabs or spaces": "Einrückung von neu ausgegebenem Code: tabs oder spaces",
+	"Width of a tab when aligning reprinted code, and of each indentation level with --indent spaces": "Breite " +
+		"eines Tabulators beim Ausrichten von neu ausgegebenem Code und jeder Einrückungsebene mit --indent spaces",
+	"Remove a UTF-8 byte order mark from the start of the output": "Eine UTF-8-Bytereihenfolgemarkierung " +
+		"am Anfang der Ausgabe entfernen",
+	"Keep end-of-line comments on struct fields and constants": "Zeilenendkommentare an Strukturfeldern " +
+		"und Konstanten behalten",
+	"Remove //line and /*line*/ position directives": "//line- und /*line*/-Positionsdirektiven entfernen",
+	"Remove all directives: build constraints, //go:, cgo, //export, //sys, and //line": "Alle Direktiven " +
+		"entfernen: Build-Bedingungen, //go:, cgo, //export, //sys und //line",
+	"Remove SPDX-License-Identifier comments":      "SPDX-License-Identifier-Kommentare entfernen",
+	"Remove a leading copyright or license header": "Einen einleitenden Copyright- oder Lizenzkopf entfernen",
+	"Answer length-prefixed editor requests on standard input and output": "Editor-Anfragen mit " +
+		"Längenpräfix über Standardein- und -ausgabe beantworten",
+	"Number of times to process every file":     "Wie oft jede Datei verarbeitet wird",
+	"Engines to measure: auto, ast, or scanner": "Zu messende Engines: auto, ast oder scanner",
+	"Measure with the settings of this JSON object (repeatable; default options if not given)": "Mit den " +
+		"Einstellungen dieses JSON-Objekts messen (wiederholbar; ohne Angabe Standardoptionen)",
+	"Write a CPU profile to a file":               "Ein CPU-Profil in eine Datei schreiben",
+	"Write a memory allocation profile to a file": "Ein Profil der Speicherbelegung in eine Datei schreiben",
+	"Remove //go: compiler directives such as //go:noinline and //go:linkname": "//go:-Compilerdirektiven " +
+		"wie //go:noinline und //go:linkname entfernen",
+	"Read default flag values from a JSON file": "Standardwerte der Optionen aus einer JSON-Datei lesen",
+	"Write each result to DIR/<input path>.golden, ending in a single newline": "Jedes Ergebnis nach " +
+		"DIR/<Eingabepfad>.golden schreiben, mit genau einem abschließenden Zeilenumbruch",
+	"Write the result back to the input file": "Ergebnis in die Eingabedatei zurückschreiben",
+	"Only process files in directories that match these globs (default **/*.go)": "In Verzeichnissen nur " +
+		"Dateien verarbeiten, die auf diese Muster passen (Standard **/*.go)",
+	"Skip files and directories in directories that match these globs": "Dateien und Verzeichnisse " +
+		"überspringen, die auf diese Muster passen",
+	"Only process files in directories that the go command builds with these build tags and the GOOS and GOARCH": "Nur " +
+		"Dateien in Verzeichnissen verarbeiten, die der go-Befehl mit diesen Build-Tags und GOOS und GOARCH baut",
+	"Process files in directories even if a .gitignore file ignores them": "Dateien in Verzeichnissen " +
+		"auch verarbeiten, wenn eine .gitignore-Datei sie ignoriert",
+	`Process files in directories marked "Code generated ... DO NOT EDIT."`: "Als generiert markierte " +
+		`Dateien in Verzeichnissen („Code generated ... DO NOT EDIT.") verarbeiten`,
+	"Write the result to a file named by a template, e.g. '{{.Dir}}/{{.Base}}_clean{{.Ext}}'": "Ergebnis in eine " +
+		"per Vorlage benannte Datei schreiben, z. B. '{{.Dir}}/{{.Base}}_clean{{.Ext}}'",
+	"Print nothing; report the result through the exit status only": "Nichts ausgeben; " +
+		"das Ergebnis nur über den Exit-Status melden",
+
+	// Usage template headings.
+	"Usage:":                  "Aufruf:",
+	"Aliases:":                "Aliase:",
+	"Examples:":               "Beispiele:",
+	"Available Commands:":     "Verfügbare Befehle:",
+	"Additional Commands:":    "Weitere Befehle:",
+	"Flags:":                  "Optionen:",
+	"Global Flags:":           "Globale Optionen:",
+	"Additional help topics:": "Weitere Hilfethemen:",
+	`Use "{{.CommandPath}} [command] --help" for more information about a command.`: `Verwenden Sie ` +
+		`"{{.CommandPath}} [Befehl] --help" für weitere Informationen zu einem Befehl.`,
+
+	// Errors.
+	"Error:":                                "Fehler:",
+	"paste and file are mutually exclusive": "--paste und eine Eingabedatei schließen sich gegenseitig aus",
+	"no input method specified":             "keine Eingabemethode angegeben",
+	"multiple input files require --write, --out-template, --golden-dir, or --silent": "mehrere " +
+		"Eingabedateien erfordern --write, --out-template, --golden-dir oder --silent",
+	"processing failed for some files": "Verarbeitung einiger Dateien fehlgeschlagen",
+	"empty input":                      "leere Eingabe",
+	"processing stopped":               "Verarbeitung abgebrochen",
+	"context canceled":                 "Kontext abgebrochen",
+	"context deadline exceeded":        "Frist des Kontexts überschritten",
+	"no Go files found":                "keine Go-Dateien gefunden",
+	"failed to list input files":       "Auflisten der Eingabedateien fehlgeschlagen",
+	"invalid override":                 "ungültige Ausnahme",
+	"invalid path glob":                "ungültiges Pfadmuster",
+	"invalid pager mode (want auto, never, or always)": "ungültiger Pager-Modus " +
+		"(erwartet auto, never oder always)",
+	"failed to read from clipboard":         "Lesen aus der Zwischenablage fehlgeschlagen",
+	"file read failed":                      "Lesen der Datei fehlgeschlagen",
+	"failed to remove comments from source": "Entfernen der Kommentare fehlgeschlagen",
+	"failed to run pager":                   "Starten des Pagers fehlgeschlagen",
+	"error parsing source code":             "Fehler beim Parsen des Quellcodes",
+	"error formatting source code":          "Fehler beim Formatieren des Quellcodes",
+	"unknown flag":                          "unbekannte Option",
+	"unknown shorthand flag":                "unbekannte Kurzoption",
+	"--write, --out-template, and --golden-dir require an input file": "--write, --out-template und " +
+		"--golden-dir erfordern eine Eingabedatei",
+	"--write, --out-template, and --golden-dir are mutually exclusive": "--write, --out-template und " +
+		"--golden-dir schließen sich gegenseitig aus",
+	"input file is outside the working directory": "Eingabedatei liegt außerhalb des Arbeitsverzeichnisses",
+	"invalid output template":                     "ungültige Ausgabevorlage",
+	"failed to write report":                      "Schreiben des Berichts fehlgeschlagen",
+	"failed to write output":                      "Schreiben der Ausgabe fehlgeschlagen",
+	"--serve-stdio does not take inputs or an output destination": "--serve-stdio akzeptiert keine " +
+		"Eingaben und kein Ausgabeziel",
+	"invalid message header": "ungültiger Nachrichtenkopf",
+	"--only-line-comments and --only-block-comments are mutually exclusive": "--only-line-comments und " +
+		"--only-block-comments schließen sich gegenseitig aus",
+	"invalid age (want a number with unit y, m, w, or d, or a YYYY-MM-DD date)": "ungültiges Alter " +
+		"(erwartet eine Zahl mit Einheit y, m, w oder d oder ein Datum YYYY-MM-DD)",
+	"--older-than and --author require an input file": "--older-than und --author erfordern eine Eingabedatei",
+	"invalid author pattern":                          "ungültiges Autorenmuster",
+	"--interactive cannot be combined with --serve-stdio or --silent": "--interactive kann nicht mit " +
+		"--serve-stdio oder --silent kombiniert werden",
+	"--review cannot be combined with --interactive, --serve-stdio, or --silent": "--review kann nicht mit " +
+		"--interactive, --serve-stdio oder --silent kombiniert werden",
+	"--verify cannot be combined with --lines, --older-than, --author, --interactive, or --review": "--verify " +
+		"kann nicht mit --lines, --older-than, --author, --interactive oder --review kombiniert werden",
+	"--review requires a terminal": "--review erfordert ein Terminal",
+	"review cancelled":             "Überprüfung abgebrochen",
+	"git blame failed":             "git blame fehlgeschlagen",
+	"verification failed":          "Verifizierung fehlgeschlagen",
+	"second pass over the input":   "zweiter Durchlauf über die Eingabe",
+	"pass over the output":         "Durchlauf über die Ausgabe",
+	"--preserve-lines and --squeeze-blank are mutually exclusive": "--preserve-lines und --squeeze-blank " +
+		"schließen sich gegenseitig aus",
+	"--source-map requires an input file":              "--source-map erfordert eine Eingabedatei",
+	"failed to write source map":                       "Schreiben der Quellzuordnung fehlgeschlagen",
+	"invalid line ending (want lf, crlf, or preserve)": "ungültiges Zeilenende (erwartet lf, crlf oder preserve)",
+	"invalid line range":                               "ungültiger Zeilenbereich",
+	"invalid indentation (want tabs or spaces)":        "ungültige Einrückung (erwartet tabs oder spaces)",
+	"invalid tab width (want a positive number)":       "ungültige Tabulatorbreite (erwartet eine positive Zahl)",
+	"invalid number of jobs (want a positive number)":  "ungültige Anzahl von Jobs (erwartet eine positive Zahl)",
+	"invalid style (want gofmt or gofumpt)":            "ungültiger Stil (erwartet gofmt oder gofumpt)",
+	"invalid engine (want auto, ast, or scanner)":      "ungültige Engine (erwartet auto, ast oder scanner)",
+	"--preserve-format requires --engine scanner":      "--preserve-format erfordert --engine scanner",
+	"--since and --state require input files":          "--since und --state erfordern Eingabedateien",
+	"--since and --state are mutually exclusive":       "--since und --state schließen sich gegenseitig aus",
+	"invalid state file (want an RFC 3339 time)":       "ungültige Statusdatei (erwartet eine Zeit nach RFC 3339)",
+	"failed to read state file":                        "Lesen der Statusdatei fehlgeschlagen",
+	"failed to write state file":                       "Schreiben der Statusdatei fehlgeschlagen",
+	"failed to write CPU profile":                      "Schreiben des CPU-Profils fehlgeschlagen",
+	"failed to write memory profile":                   "Schreiben des Speicherprofils fehlgeschlagen",
+	"failed to serve pprof":                            "Bereitstellen von pprof fehlgeschlagen",
+	"--pprof-addr requires --serve-stdio":              "--pprof-addr erfordert --serve-stdio",
+	"invalid comment pattern":                          "ungültiges Kommentarmuster",
+	"invalid request":                                  "ungültige Anfrage",
+	"invalid selection":                                "ungültige Auswahl",
+	"invalid UTF-8":                                    "ungültiges UTF-8",
+	"failed to read configuration":                     "Lesen der Konfiguration fehlgeschlagen",
+	"invalid configuration":                            "ungültige Konfiguration",
+	"unknown configuration key":                        "unbekannter Konfigurationsschlüssel",
+	"invalid configuration value":                      "ungültiger Konfigurationswert",
+	"built-in configuration":                           "integrierte Konfiguration",
+	"flag needs an argument":                           "Option benötigt ein Argument",
+	"%s: used %s engine: %s":                           "%s: %s-Engine verwendet: %s",
+	"parse failed":                                     "Parsen fehlgeschlagen",
+	"input exceeds AST size limit":                     "Eingabe überschreitet die Größengrenze für den AST",
+	"clipboard":                                        "Zwischenablage",
+	"Version:":                                         "Version:",
+	"Command:":                                         "Befehl:",
+	"Formats:":                                         "Formate:",
+	"Protocols:":                                       "Protokolle:",
+	"Languages:":                                       "Sprachen:",
+	"DIRECTORY":                                        "VERZEICHNIS",
+	"ENGINE":                                           "ENGINE",
+	"MB/S":                                             "MB/S",
+	"FILES/S":                                          "DATEIEN/S",
+	"FAILED":                                           "FEHLER",
+	"OPTIONS":                                          "OPTIONEN",
+	"invalid number of runs (want a positive number)":  "ungültige Anzahl von Durchläufen (erwartet eine positive Zahl)",
+	"invalid option set":                               "ungültiger Optionssatz",
+	"FILES":                                            "DATEIEN",
+	"COMMENTS":                                         "KOMMENTARE",
+	"LINES":                                            "ZEILEN",
+	"PACKAGE":                                          "PAKET",
+	"FILE":                                             "DATEI",
+	"CODE":                                             "CODE",
+	"RATIO":                                            "VERHÄLTNIS",
+	"DOCS":                                             "DOKU",
+	"KIND":                                             "ART",
+	"PATH":                                             "PFAD",
+	"DENSITY":                                          "DICHTE",
+	"DOCUMENTED":                                       "DOKUMENTIERT",
+	"EXPORTED":                                         "EXPORTIERT",
+	"ERROR":                                            "FEHLER",
+	"REMOVED":                                          "ENTFERNT",
+	"LINE COMMENTS":                                    "ZEILENKOMMENTARE",
+	"BLOCK COMMENTS":                                   "BLOCKKOMMENTARE",
+	"DOC COMMENTS":                                     "DOKU-KOMMENTARE",
+	"DIRECTIVES KEPT":                                  "BEHALTENE DIREKTIVEN",
+	"LINES REMOVED":                                    "ENTFERNTE ZEILEN",
+	"BYTES SAVED":                                      "GESPARTE BYTES",
+	"BYTES":                                            "BYTES",
+	"HEADER":                                           "KOPF",
+	"LICENSE":                                          "LIZENZ",
+	"FIRST LINE":                                       "ERSTE ZEILE",
+	"LAST LINE":                                        "LETZTE ZEILE",
+	"found":                                            "gefunden",
+	"missing":                                          "fehlt",
+	"LINE":                                             "ZEILE",
+	"COLUMN":                                           "SPALTE",
+	"COMMENT":                                          "KOMMENTAR",
+	"RUNS":                                             "DURCHLÄUFE",
+	"SECONDS":                                          "SEKUNDEN",
+	"INPUT TOKENS":                                     "EINGABE-TOKEN",
+	"OUTPUT TOKENS":                                    "AUSGABE-TOKEN",
+	"SAVED":                                            "GESPART",
+	"PERCENT SAVED":                                    "PROZENT GESPART",
+	"INPUT BYTES":                                      "EINGABE-BYTES",
+	"CATEGORY":                                         "KATEGORIE",
+	"KEPT":                                             "BEHALTEN",
+	"%s: removed %d comments (%d line, %d block, %d doc), %d lines, %d bytes, ~%d of %d tokens; " +
+		"kept %d directives": "%s: %d Kommentare (%d Zeilen-, %d Block-, %d Doku-), %d Zeilen, %d Bytes, " +
+		"~%d von %d Token entfernt; %d Direktiven behalten",
+	"%d files, %d failed: removed %d comments (%d line, %d block, %d doc), %d lines, %d bytes, " +
+		"~%d of %d tokens; kept %d directives; took %s": "%d Dateien, %d fehlgeschlagen: %d Kommentare " +
+		"(%d Zeilen-, %d Block-, %d Doku-), %d Zeilen, %d Bytes, ~%d von %d Token entfernt; " +
+		"%d Direktiven behalten; Dauer %s",
+	"refusing to write output that changes program behavior (use --force)": "Ausgabe, die das " +
+		"Programmverhalten ändert, wird nicht geschrieben (--force verwenden)",
+	"%s:%d: warning: removing %s changes program behavior": "%s:%d: Warnung: %s entfernt; " +
+		"das ändert das Programmverhalten",
+	"build constraint":                        "Build-Bedingung",
+	"embed directive":                         "embed-Direktive",
+	"cgo preamble":                            "cgo-Präambel",
+	"cgo export directive":                    "cgo-export-Direktive",
+	"line directive":                          "line-Direktive",
+	"unsupported language, using English: %s": "nicht unterstützte Sprache, verwende Englisch: %s",
+}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Permissions for files and directories created by the tool.
+const (
+	outputFileMode = 0o644
+	outputDirMode  = 0o755
+)
+
+// maxOpenFiles is the number of files that may be open at the same time
+// for reading inputs and writing results, whatever --jobs asks for, so that
+// a run over a large tree stays well below the limit on open file
+// descriptors of the process, commonly 256 or 1024.
+const maxOpenFiles = 64
+
+// openFiles holds a token for each file opened through acquireFile.
+var openFiles = make(chan struct{}, maxOpenFiles)
+
+// acquireFile waits until fewer than maxOpenFiles files are open and
+// returns the function to call once the file about to be opened is closed
+// again.
+func acquireFile() func() {
+	openFiles <- struct{}{}
+
+	return func() { <-openFiles }
+}
+
+var (
+	// errOutputNeedsFile is returned when --write, --out-template, or
+	// --golden-dir is used with input that does not come from a file.
+	errOutputNeedsFile = errors.New("--write, --out-template, and --golden-dir require an input file")
+
+	// errOutputConflict is returned when more than one of --write,
+	// --out-template, and --golden-dir is specified.
+	errOutputConflict = errors.New("--write, --out-template, and --golden-dir are mutually exclusive")
+
+	// errOutsideGoldenRoot is returned when an input file for --golden-dir
+	// lies outside the working directory, so it has no stable golden name.
+	errOutsideGoldenRoot = errors.New("input file is outside the working directory")
+
+	// errInvalidTemplate is returned when --out-template cannot be parsed or
+	// executed.
+	errInvalidTemplate = errors.New("invalid output template")
+)
+
+// outputName holds the fields available to --out-template, derived from
+// the input file path. For "src/pkg/file.go" they are Dir "src/pkg", Name
+// "file.go", Base "file", and Ext ".go".
+type outputName struct {
+	Dir  string // Dir is the directory of the input file.
+	Name string // Name is the file name of the input, including its extension.
+	Base string // Base is the file name of the input without its extension.
+	Ext  string // Ext is the extension of the input file, including the dot.
+}
+
+// outputPath expands the --out-template text for inputPath.
+func outputPath(text, inputPath string) (string, error) {
+	tmpl, err := template.New("out-template").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidTemplate, err)
+	}
+
+	name := filepath.Base(inputPath)
+	ext := filepath.Ext(name)
+	fields := outputName{
+		Dir:  filepath.Dir(inputPath),
+		Name: name,
+		Base: strings.TrimSuffix(name, ext),
+		Ext:  ext,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, fields); err != nil {
+		return "", fmt.Errorf("%w: %w", errInvalidTemplate, err)
+	}
+
+	return filepath.Clean(buf.String()), nil
+}
+
+// writeFile writes content to path, creating parent directories as needed.
+// An existing file keeps its permissions. If the file already holds content,
+// it is left alone, keeping its modification time, unless --touch-unchanged
+// is in effect. The content is written to a temporary file in the same
+// directory that is then renamed over path, so that a failure or an
+// interrupt midway leaves the file as it was rather than truncated. A
+// symbolic link is written through, to the file it points to.
+func writeFile(path, content string) error {
+	mode := os.FileMode(outputFileMode)
+
+	info, err := os.Stat(path)
+	if err == nil {
+		mode = info.Mode().Perm()
+
+		if target, linkErr := filepath.EvalSymlinks(path); linkErr == nil {
+			path = target
+		}
+	}
+
+	if err == nil && skipUnchanged() && info.Size() == int64(len(content)) {
+		if existing, err := readFileString(path); err == nil && existing == content {
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), outputDirMode); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	defer acquireFile()()
+
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	_, err = temp.WriteString(content)
+	if err == nil {
+		err = temp.Chmod(mode)
+	}
+
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(temp.Name(), path)
+	}
+
+	if err != nil {
+		_ = os.Remove(temp.Name())
+
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	return nil
+}
+
+// readFileString returns the content of the file at path byte for byte,
+// with its line endings and final newline or lack of one, read straight
+// into the string so that a large file is held in memory only once.
+func readFileString(path string) (string, error) {
+	defer acquireFile()()
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err //nolint:wrapcheck // Callers describe the failure.
+	}
+
+	defer func() { _ = file.Close() }()
+
+	var content strings.Builder
+
+	if info, err := file.Stat(); err == nil {
+		content.Grow(int(info.Size()))
+	}
+
+	if _, err := io.Copy(&content, file); err != nil {
+		return "", err //nolint:wrapcheck // Callers describe the failure.
+	}
+
+	return content.String(), nil
+}
+
+// skipUnchanged reports whether files that would be rewritten with their
+// current content are skipped. --touch-unchanged takes precedence over
+// --skip-unchanged.
+func skipUnchanged() bool {
+	return cfg.skipUnchanged && !cfg.touchUnchanged
+}
+
+// goldenExt is the extension appended to the names of golden files.
+const goldenExt = ".golden"
+
+// goldenPath returns the golden file for inputPath under dir. Golden files
+// mirror the path of the input relative to the working directory, with
+// goldenExt appended, so their names are stable across machines.
+func goldenPath(dir, inputPath string) (string, error) {
+	name := slashPath(inputPath)
+	if name == ".." || strings.HasPrefix(name, "../") || filepath.IsAbs(name) {
+		return "", fmt.Errorf("%w: %s", errOutsideGoldenRoot, inputPath)
+	}
+
+	return filepath.Join(dir, filepath.FromSlash(name)+goldenExt), nil
+}
+
+// goldenContent returns source as stored in a golden file: ending in
+// exactly one newline, so that golden files compare equal regardless of
+// how the engine terminated its output.
+func goldenContent(source string) string {
+	return strings.TrimRight(source, "\n") + "\n"
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pierow2k/nogocomments/pkg/commentremover"
+	"github.com/pierow2k/nogocomments/pkg/walker"
+	"github.com/spf13/pflag"
+)
+
+// overridesKey is the configuration key that holds path-specific settings.
+const overridesKey = "overrides"
+
+var (
+	// errInvalidOverride is returned when the overrides of a configuration
+	// are not a list of objects with at least one path each.
+	errInvalidOverride = errors.New("invalid override")
+
+	// errInvalidGlob is returned when an override path is not a valid glob.
+	errInvalidGlob = errors.New("invalid path glob")
+)
+
+// runFlags select the inputs and destination of a run. Like the
+// presentation flags, they apply to the run as a whole and cannot be
+// overridden per path.
+var runFlags = map[string]bool{
+	"exclude": true, "include": true, "include-generated": true, "no-gitignore": true, "tags": true,
+	"golden-dir": true, "out-template": true, "paste": true, "write": true, "source-map": true,
+	"skip-unchanged": true, "touch-unchanged": true, "older-than": true, "author": true,
+	"verify": true, "since": true, "state": true, "verify-compile": true, "verify-ast": true,
+	"encoding": true, "keep-encoding": true,
+}
+
+// policyOverride is one entry of the "overrides" configuration key. Its
+// settings, keyed by long flag name like the configuration itself, apply to
+// the input files matching any of its path globs.
+type policyOverride struct {
+	Paths    []string                   `json:"paths"`
+	Settings map[string]json.RawMessage `json:"settings"`
+
+	source string // source names the configuration the override came from.
+}
+
+// overrides holds the path-specific overrides of all loaded configurations,
+// in the order they are applied.
+var overrides []policyOverride
+
+// loadOverrides validates the overrides in raw, read from the configuration
+// called source, and appends them to overrides.
+func loadOverrides(raw json.RawMessage, source string) error {
+	var list []policyOverride
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return fmt.Errorf("%s: %w: %w", source, errInvalidOverride, err)
+	}
+
+	for i := range list {
+		if len(list[i].Paths) == 0 {
+			return fmt.Errorf("%s: %w: %d", source, errInvalidOverride, i+1)
+		}
+
+		for _, glob := range list[i].Paths {
+			if !validGlob(glob) {
+				return fmt.Errorf("%s: %w: %q", source, errInvalidGlob, glob)
+			}
+		}
+
+		for name := range list[i].Settings {
+			if !definedFlags[name] || !overridable(name) {
+				return fmt.Errorf("%s: %w: %q", source, errUnknownConfigKey, name)
+			}
+		}
+
+		list[i].source = source
+	}
+
+	overrides = append(overrides, list...)
+
+	return nil
+}
+
+// overridable reports whether the flag called name may be set by an
+// override.
+func overridable(name string) bool {
+	return configurable(name) && !presentationFlags[name] && !runFlags[name]
+}
+
+// optionsFor returns the comment remover options for the input file at
+// inputPath: the command-line configuration with every matching override
+// applied in order, so later overrides win. Flags given on the command
+// line still take precedence. The global configuration is left unchanged.
+func optionsFor(flags *pflag.FlagSet, inputPath string) ([]commentremover.Option, error) {
+	if inputPath == "" || len(overrides) == 0 {
+		return removerOptions()
+	}
+
+	saved := cfg
+	defer func() { cfg = saved }()
+
+	name := slashPath(inputPath)
+
+	for _, override := range overrides {
+		if !override.matches(name) {
+			continue
+		}
+
+		if err := applySettings(flags, override.Settings, override.source, overridable); err != nil {
+			return nil, err
+		}
+	}
+
+	return removerOptions()
+}
+
+// matches reports whether the slash-separated path name matches any of the
+// globs of override.
+func (override policyOverride) matches(name string) bool {
+	for _, glob := range override.Paths {
+		if ok, _ := walker.Match(glob, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// slashPath returns inputPath slash-separated and, where possible, relative
+// to the working directory. This is the form that override globs, using
+// the syntax of walker.Match, are matched against.
+func slashPath(inputPath string) string {
+	if filepath.IsAbs(inputPath) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, inputPath); err == nil {
+				inputPath = rel
+			}
+		}
+	}
+
+	return filepath.ToSlash(filepath.Clean(inputPath))
+}
+
+// validGlob reports whether glob is a valid, non-empty pattern.
+func validGlob(glob string) bool {
+	_, err := walker.Match(glob, "")
+
+	return err == nil && glob != ""
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Pager modes accepted by the --pager flag.
+const (
+	pagerAuto   = "auto"   // pagerAuto pages only when output overflows an interactive terminal.
+	pagerNever  = "never"  // pagerNever always writes directly to standard output.
+	pagerAlways = "always" // pagerAlways pages even when standard output is not a terminal.
+)
+
+// defaultPager is used when the PAGER environment variable is not set.
+const defaultPager = "less"
+
+// errInvalidPagerMode is returned when the --pager flag has an unknown value.
+var errInvalidPagerMode = errors.New("invalid pager mode (want auto, never, or always)")
+
+// validatePagerMode returns an error if mode is not a recognized pager mode.
+func validatePagerMode(mode string) error {
+	switch mode {
+	case pagerAuto, pagerNever, pagerAlways:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", errInvalidPagerMode, mode)
+	}
+}
+
+// shouldPage reports whether text should be sent through the pager. In
+// auto mode this is the case when standard output is a terminal and text
+// has more lines than the terminal has rows.
+func shouldPage(mode, text string) bool {
+	switch mode {
+	case pagerAlways:
+		return true
+	case pagerAuto:
+		fd := int(os.Stdout.Fd()) //nolint:gosec // File descriptors fit in an int.
+		if !term.IsTerminal(fd) {
+			return false
+		}
+
+		_, height, err := term.GetSize(fd)
+		if err != nil {
+			return false
+		}
+
+		return strings.Count(text, "\n") >= height
+	default:
+		return false
+	}
+}
+
+// pagerCommand returns the pager command line, taken from $PAGER like git
+// does. It returns nil if paging has been disabled by setting PAGER to an
+// empty string or to "cat".
+func pagerCommand() []string {
+	pager, ok := os.LookupEnv("PAGER")
+	if !ok {
+		pager = defaultPager
+	}
+
+	fields := strings.Fields(pager)
+	if len(fields) == 0 || fields[0] == "cat" {
+		return nil
+	}
+
+	return fields
+}
+
+// writeOutput writes text to standard output, piping it through the pager
+// when mode calls for it.
+func writeOutput(mode, text string) error {
+	pager := pagerCommand()
+	if pager == nil || !shouldPage(mode, text) {
+		_, _ = io.WriteString(os.Stdout, text)
+
+		return nil
+	}
+
+	pagerCmd := exec.Command(pager[0], pager[1:]...) //nolint:gosec // The pager is chosen by the user.
+	pagerCmd.Stdin = strings.NewReader(text)
+	pagerCmd.Stdout = os.Stdout
+	pagerCmd.Stderr = os.Stderr
+
+	// Match git's defaults so less exits on short output, passes colors
+	// through, and leaves the text on screen after quitting.
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		pagerCmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+
+	if err := pagerCmd.Run(); err != nil {
+		return fmt.Errorf("failed to run pager: %w", err)
+	}
+
+	return nil
+}
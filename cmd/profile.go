@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	httppprof "net/http/pprof"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"time"
+)
+
+// pprofReadHeaderTimeout bounds the time the --pprof-addr server waits for
+// the headers of a request.
+const pprofReadHeaderTimeout = 10 * time.Second
+
+// errPprofNeedsServe is returned when --pprof-addr is used without
+// --serve-stdio.
+var errPprofNeedsServe = errors.New("--pprof-addr requires --serve-stdio")
+
+// cpuProfile is the file that the CPU profile is being written to, if
+// --cpuprofile is in effect.
+var cpuProfile *os.File
+
+// startProfiling starts writing a CPU profile to the --cpuprofile file, if
+// set.
+func startProfiling() error {
+	if cfg.cpuProfile == "" {
+		return nil
+	}
+
+	profile, err := os.Create(cfg.cpuProfile)
+	if err != nil {
+		return fmt.Errorf("failed to write CPU profile: %w", err)
+	}
+
+	if err := pprof.StartCPUProfile(profile); err != nil {
+		_ = profile.Close()
+
+		return fmt.Errorf("failed to write CPU profile: %w", err)
+	}
+
+	cpuProfile = profile
+
+	return nil
+}
+
+// stopProfiling finishes the CPU profile started by startProfiling, if
+// any, and writes a profile of the memory allocated during the run to the
+// --memprofile file, if set.
+func stopProfiling() error {
+	var err error
+
+	if cpuProfile != nil {
+		pprof.StopCPUProfile()
+
+		if closeErr := cpuProfile.Close(); closeErr != nil {
+			err = fmt.Errorf("failed to write CPU profile: %w", closeErr)
+		}
+
+		cpuProfile = nil
+	}
+
+	if cfg.memProfile != "" {
+		if memErr := writeMemProfile(cfg.memProfile); err == nil {
+			err = memErr
+		}
+	}
+
+	return err
+}
+
+// writeMemProfile writes the allocation profile to path. It collects
+// garbage first, so that the profile is up to date.
+func writeMemProfile(path string) error {
+	profile, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to write memory profile: %w", err)
+	}
+
+	runtime.GC()
+
+	err = pprof.Lookup("allocs").WriteTo(profile, 0)
+	if closeErr := profile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to write memory profile: %w", err)
+	}
+
+	return nil
+}
+
+// servePprof serves the net/http/pprof handlers at addr until the returned
+// function is called, so that a long-running --serve-stdio process can be
+// profiled with go tool pprof. The address served is reported on standard
+// error, which helps if addr asks for any free port, as in "localhost:0".
+func servePprof(addr string) (func(), error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serve pprof: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", httppprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", httppprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", httppprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", httppprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", httppprof.Trace)
+
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: pprofReadHeaderTimeout}
+
+	go func() { _ = server.Serve(listener) }()
+
+	notef("serving pprof at http://%s/debug/pprof/", listener.Addr())
+
+	return func() { _ = server.Close() }, nil
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// progressInterval is the least time between two redraws of the progress
+// line, except for the last one.
+const progressInterval = 100 * time.Millisecond
+
+// clearLine returns the cursor to the start of the line and erases it.
+const clearLine = "\r\x1b[K"
+
+// progress is the progress line of the current run, if one is shown.
+var progress *progressLine
+
+// progressLine shows how many of the input files of a run are done, and
+// how many failed, on a line of standard error that is redrawn in place.
+// Its methods may be called on a nil progressLine, which shows nothing.
+type progressLine struct {
+	mu    sync.Mutex
+	out   io.Writer // out is the terminal the line is shown on.
+	total int       // total is the number of input files.
+	text  string    // text is the line as last drawn, or empty if it is cleared.
+	drawn time.Time // drawn is when the line was last drawn.
+}
+
+// newProgressLine returns the progress line for a run over total files, or
+// nil if none is to be shown: with --no-progress or --silent, when asking
+// the user with --interactive or --review, or when standard error is not a
+// terminal, as when the output of a scheduled job is logged.
+func newProgressLine(total int) *progressLine {
+	if cfg.noProgress || cfg.silent || cfg.interactive || cfg.review ||
+		!term.IsTerminal(int(os.Stderr.Fd())) { //nolint:gosec // File descriptors fit in an int.
+		return nil
+	}
+
+	return &progressLine{out: os.Stderr, total: total}
+}
+
+// update shows that done files are done, failed of them with errors. The
+// line is redrawn at most once per progressInterval until the last file is
+// done.
+func (line *progressLine) update(done, failed int) {
+	if line == nil {
+		return
+	}
+
+	line.mu.Lock()
+	defer line.mu.Unlock()
+
+	if done < line.total && time.Since(line.drawn) < progressInterval {
+		return
+	}
+
+	line.text = "nogocomments: " + tr("%d/%d files, %d errors", done, line.total, failed)
+	line.drawn = time.Now()
+	_, _ = fmt.Fprint(line.out, clearLine+line.text)
+}
+
+// clear erases the line, so that a message can be printed in its place. It
+// is drawn again by the next update.
+func (line *progressLine) clear() {
+	if line == nil {
+		return
+	}
+
+	line.mu.Lock()
+	defer line.mu.Unlock()
+
+	if line.text != "" {
+		_, _ = fmt.Fprint(line.out, clearLine)
+		line.text, line.drawn = "", time.Time{}
+	}
+}
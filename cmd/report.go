@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pierow2k/nogocomments/pkg/commentremover"
+	"github.com/spf13/pflag"
+)
+
+// presentationFlags do not affect the stripped output, so they are left
+// out of the options fingerprint.
+var presentationFlags = map[string]bool{
+	"cache": true, "config": true, "help": true, "highlight": true, "jobs": true, "lang": true,
+	"pager": true, "report": true, "silent": true, "savings": true, "stats": true, "summary": true, "version": true,
+	"cpuprofile": true, "memprofile": true, "pprof-addr": true, "no-progress": true,
+	"redact-crashes": true, "report-format": true,
+}
+
+// runReport is the JSON document written by --report. It records what was
+// processed along with enough metadata to make an archived report
+// self-describing.
+type runReport struct {
+	Tool       toolInfo     `json:"tool"`
+	Options    string       `json:"optionsFingerprint"`
+	Host       hostInfo     `json:"host"`
+	StartedAt  time.Time    `json:"startedAt"`
+	FinishedAt time.Time    `json:"finishedAt"`
+	GitCommit  string       `json:"gitCommit,omitempty"`
+	Files      []fileReport `json:"files"`
+}
+
+// toolInfo identifies the build of nogocomments that produced a report.
+type toolInfo struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	BuildDate string `json:"buildDate"`
+}
+
+// hostInfo identifies the platform a report was produced on.
+type hostInfo struct {
+	OS   string `json:"os"`
+	Arch string `json:"arch"`
+}
+
+// fileReport is the outcome for a single input.
+type fileReport struct {
+	Path     string `json:"path"`
+	Engine   string `json:"engine,omitempty"`
+	Fallback string `json:"fallback,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Error    string `json:"error,omitempty"`
+
+	Unparsed []commentremover.Unparsed `json:"unparsed,omitempty"`
+
+	Stats *commentremover.Stats `json:"stats,omitempty"`
+}
+
+// newRunReport starts a report for a run configured by flags.
+func newRunReport(flags *pflag.FlagSet) *runReport {
+	return &runReport{
+		Tool:      toolInfo{Name: "nogocomments", Version: Version, BuildDate: BuildDate},
+		Options:   optionsFingerprint(flags),
+		Host:      hostInfo{OS: runtime.GOOS, Arch: runtime.GOARCH},
+		StartedAt: time.Now(),
+		Files:     []fileReport{},
+	}
+}
+
+// optionsFingerprint returns a SHA-256 digest of the flag values and
+// path-specific overrides that affect the output, so reports from runs
+// with identical options can be matched up regardless of how the options
+// were supplied.
+func optionsFingerprint(flags *pflag.FlagSet) string {
+	return settingsFingerprint(flags, func(name string) bool { return presentationFlags[name] })
+}
+
+// settingsFingerprint returns a SHA-256 digest of the values of the flags
+// that ignored does not rule out and of the path-specific overrides.
+func settingsFingerprint(flags *pflag.FlagSet, ignored func(name string) bool) string {
+	var settings []string
+
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if !ignored(flag.Name) {
+			settings = append(settings, flag.Name+"="+flag.Value.String())
+		}
+	})
+
+	slices.Sort(settings)
+
+	if len(overrides) > 0 {
+		encoded, _ := json.Marshal(overrides) //nolint:errchkjson // Overrides were decoded from JSON.
+		settings = append(settings, overridesKey+"="+string(encoded))
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(settings, "\n")))
+
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// gitCommit returns the commit checked out in the git work tree containing
+// path, or "" if path is not inside a work tree.
+func gitCommit(path string) string {
+	gitCmd := exec.Command("git", "-C", filepath.Dir(path), "rev-parse", "HEAD")
+
+	out, err := gitCmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+// finish records the end time and writes the report to path as indented
+// JSON.
+func (report *runReport) finish(path string) error {
+	report.FinishedAt = time.Now()
+
+	var buf strings.Builder
+
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	return writeFile(path, buf.String())
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Formats of reports, the output of the stats and bench commands and of
+// --summary, besides text and json.
+const (
+	formatCSV      = "csv"
+	formatMarkdown = "markdown"
+)
+
+// reportFormats are the values --report-format accepts.
+var reportFormats = []string{formatText, formatJSON, formatCSV, formatMarkdown}
+
+// validateReportFormat returns an error unless --report-format names one of
+// reportFormats.
+func validateReportFormat() error {
+	if !slices.Contains(reportFormats, cfg.format) {
+		return fmt.Errorf("%w: %q", errInvalidFormat, cfg.format)
+	}
+
+	return nil
+}
+
+// writeJSON writes value to out as indented JSON.
+func writeJSON(out io.Writer, value any) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(value) //nolint:wrapcheck // Callers write to standard streams.
+}
+
+// writeTable writes a table with the columns of header and the cells of
+// rows to out, in the csv or markdown --report-format. The header of CSV
+// is left as it is, for scripts to rely on, while that of Markdown, meant
+// for people, is upper-cased and translated like the text tables. Columns
+// of numbers are right-aligned in Markdown.
+func writeTable(out io.Writer, header []string, rows [][]string) error {
+	if cfg.format == formatCSV {
+		writer := csv.NewWriter(out)
+		_ = writer.Write(header)
+		_ = writer.WriteAll(rows)
+
+		return writer.Error() //nolint:wrapcheck // Callers write to standard streams.
+	}
+
+	var table strings.Builder
+
+	titles, rules := make([]string, len(header)), make([]string, len(header))
+
+	for column, name := range header {
+		titles[column] = markdownCell(tr(strings.ToUpper(name)))
+		rules[column] = "---"
+
+		if numericColumn(rows, column) {
+			rules[column] = "---:"
+		}
+	}
+
+	writeMarkdownRow(&table, titles)
+	writeMarkdownRow(&table, rules)
+
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for column, cell := range row {
+			cells[column] = markdownCell(cell)
+		}
+
+		writeMarkdownRow(&table, cells)
+	}
+
+	_, err := fmt.Fprint(out, table.String())
+
+	return err //nolint:wrapcheck // Callers write to standard streams.
+}
+
+// writeMarkdownRow writes cells to table as a row of a Markdown table.
+func writeMarkdownRow(table *strings.Builder, cells []string) {
+	table.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+}
+
+// markdownCell returns text escaped for a cell of a Markdown table, in
+// which a pipe would end the cell and a line break the row.
+func markdownCell(text string) string {
+	return strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ").Replace(text)
+}
+
+// numericColumn reports whether the cells of rows in column, not counting
+// empty cells and the "-" of values that do not apply, are all numbers.
+func numericColumn(rows [][]string, column int) bool {
+	numbers := 0
+
+	for _, row := range rows {
+		switch cell := row[column]; {
+		case cell == "" || cell == "-":
+		case isNumber(cell):
+			numbers++
+		default:
+			return false
+		}
+	}
+
+	return numbers > 0
+}
+
+// isNumber reports whether cell is a number, or a percentage.
+func isNumber(cell string) bool {
+	_, err := strconv.ParseFloat(strings.TrimSuffix(cell, "%"), 64)
+
+	return err == nil
+}
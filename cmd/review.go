@@ -0,0 +1,367 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/pierow2k/nogocomments/pkg/commentremover"
+	"golang.org/x/term"
+)
+
+var (
+	// errReviewConflict is returned when --review is combined with a mode
+	// that asks about comments differently or not at all.
+	errReviewConflict = errors.New("--review cannot be combined with --interactive, --serve-stdio, or --silent")
+
+	// errReviewNeedsTerminal is returned when --review is used without a
+	// terminal on standard input and standard error.
+	errReviewNeedsTerminal = errors.New("--review requires a terminal")
+
+	// errReviewCancelled is returned when the review of an input is quit
+	// without applying the selection.
+	errReviewCancelled = errors.New("review cancelled")
+)
+
+// ANSI escape sequences used by the review screen.
+const (
+	ansiEnterScreen = "\x1b[?1049h\x1b[?25l" // ansiEnterScreen switches to the alternate screen and hides the cursor.
+	ansiLeaveScreen = "\x1b[?25h\x1b[?1049l" // ansiLeaveScreen restores the cursor and the normal screen.
+	ansiClearScreen = "\x1b[H\x1b[2J"        // ansiClearScreen moves the cursor home and clears the screen.
+	ansiReverse     = "\x1b[7m"              // ansiReverse shows the highlighted row in reverse video.
+	ansiDeleted     = "\x1b[31m"             // ansiDeleted colors removed lines red.
+	ansiAdded       = "\x1b[32m"             // ansiAdded colors added lines green.
+)
+
+// diffContext is the number of unchanged lines shown around each change in
+// the diff preview.
+const diffContext = 3
+
+// commentSpan is the range of lines first through last, counting from 1,
+// that a comment spans.
+type commentSpan struct {
+	first, last int
+}
+
+// reviewScreen is the full-screen list of the comments of an input that
+// --review lets the user select for removal.
+type reviewScreen struct {
+	name   string                  // name is the input as called in messages.
+	source string                  // source is the input.
+	lines  []string                // lines are the lines of source.
+	opts   []commentremover.Option // opts remove the comments listed in spans, and possibly others.
+	spans  []commentSpan           // spans are the comments that opts would remove, in source order.
+	remove []bool                  // remove tells for each of spans whether it is selected for removal.
+	cursor int                     // cursor is the index of the highlighted comment.
+	top    int                     // top is the index of the first comment on the screen.
+
+	diff    []string // diff holds the rendered diff preview while it is shown.
+	diffTop int      // diffTop is the index of the first line of diff on the screen.
+
+	out           io.Writer // out is the terminal.
+	width, height int       // width and height are the size of the terminal.
+}
+
+// reviewOptions lets the user select, on a full-screen list, which of the
+// comments that opts remove from source, the input called inputName, are
+// really removed. It returns opts restricted to the selection.
+func reviewOptions(inputName, source string, opts []commentremover.Option) ([]commentremover.Option, error) {
+	var spans []commentSpan
+
+	collect := commentremover.RemoveIf(func(first, last int) bool {
+		spans = append(spans, commentSpan{first: first, last: last})
+
+		return true
+	})
+
+	if _, err := commentremover.Process(source, append(slices.Clip(opts), collect)...); err != nil {
+		return nil, fmt.Errorf("failed to remove comments from source: %w", withSourceContext(inputName, source, err))
+	}
+
+	if len(spans) == 0 {
+		return opts, nil
+	}
+
+	screen := &reviewScreen{
+		name:   inputName,
+		source: source,
+		lines:  strings.Split(source, "\n"),
+		opts:   opts,
+		spans:  spans,
+		remove: make([]bool, len(spans)),
+		out:    os.Stderr,
+	}
+
+	for i := range screen.remove {
+		screen.remove[i] = true
+	}
+
+	if err := screen.run(); err != nil {
+		return nil, err
+	}
+
+	return append(opts, commentremover.RemoveIf(selection(screen.remove))), nil
+}
+
+// selection returns a filter accepting the comments whose entries in
+// remove are set. The comments are taken in the order the filter is
+// consulted, which is the order they were listed in.
+func selection(remove []bool) commentremover.LineFilter {
+	next := 0
+
+	return func(_, _ int) bool {
+		i := next
+		next++
+
+		return i < len(remove) && remove[i]
+	}
+}
+
+// run shows the screen until the user applies the selection or quits.
+func (screen *reviewScreen) run() error {
+	stdin, stderr := int(os.Stdin.Fd()), int(os.Stderr.Fd()) //nolint:gosec // File descriptors fit in an int.
+	if !term.IsTerminal(stdin) || !term.IsTerminal(stderr) {
+		return errReviewNeedsTerminal
+	}
+
+	state, err := term.MakeRaw(stdin)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errReviewNeedsTerminal, err)
+	}
+
+	defer func() { _ = term.Restore(stdin, state) }()
+
+	_, _ = io.WriteString(screen.out, ansiEnterScreen)
+	defer func() { _, _ = io.WriteString(screen.out, ansiLeaveScreen) }()
+
+	buf := make([]byte, 16)
+
+	for {
+		screen.width, screen.height, err = term.GetSize(stderr)
+		if err != nil || screen.height < 3 {
+			screen.width, screen.height = 80, 24
+		}
+
+		screen.draw()
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return errReviewCancelled
+		}
+
+		if done, err := screen.handle(string(buf[:n])); done {
+			return err
+		}
+	}
+}
+
+// handle applies the key key, reporting whether the review is over and,
+// if so, whether it was cancelled.
+func (screen *reviewScreen) handle(key string) (bool, error) {
+	switch key {
+	case "q", "\x03":
+		return true, errReviewCancelled
+	case "\r", "\n", "w":
+		return true, nil
+	}
+
+	if screen.diff != nil {
+		screen.handleDiff(key)
+
+		return false, nil
+	}
+
+	switch key {
+	case "\x1b[A", "k":
+		screen.cursor = max(screen.cursor-1, 0)
+	case "\x1b[B", "j":
+		screen.cursor = min(screen.cursor+1, len(screen.spans)-1)
+	case "\x1b[5~":
+		screen.cursor = max(screen.cursor-screen.rows(), 0)
+	case "\x1b[6~":
+		screen.cursor = min(screen.cursor+screen.rows(), len(screen.spans)-1)
+	case " ":
+		screen.remove[screen.cursor] = !screen.remove[screen.cursor]
+	case "a", "n":
+		for i := range screen.remove {
+			screen.remove[i] = key == "a"
+		}
+	case "d":
+		screen.diff, screen.diffTop = screen.preview(), 0
+	case "\x1b":
+		return true, errReviewCancelled
+	}
+
+	return false, nil
+}
+
+// handleDiff applies the key key while the diff preview is shown.
+func (screen *reviewScreen) handleDiff(key string) {
+	last := max(len(screen.diff)-screen.rows(), 0)
+
+	switch key {
+	case "\x1b[A", "k":
+		screen.diffTop = max(screen.diffTop-1, 0)
+	case "\x1b[B", "j":
+		screen.diffTop = min(screen.diffTop+1, last)
+	case "\x1b[5~":
+		screen.diffTop = max(screen.diffTop-screen.rows(), 0)
+	case "\x1b[6~", " ":
+		screen.diffTop = min(screen.diffTop+screen.rows(), last)
+	case "d", "\x1b":
+		screen.diff = nil
+	}
+}
+
+// rows returns the number of lines available between the title and the
+// key help.
+func (screen *reviewScreen) rows() int {
+	return screen.height - 2
+}
+
+// draw renders the screen: a title, the list of comments or the diff
+// preview, and a line of key help.
+func (screen *reviewScreen) draw() {
+	var out strings.Builder
+
+	out.WriteString(ansiClearScreen)
+
+	selected := 0
+
+	for _, remove := range screen.remove {
+		if remove {
+			selected++
+		}
+	}
+
+	screen.writeRow(&out, "", tr("%s: %d of %d comments selected for removal", screen.name, selected, len(screen.spans)))
+
+	if screen.diff != nil {
+		end := min(screen.diffTop+screen.rows(), len(screen.diff))
+		for _, line := range screen.diff[screen.diffTop:end] {
+			style := ""
+
+			switch {
+			case strings.HasPrefix(line, string(diffDelete)):
+				style = ansiDeleted
+			case strings.HasPrefix(line, string(diffInsert)):
+				style = ansiAdded
+			}
+
+			screen.writeRow(&out, style, line)
+		}
+
+		screen.pad(&out, end-screen.diffTop)
+		screen.writeRow(&out, "", tr("↑/↓ scroll  d back  enter apply  q quit"))
+	} else {
+		screen.top = min(screen.top, screen.cursor)
+		screen.top = max(screen.top, screen.cursor-screen.rows()+1)
+
+		end := min(screen.top+screen.rows(), len(screen.spans))
+		for i := screen.top; i < end; i++ {
+			style := ""
+			if i == screen.cursor {
+				style = ansiReverse
+			}
+
+			screen.writeRow(&out, style, screen.describe(i))
+		}
+
+		screen.pad(&out, end-screen.top)
+		screen.writeRow(&out, "", tr("↑/↓ move  space toggle  a all  n none  d diff  enter apply  q quit"))
+	}
+
+	_, _ = io.WriteString(screen.out, out.String())
+}
+
+// describe returns the list entry for the comment spans[i]: a check box,
+// its line number, and its first line.
+func (screen *reviewScreen) describe(i int) string {
+	span := screen.spans[i]
+
+	box := "[ ]"
+	if screen.remove[i] {
+		box = "[x]"
+	}
+
+	entry := fmt.Sprintf("%s %5d  %s", box, span.first, strings.TrimSpace(screen.lines[span.first-1]))
+	if span.last > span.first {
+		entry += " " + tr("(+%d lines)", span.last-span.first)
+	}
+
+	return entry
+}
+
+// writeRow writes text as a row of the screen in style, cut to the width
+// of the terminal. Rows end in "\r\n", since the terminal is in raw mode.
+func (screen *reviewScreen) writeRow(out *strings.Builder, style, text string) {
+	runes := []rune(strings.ReplaceAll(text, "\t", "    "))
+	if len(runes) > screen.width {
+		runes = runes[:screen.width]
+	}
+
+	out.WriteString(style + string(runes))
+
+	if style != "" {
+		out.WriteString(ansiReset)
+	}
+
+	out.WriteString("\r\n")
+}
+
+// pad writes empty rows after the used rows of the middle of the screen, so
+// the key help stays on the last line.
+func (screen *reviewScreen) pad(out *strings.Builder, used int) {
+	for range screen.rows() - used {
+		out.WriteString("\r\n")
+	}
+}
+
+// preview returns the diff that removing the selected comments makes to
+// the input, as rows of the screen: the changed lines with a few lines of
+// context, and a "@@" row with the line number before each hunk.
+func (screen *reviewScreen) preview() []string {
+	opts := append(slices.Clip(screen.opts), commentremover.RemoveIf(selection(screen.remove)))
+
+	result, err := commentremover.Process(screen.source, opts...)
+	if err != nil {
+		return []string{localizeMessage(err.Error())}
+	}
+
+	diff := lineDiff(screen.lines, strings.Split(result.Source, "\n"))
+	shown := make([]bool, len(diff))
+
+	for i, line := range diff {
+		if line.op != diffSame {
+			for j := max(i-diffContext, 0); j <= min(i+diffContext, len(diff)-1); j++ {
+				shown[j] = true
+			}
+		}
+	}
+
+	rows := []string{}
+	line := 1
+
+	for i, entry := range diff {
+		if shown[i] && (i == 0 || !shown[i-1]) {
+			rows = append(rows, fmt.Sprintf("@@ %d @@", line))
+		}
+
+		if shown[i] {
+			rows = append(rows, string(entry.op)+entry.text)
+		}
+
+		if entry.op != diffInsert {
+			line++
+		}
+	}
+
+	if len(rows) == 0 {
+		rows = append(rows, tr("No changes."))
+	}
+
+	return rows
+}
@@ -3,19 +3,134 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"go/build"
 	"os"
+	"os/signal"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/atotto/clipboard"
 	"github.com/pierow2k/nogocomments/pkg/commentremover"
+	"github.com/pierow2k/nogocomments/pkg/walker"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 // Configuration stores the configuration parsed from command-line flags.
 type Configuration struct {
-	filePath     string // filePath is the path to the Go source file to process.
-	useClipboard bool   // useClipboard indicates whether to read input from the clipboard.
+	filePaths        []string // filePaths are the paths to the Go source files to process.
+	useClipboard     bool     // useClipboard indicates whether to read input from the clipboard.
+	preserveFormat   bool     // preserveFormat forces the scanner engine so untouched code keeps its formatting.
+	minimalDiff      bool     // minimalDiff deletes the comments chosen on the AST from the original bytes.
+	squeezeBlank     bool     // squeezeBlank collapses runs of blank lines in the output to one.
+	preserveLines    bool     // preserveLines keeps every input line at the same line number in the output.
+	eol              string   // eol selects the line breaks of the output: lf, crlf, or preserve.
+	encoding         string   // encoding names the encoding of the input, or auto to detect it.
+	stripBOM         bool     // stripBOM drops a leading byte order mark instead of restoring it.
+	indent           string   // indent selects how reprinted code is indented: tabs or spaces.
+	tabWidth         int      // tabWidth is the width of a tab, and of an indentation level with spaces.
+	style            string   // style selects the formatting rules of reprinted code: gofmt or gofumpt.
+	engine           string   // engine selects the pipeline that removes comments: auto, ast, or scanner.
+	pager            string   // pager controls whether output is paged: auto, never, or always.
+	highlight        bool     // highlight colorizes the output when it is written to a terminal.
+	lang             string   // lang selects the language of messages; see requestedLanguage.
+	configPath       string   // configPath is the path to a JSON file with default flag values.
+	silent           bool     // silent suppresses all output; results are reported by exit status only.
+	write            bool     // write replaces the input file with the result.
+	outTemplate      string   // outTemplate names the output file, relative to the input file, instead of stdout.
+	goldenDir        string   // goldenDir is the directory that golden files are written to, if set.
+	sourceMap        string   // sourceMap names the source map file of each input, relative to it, if set.
+	reportPath       string   // reportPath is where the JSON run report is written, if set.
+	include          []string // include restricts the files selected in directories to matching globs.
+	exclude          []string // exclude skips files and directories in directories that match globs.
+	noGitignore      bool     // noGitignore selects files in directories even if .gitignore ignores them.
+	withGenerated    bool     // withGenerated selects generated files in directories.
+	tags             []string // tags, unless nil, selects the files in directories built with these build tags.
+	force            bool     // force writes output even if removed comments change program behavior.
+	heatmap          bool     // heatmap breaks the statistics of the stats command down by directory.
+	statsPackages    bool     // statsPackages breaks the statistics of the stats command down by package.
+	statsFiles       bool     // statsFiles breaks the statistics of the stats command down by file.
+	licensesMissing  bool     // licensesMissing lists only the files without a license header in the licenses command.
+	format           string   // format is the format of the stats and bench commands and of --summary.
+	capabilitiesJSON bool     // capabilitiesJSON prints the capabilities command output as JSON.
+	benchRuns        int      // benchRuns is the number of times the bench command processes every file.
+	benchEngines     []string // benchEngines are the engines that the bench command measures.
+	benchSets        []string // benchSets are the JSON option sets that the bench command measures.
+	skipUnchanged    bool     // skipUnchanged leaves output files that already hold the result untouched.
+	touchUnchanged   bool     // touchUnchanged rewrites output files even if they already hold the result.
+	serveStdio       bool     // serveStdio answers editor requests on standard input instead of processing inputs.
+
+	stripBuildConstraints bool // stripBuildConstraints removes //go:build and // +build lines.
+	stripGenerate         bool // stripGenerate removes //go:generate directives.
+	stripEmbed            bool // stripEmbed removes //go:embed directives.
+	stripCgoPreamble      bool // stripCgoPreamble removes the comment preceding import "C".
+	stripCgoExports       bool // stripCgoExports removes //export directives from cgo files.
+
+	stripCompilerDirectives bool // stripCompilerDirectives removes //go: pragmas such as //go:noinline.
+	stripSysDirectives      bool // stripSysDirectives removes //sys and //sysnb directives.
+	stripLineDirectives     bool // stripLineDirectives removes //line directives.
+	stripDirectives         bool // stripDirectives removes every kind of directive.
+	stripLicenseHeader      bool // stripLicenseHeader removes a leading copyright or license header.
+	stripSPDX               bool // stripSPDX removes SPDX-License-Identifier comments.
+	keepNolint              bool // keepNolint retains //nolint lint-suppression comments.
+	keepDoc                 bool // keepDoc retains the doc comments of exported declarations.
+	keepPackageDoc          bool // keepPackageDoc retains the package doc comment.
+	keepDeprecated          bool // keepDeprecated retains "Deprecated:" notices; implied by keepDoc.
+	keepAnnotations         bool // keepAnnotations retains @ annotations for code generators.
+	strictUTF8              bool // strictUTF8 rejects input that is not valid UTF-8.
+	keepEncoding            bool // keepEncoding writes the output in the encoding of the input instead of UTF-8.
+	bestEffort              bool // bestEffort cleans code that fails to parse or tokenize as far as possible.
+	recoverPartial          bool // recoverPartial cleans the declarations that parse, leaving the rest unchanged.
+
+	keepPatterns   []string // keepPatterns are regular expressions; matching comments are kept.
+	removePatterns []string // removePatterns are regular expressions; only matching comments are removed.
+	onlyTodos      bool     // onlyTodos removes only TODO, FIXME, and HACK comments.
+	keepTodos      bool     // keepTodos retains TODO, FIXME, and HACK comments.
+
+	onlyLineComments  bool // onlyLineComments removes // comments and keeps /* */ comments.
+	onlyBlockComments bool // onlyBlockComments removes /* */ comments and keeps // comments.
+	onlyFuncBodies    bool // onlyFuncBodies removes only the comments inside function bodies.
+	keepFieldComments bool // keepFieldComments retains end-of-line comments of struct fields and constants.
+
+	lines []string // lines are START:END line ranges; only comments beginning within them are removed.
+
+	olderThan string       // olderThan is an age or date; only comments last changed before it are removed.
+	author    string       // author is a regular expression; only comments last changed by matching authors are removed.
+	blame     *blameFilter // blame is the filter built from olderThan and author, if either is set.
+
+	interactive bool      // interactive asks before removing each comment.
+	reviewer    *reviewer // reviewer does the asking with --interactive.
+	review      bool      // review selects the comments to remove on a full-screen list.
+
+	verify        bool // verify checks that removal is deterministic and idempotent before writing each result.
+	verifyCompile bool // verifyCompile checks that the package of each input file still builds with its result.
+	verifyAST     bool // verifyAST checks that each result has the syntax tree of its input but for comments.
+	stats         bool // stats prints what was removed from each input to standard error.
+	summary       bool // summary prints what was removed from all inputs, and how long it took, after a run.
+	savings       bool // savings prints the bytes and lines saved in each input, largest savings first, after a run.
+	jobs          int  // jobs is the number of input files whose comments are removed at the same time.
+
+	noProgress    bool // noProgress hides the progress line shown on a terminal during runs over several files.
+	redactCrashes bool // redactCrashes masks the identifiers, literals, and comments of inputs in crash reports.
+
+	cacheDir string       // cacheDir is the directory of the result cache, if set.
+	cache    *resultCache // cache is the result cache, if one is in use.
+
+	since     string // since is a time, file, age, or date; only files modified after it are processed.
+	statePath string // statePath is the file recording the start of the last successful run, if set.
+
+	rewritten map[string]time.Time // rewritten holds the modification times of the input files rewritten by --write.
+
+	cpuProfile string // cpuProfile is the file that a CPU profile of the run is written to, if set.
+	memProfile string // memProfile is the file that a memory allocation profile is written to, if set.
+	pprofAddr  string // pprofAddr is the address that net/http/pprof is served at with --serve-stdio, if set.
 }
 
 var (
@@ -28,6 +143,74 @@ var (
 	// errNoInputMethod is returned when neither a file path nor the paste
 	// flag is specified.
 	errNoInputMethod = errors.New("no input method specified")
+
+	// errMultipleNeedOutput is returned when several input files are given
+	// without a destination for each of them.
+	errMultipleNeedOutput = errors.New("multiple input files require --write, --out-template, --golden-dir, " +
+		"or --silent")
+
+	// errNoGoFiles is returned when an input directory holds no Go files to
+	// process.
+	errNoGoFiles = errors.New("no Go files found")
+
+	// errBehaviorChange is returned when the comments removed from a file
+	// change how it builds or runs and the result would be written to a file.
+	errBehaviorChange = errors.New("refusing to write output that changes program behavior (use --force)")
+
+	// errInvalidPattern is returned when a --keep-pattern or
+	// --remove-pattern value is not a valid regular expression.
+	errInvalidPattern = errors.New("invalid comment pattern")
+
+	// errCommentKindConflict is returned when both --only-line-comments and
+	// --only-block-comments are specified.
+	errCommentKindConflict = errors.New("--only-line-comments and --only-block-comments are mutually exclusive")
+
+	// errLayoutConflict is returned when both --preserve-lines and
+	// --squeeze-blank are specified.
+	errLayoutConflict = errors.New("--preserve-lines and --squeeze-blank are mutually exclusive")
+
+	// errInvalidLineEnding is returned when the --eol flag has an unknown
+	// value.
+	errInvalidLineEnding = errors.New("invalid line ending (want lf, crlf, or preserve)")
+
+	// errInvalidEncoding is returned when the --encoding flag has an
+	// unknown value.
+	errInvalidEncoding = errors.New("invalid encoding (want auto, utf-8, utf-16le, utf-16be, or latin1)")
+
+	// errInvalidLineRange is returned when a --lines value is not a line
+	// number or a range START:END of line numbers counting from 1.
+	errInvalidLineRange = errors.New("invalid line range")
+
+	// errInvalidIndent is returned when the --indent flag has an unknown
+	// value.
+	errInvalidIndent = errors.New("invalid indentation (want tabs or spaces)")
+
+	// errInvalidTabWidth is returned when the --tabwidth flag is not
+	// positive.
+	errInvalidTabWidth = errors.New("invalid tab width (want a positive number)")
+
+	// errInvalidStyle is returned when the --style flag has an unknown
+	// value.
+	errInvalidStyle = errors.New("invalid style (want gofmt or gofumpt)")
+
+	// errInvalidJobs is returned when the --jobs flag is not positive.
+	errInvalidJobs = errors.New("invalid number of jobs (want a positive number)")
+
+	// errInvalidEngine is returned when the --engine flag has an unknown
+	// value.
+	errInvalidEngine = errors.New("invalid engine (want auto, ast, or scanner)")
+
+	// errEngineConflict is returned when --preserve-format is combined with
+	// an --engine other than scanner.
+	errEngineConflict = errors.New("--preserve-format requires --engine scanner")
+
+	// errFilesFailed is returned when processing fails for some of several
+	// input files.
+	errFilesFailed = errors.New("processing failed for some files")
+
+	// errStopped is returned when the context of the run is done before all
+	// input files are processed.
+	errStopped = errors.New("processing stopped")
 )
 
 // BuildDate, CopyrightDate, Version, and License contain build information.
@@ -40,91 +223,1103 @@ var (
 	License       = "Licensed under the MIT License <https://opensource.org/licenses/MIT>"
 )
 
-// rootCmd represents the base command for the CLI.
-var rootCmd = &cobra.Command{
-	Use:   "nogocomments [INPUT_FILE]",
-	Short: "Remove comments from Go source code.",
-	Long: `nogocomments removes comments from Go source code.
+// rootLong and rootExample are the long description and usage examples of
+// the root command. They double as message catalog keys.
+const (
+	rootLong = `nogocomments removes comments from Go source code.
 It reads Go code from a file or the system clipboard and writes the
 result to standard output. It supports both complete packages and
-standalone code snippets.`,
-	Example: `  # Remove comments from a file
+standalone code snippets.`
+
+	rootExample = `  # Remove comments from a file
   nogocomments somecode.go
 
+  # Remove comments from all Go files under a directory in place
+  nogocomments --write --exclude '**/*_test.go' ./pkg
+
   # Remove comments from code on the clipboard
-  nogocomments --paste`,
+  nogocomments --paste`
+)
+
+// rootCmd represents the base command for the CLI.
+var rootCmd = &cobra.Command{
+	Use:     "nogocomments [INPUT_FILE|DIR...]",
+	Short:   "Remove comments from Go source code.",
+	Long:    rootLong,
+	Example: rootExample,
 	Version: fmt.Sprintf(
 		"%s - built %s\nCopyright © %s Pierow2k\n%s",
 		Version, BuildDate, CopyrightDate, License,
 	),
-	Args: cobra.MaximumNArgs(1),
-	RunE: runFunction,
+	Args:              cobra.ArbitraryArgs,
+	PersistentPreRunE: preRun,
+	RunE:              runFunction,
 }
 
 // Execute is the entry point for the CLI. It processes command-line
-// arguments and exits with a non-zero status code on error.
+// arguments and exits with a non-zero status code on error. Errors are
+// printed in the language selected by --lang or the environment, followed
+// by the usage of the command that failed. An interrupt or SIGTERM stops
+// the run between input files, as ExecuteContext describes, and a second
+// one ends the process at once.
 func Execute() {
-	rootCmd.InitDefaultHelpFlag()
-	rootCmd.Flags().Lookup("help").Usage = "Show help"
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	context.AfterFunc(ctx, stop)
+
+	ExecuteContext(ctx)
+}
+
+// ExecuteContext is like Execute, but runs under ctx: once ctx is done, no
+// further input file or --serve-stdio request is started. The profiles
+// requested with --cpuprofile and --memprofile are written before it exits.
+func ExecuteContext(ctx context.Context) {
+	languageName, fromFlag := requestedLanguage(os.Args[1:])
+	languageOK := setLanguage(languageName)
+
+	rootCmd.InitDefaultHelpCmd()
+
+	for _, command := range append(rootCmd.Commands(), rootCmd) {
+		command.InitDefaultHelpFlag()
+		command.Flags().Lookup("help").Usage = "Show help"
+	}
+
 	rootCmd.InitDefaultVersionFlag()
 	rootCmd.Flags().Lookup("version").Usage = "Show version, build details, and license"
+	rootCmd.CompletionOptions.DisableDefaultCmd = true
+	rootCmd.SilenceErrors = true
+	rootCmd.SilenceUsage = true
+
+	localizeCommand(rootCmd)
+
+	if !languageOK && fromFlag {
+		unsupportedLanguage = languageName
+	}
+
+	command, err := rootCmd.ExecuteContextC(ctx)
+	if profileErr := stopProfiling(); err == nil {
+		err = profileErr
+	}
+
+	if err != nil {
+		if !cfg.silent {
+			_, _ = fmt.Fprintln(os.Stderr, tr("Error:"), localizeMessage(err.Error()))
+			command.Println(command.UsageString())
+		}
+
+		if crashed.Load() {
+			os.Exit(exitCrashed)
+		}
 
-	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
 	}
 }
 
+// unsupportedLanguage is the --lang value that had no catalog, if any. The
+// warning is deferred until the flags are parsed so that --silent applies.
+var unsupportedLanguage string
+
+// preRun runs before every command, after the command line is parsed. It
+// loads the configuration, reports deferred warnings, and starts the CPU
+// profile if --cpuprofile is given.
+func preRun(command *cobra.Command, args []string) error {
+	if err := loadConfig(command, args); err != nil {
+		return err
+	}
+
+	if unsupportedLanguage != "" {
+		notef("unsupported language, using English: %s", unsupportedLanguage)
+	}
+
+	return startProfiling()
+}
+
+// notef prints a translated notice to standard error unless --silent is in
+// effect, in place of the progress line if one is shown.
+func notef(format string, args ...any) {
+	if cfg.silent {
+		return
+	}
+
+	progress.clear()
+
+	_, _ = fmt.Fprintln(os.Stderr, "nogocomments:", tr(format, args...))
+}
+
 // init registers the command-line flags for the root command.
 func init() {
 	rootCmd.Flags().BoolVarP(&cfg.useClipboard, "paste", "p", false, "Read code from the system clipboard")
+	rootCmd.Flags().BoolVar(&cfg.preserveFormat, "preserve-format", false,
+		"Delete comments in place without reformatting the remaining code")
+	rootCmd.Flags().BoolVar(&cfg.minimalDiff, "minimal-diff", false,
+		"Choose comments on the parsed code but delete them in place, leaving all other bytes unchanged")
+	rootCmd.Flags().BoolVar(&cfg.squeezeBlank, "squeeze-blank", false,
+		"Collapse runs of blank lines in the output to a single blank line")
+	rootCmd.Flags().BoolVar(&cfg.preserveLines, "preserve-lines", false,
+		"Leave lines that held only comments empty, so every line keeps its line number")
+	rootCmd.Flags().StringVar(&cfg.eol, "eol", commentremover.LineEndingPreserve.String(),
+		"Line breaks of the output: lf, crlf, or preserve to keep those of the input")
+	rootCmd.Flags().BoolVar(&cfg.stripBOM, "strip-bom", false,
+		"Remove a UTF-8 byte order mark from the start of the output")
+	rootCmd.Flags().StringVar(&cfg.indent, "indent", commentremover.IndentTabs.String(),
+		"Indentation of reprinted code: tabs or spaces")
+	rootCmd.Flags().IntVar(&cfg.tabWidth, "tabwidth", 8,
+		"Width of a tab when aligning reprinted code, and of each indentation level with --indent spaces")
+	rootCmd.Flags().StringVar(&cfg.style, "style", commentremover.StyleGofmt.String(),
+		"Formatting rules of reprinted code: gofmt or the stricter gofumpt")
+	rootCmd.Flags().StringVar(&cfg.engine, "engine", commentremover.EngineAuto.String(),
+		"Engine that removes comments: auto, ast, or scanner, which deletes them without reprinting the code")
+	rootCmd.Flags().StringVar(&cfg.pager, "pager", pagerAuto,
+		"Page long output through $PAGER: auto, never, or always")
+	rootCmd.Flags().BoolVar(&cfg.highlight, "highlight", false,
+		"Colorize the output when writing to a terminal")
+	rootCmd.PersistentFlags().StringVar(&cfg.lang, "lang", "", "Language of messages (default taken from LANG)")
+	rootCmd.PersistentFlags().StringVar(&cfg.configPath, "config", "", "Read default flag values from a JSON file")
+	rootCmd.Flags().BoolVarP(&cfg.write, "write", "w", false, "Write the result back to the input file")
+	rootCmd.Flags().StringVar(&cfg.sourceMap, "source-map", "",
+		"Write a JSON map from output to input positions to a file named by a template, e.g. '{{.Dir}}/{{.Name}}.map'")
+	rootCmd.Flags().StringVar(&cfg.outTemplate, "out-template", "",
+		"Write the result to a file named by a template, e.g. '{{.Dir}}/{{.Base}}_clean{{.Ext}}'")
+	rootCmd.Flags().StringVar(&cfg.goldenDir, "golden-dir", "",
+		"Write each result to DIR/<input path>.golden, ending in a single newline")
+	rootCmd.Flags().BoolVar(&cfg.skipUnchanged, "skip-unchanged", true,
+		"Leave output files that already hold the result untouched, keeping their modification times")
+	rootCmd.Flags().BoolVar(&cfg.touchUnchanged, "touch-unchanged", false,
+		"Rewrite output files even if they already hold the result, updating their modification times")
+	rootCmd.Flags().BoolVar(&cfg.serveStdio, "serve-stdio", false,
+		"Answer length-prefixed editor requests on standard input and output")
+	rootCmd.Flags().StringVar(&cfg.reportPath, "report", "", "Write a JSON run report to a file")
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.include, "include", nil,
+		"Only process files in directories that match these globs (default **/*.go)")
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.exclude, "exclude", nil,
+		"Skip files and directories in directories that match these globs")
+	rootCmd.PersistentFlags().BoolVar(&cfg.noGitignore, "no-gitignore", false,
+		"Process files in directories even if a .gitignore file ignores them")
+	rootCmd.PersistentFlags().BoolVar(&cfg.withGenerated, "include-generated", false,
+		`Process files in directories marked "Code generated ... DO NOT EDIT."`)
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.tags, "tags", nil,
+		"Only process files in directories that the go command builds with these build tags and the GOOS and GOARCH")
+	rootCmd.Flags().BoolVar(&cfg.force, "force", false,
+		"Write output even if removing directives changes program behavior")
+	rootCmd.PersistentFlags().StringVar(&cfg.format, "report-format", formatText,
+		"Format of statistics, benchmarks, and the --summary: text, json, csv, or markdown")
+	rootCmd.PersistentFlags().BoolVar(&cfg.silent, "silent", false,
+		"Print nothing; report the result through the exit status only")
+	rootCmd.Flags().BoolVar(&cfg.stripBuildConstraints, "strip-build-constraints", false,
+		"Remove //go:build and // +build constraints")
+	rootCmd.Flags().BoolVar(&cfg.stripGenerate, "strip-generate", false, "Remove //go:generate directives")
+	rootCmd.Flags().BoolVar(&cfg.stripEmbed, "strip-embed", false, "Remove //go:embed directives")
+	rootCmd.Flags().BoolVar(&cfg.stripCgoPreamble, "strip-cgo-preamble", false,
+		`Remove the cgo preamble preceding import "C"`)
+	rootCmd.Flags().BoolVar(&cfg.stripCgoExports, "strip-cgo-exports", false,
+		"Remove //export directives from cgo files")
+	rootCmd.Flags().BoolVar(&cfg.stripCompilerDirectives, "strip-compiler-directives", false,
+		"Remove //go: compiler directives such as //go:noinline and //go:linkname")
+	rootCmd.Flags().BoolVar(&cfg.stripSysDirectives, "strip-sys-directives", false,
+		"Remove //sys and //sysnb syscall generation directives")
+	rootCmd.Flags().BoolVar(&cfg.stripLineDirectives, "strip-line-directives", false,
+		"Remove //line and /*line*/ position directives")
+	rootCmd.Flags().BoolVar(&cfg.stripDirectives, "strip-directives", false,
+		"Remove all directives: build constraints, //go:, cgo, //export, //sys, and //line")
+	rootCmd.Flags().BoolVar(&cfg.stripLicenseHeader, "strip-license-header", false,
+		"Remove a leading copyright or license header")
+	rootCmd.Flags().BoolVar(&cfg.stripSPDX, "strip-spdx", false, "Remove SPDX-License-Identifier comments")
+	rootCmd.Flags().BoolVar(&cfg.keepNolint, "keep-nolint", false, "Keep //nolint lint-suppression comments")
+	rootCmd.Flags().BoolVar(&cfg.keepDoc, "keep-doc", false,
+		"Keep the doc comments of exported types, functions, methods, constants, and variables")
+	rootCmd.Flags().BoolVar(&cfg.keepPackageDoc, "keep-package-doc", false,
+		"Keep the package doc comment preceding the package clause")
+	rootCmd.Flags().StringArrayVar(&cfg.keepPatterns, "keep-pattern", nil,
+		"Keep comments matching this regular expression (repeatable)")
+	rootCmd.Flags().StringArrayVar(&cfg.removePatterns, "remove-pattern", nil,
+		"Remove only comments matching this regular expression (repeatable)")
+	rootCmd.Flags().BoolVar(&cfg.keepDeprecated, "keep-deprecated", false,
+		`Keep "Deprecated:" notices in comments (implied by --keep-doc)`)
+	rootCmd.Flags().BoolVar(&cfg.keepAnnotations, "keep-annotations", false,
+		"Keep @ annotations for code generators, such as // @Router for swaggo")
+	rootCmd.Flags().BoolVar(&cfg.onlyTodos, "only-todos", false,
+		"Remove only TODO, FIXME, and HACK comments, keeping all others")
+	rootCmd.Flags().BoolVar(&cfg.keepTodos, "keep-todos", false, "Keep TODO, FIXME, and HACK comments")
+	rootCmd.Flags().BoolVar(&cfg.onlyLineComments, "only-line-comments", false,
+		"Remove only // line comments, keeping /* */ block comments")
+	rootCmd.Flags().BoolVar(&cfg.onlyBlockComments, "only-block-comments", false,
+		"Remove only /* */ block comments, keeping // line comments")
+	rootCmd.Flags().BoolVar(&cfg.onlyFuncBodies, "only-func-bodies", false,
+		"Remove only comments inside function and method bodies, keeping those of declarations")
+	rootCmd.Flags().StringArrayVar(&cfg.lines, "lines", nil,
+		"Remove only comments beginning on lines START:END, or on line N (repeatable)")
+	rootCmd.Flags().StringVar(&cfg.olderThan, "older-than", "",
+		"Remove only comments last changed before an age such as 2y, 6m, or 3w, or a date, per git blame")
+	rootCmd.Flags().StringVar(&cfg.author, "author", "",
+		"Remove only comments last changed by authors matching a regular expression, per git blame")
+	rootCmd.Flags().BoolVar(&cfg.interactive, "interactive", false,
+		"Show each comment in context and ask whether to remove it, like git add -p")
+	rootCmd.Flags().BoolVar(&cfg.review, "review", false,
+		"Select the comments to remove on a full-screen list with a diff preview")
+	rootCmd.Flags().BoolVar(&cfg.verify, "verify", false,
+		"Fail unless a second pass over the input and a pass over the output give the same result")
+	rootCmd.Flags().BoolVar(&cfg.verifyCompile, "verify-compile", false,
+		"Fail unless the package of each input file still builds with the output in place of the file")
+	rootCmd.Flags().BoolVar(&cfg.verifyAST, "verify-ast", false,
+		"Fail unless the output parses to the syntax tree of the input but for comments and positions")
+	rootCmd.Flags().BoolVar(&cfg.stats, "stats", false,
+		"Print the comments, lines, bytes, and estimated tokens removed from each input to standard error")
+	rootCmd.Flags().BoolVar(&cfg.summary, "summary", false,
+		"Print the totals of the run to standard error: files, comments, lines, bytes, and estimated tokens removed, "+
+			"and time taken")
+	rootCmd.Flags().BoolVar(&cfg.savings, "savings", false,
+		"Print the bytes and lines saved in each input, largest savings first, to standard error after a run")
+	rootCmd.Flags().BoolVar(&cfg.keepFieldComments, "keep-field-comments", false,
+		"Keep end-of-line comments on struct fields and constants")
+	rootCmd.Flags().BoolVar(&cfg.strictUTF8, "strict-utf8", false, "Reject input that contains invalid UTF-8")
+	rootCmd.Flags().StringVar(&cfg.encoding, "encoding", encodingAuto,
+		"Encoding of the input: auto, utf-8, utf-16le, utf-16be, or latin1, decoded to UTF-8 before parsing")
+	rootCmd.Flags().BoolVar(&cfg.keepEncoding, "keep-encoding", false,
+		"Write the output in the encoding of the input instead of UTF-8")
+	rootCmd.Flags().BoolVar(&cfg.bestEffort, "best-effort", false,
+		"Clean code that does not parse or tokenize as far as possible instead of failing")
+	rootCmd.Flags().BoolVar(&cfg.recoverPartial, "recover-partial", false,
+		"Clean the declarations that parse, leaving those with syntax errors unchanged")
+	rootCmd.Flags().IntVarP(&cfg.jobs, "jobs", "j", runtime.NumCPU(),
+		"Number of input files to process at the same time")
+	rootCmd.Flags().BoolVar(&cfg.noProgress, "no-progress", false,
+		"Do not show the number of files done on standard error when processing several files")
+	rootCmd.Flags().BoolVar(&cfg.redactCrashes, "redact-crashes", false,
+		"Mask the identifiers, literals, and comments of the input saved with a crash report")
+	rootCmd.Flags().StringVar(&cfg.cacheDir, "cache", "",
+		"Reuse the results for files with unchanged content and options from a cache in this directory")
+	rootCmd.Flags().StringVar(&cfg.since, "since", "",
+		"Process only files modified after a time, the modification time of a file, an age, or a date")
+	rootCmd.Flags().StringVar(&cfg.statePath, "state", "",
+		"Process only files modified since the last successful run recorded in this file, then record this run")
+	rootCmd.PersistentFlags().StringVar(&cfg.cpuProfile, "cpuprofile", "", "Write a CPU profile to a file")
+	rootCmd.PersistentFlags().StringVar(&cfg.memProfile, "memprofile", "",
+		"Write a memory allocation profile to a file")
+	rootCmd.Flags().StringVar(&cfg.pprofAddr, "pprof-addr", "",
+		"Serve net/http/pprof at this address, such as localhost:6060, with --serve-stdio")
+}
+
+// removerOptions translates the command-line configuration into options
+// for the comment remover. It fails if a --keep-pattern or
+// --remove-pattern value is not a valid regular expression, if a --lines
+// value is not a valid line range, if --eol is not a known line ending, if
+// --indent, --tabwidth, --style, or --engine is invalid, or if
+// --preserve-format is combined with another engine than scanner.
+func removerOptions() ([]commentremover.Option, error) {
+	keepPatterns, err := compilePatterns(cfg.keepPatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	removePatterns, err := compilePatterns(cfg.removePatterns)
+	if err != nil {
+		return nil, err
+	}
+
+	lineRanges, err := parseLineRanges(cfg.lines)
+	if err != nil {
+		return nil, err
+	}
+
+	lineEnding, err := parseLineEnding(cfg.eol)
+	if err != nil {
+		return nil, err
+	}
+
+	indent, err := parseIndent(cfg.indent)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.tabWidth < 1 {
+		return nil, fmt.Errorf("%w: %d", errInvalidTabWidth, cfg.tabWidth)
+	}
+
+	style, err := parseStyle(cfg.style)
+	if err != nil {
+		return nil, err
+	}
+
+	engine, err := parseEngine(cfg.engine)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.preserveFormat {
+		if engine != commentremover.EngineAuto && engine != commentremover.EngineScanner {
+			return nil, errEngineConflict
+		}
+
+		engine = commentremover.EngineScanner
+	}
+
+	opts := []commentremover.Option{
+		commentremover.WithEngine(engine),
+		commentremover.KeepBuildConstraints(keepDirective(cfg.stripBuildConstraints)),
+		commentremover.KeepGenerate(keepDirective(cfg.stripGenerate)),
+		commentremover.KeepEmbed(keepDirective(cfg.stripEmbed)),
+		commentremover.KeepCgoPreamble(keepDirective(cfg.stripCgoPreamble)),
+		commentremover.KeepCgoExports(keepDirective(cfg.stripCgoExports)),
+		commentremover.KeepCompilerDirectives(keepDirective(cfg.stripCompilerDirectives)),
+		commentremover.KeepSyscallDirectives(keepDirective(cfg.stripSysDirectives)),
+		commentremover.KeepLineDirectives(keepDirective(cfg.stripLineDirectives)),
+		commentremover.KeepLicenseHeader(!cfg.stripLicenseHeader),
+		commentremover.KeepSPDX(!cfg.stripSPDX),
+		commentremover.KeepNolint(cfg.keepNolint),
+		commentremover.KeepDoc(cfg.keepDoc),
+		commentremover.KeepPackageDoc(cfg.keepPackageDoc),
+		commentremover.KeepDeprecated(cfg.keepDeprecated || cfg.keepDoc),
+		commentremover.KeepAnnotations(cfg.keepAnnotations),
+		commentremover.KeepTodos(cfg.keepTodos),
+		commentremover.OnlyTodos(cfg.onlyTodos),
+		commentremover.KeepLineComments(cfg.onlyBlockComments),
+		commentremover.KeepBlockComments(cfg.onlyLineComments),
+		commentremover.OnlyFuncBodies(cfg.onlyFuncBodies),
+		commentremover.KeepFieldComments(cfg.keepFieldComments),
+		commentremover.StrictUTF8(cfg.strictUTF8),
+		commentremover.BestEffort(cfg.bestEffort),
+		commentremover.RecoverPartial(cfg.recoverPartial),
+		commentremover.MinimalDiff(cfg.minimalDiff),
+		commentremover.SqueezeBlank(cfg.squeezeBlank),
+		commentremover.PreserveLines(cfg.preserveLines),
+		commentremover.WithLineEnding(lineEnding),
+		commentremover.KeepBOM(!cfg.stripBOM),
+		commentremover.WithIndent(indent),
+		commentremover.TabWidth(cfg.tabWidth),
+		commentremover.WithStyle(style),
+		commentremover.KeepPattern(keepPatterns...),
+		commentremover.RemovePattern(removePatterns...),
+	}
+
+	return append(opts, lineRanges...), nil
+}
+
+// parseLineRanges converts --lines values, "START:END" or "N", to options
+// restricting removal to those lines.
+func parseLineRanges(texts []string) ([]commentremover.Option, error) {
+	opts := make([]commentremover.Option, 0, len(texts))
+
+	for _, text := range texts {
+		firstText, lastText, ok := strings.Cut(text, ":")
+		if !ok {
+			lastText = firstText
+		}
+
+		first, firstErr := strconv.Atoi(firstText)
+		last, lastErr := strconv.Atoi(lastText)
+
+		if firstErr != nil || lastErr != nil || first < 1 || last < first {
+			return nil, fmt.Errorf("%w: %q", errInvalidLineRange, text)
+		}
+
+		opts = append(opts, commentremover.LineRange(first, last))
+	}
+
+	return opts, nil
+}
+
+// parseLineEnding converts an --eol value to a line ending.
+func parseLineEnding(text string) (commentremover.LineEnding, error) {
+	for _, ending := range []commentremover.LineEnding{
+		commentremover.LineEndingPreserve, commentremover.LineEndingLF, commentremover.LineEndingCRLF,
+	} {
+		if text == ending.String() {
+			return ending, nil
+		}
+	}
+
+	return commentremover.LineEndingPreserve, fmt.Errorf("%w: %q", errInvalidLineEnding, text)
+}
+
+// encodingAuto is the --encoding value that detects the encoding of each
+// input.
+const encodingAuto = "auto"
+
+// parseEncoding converts an --encoding value other than auto to an
+// encoding.
+func parseEncoding(text string) (commentremover.Encoding, error) {
+	for _, enc := range []commentremover.Encoding{
+		commentremover.EncodingUTF8, commentremover.EncodingUTF16LE, commentremover.EncodingUTF16BE,
+		commentremover.EncodingLatin1,
+	} {
+		if text == enc.String() {
+			return enc, nil
+		}
+	}
+
+	return commentremover.EncodingUTF8, fmt.Errorf("%w: %q", errInvalidEncoding, text)
+}
+
+// parseIndent converts an --indent value to an indentation.
+func parseIndent(text string) (commentremover.Indent, error) {
+	for _, indent := range []commentremover.Indent{commentremover.IndentTabs, commentremover.IndentSpaces} {
+		if text == indent.String() {
+			return indent, nil
+		}
+	}
+
+	return commentremover.IndentTabs, fmt.Errorf("%w: %q", errInvalidIndent, text)
+}
+
+// parseStyle converts a --style value to a style.
+func parseStyle(text string) (commentremover.Style, error) {
+	for _, style := range []commentremover.Style{commentremover.StyleGofmt, commentremover.StyleGofumpt} {
+		if text == style.String() {
+			return style, nil
+		}
+	}
+
+	return commentremover.StyleGofmt, fmt.Errorf("%w: %q", errInvalidStyle, text)
+}
+
+// parseEngine converts an --engine value to an engine.
+func parseEngine(text string) (commentremover.Engine, error) {
+	for _, engine := range []commentremover.Engine{
+		commentremover.EngineAuto, commentremover.EngineAST, commentremover.EngineScanner,
+	} {
+		if text == engine.String() {
+			return engine, nil
+		}
+	}
+
+	return commentremover.EngineAuto, fmt.Errorf("%w: %q", errInvalidEngine, text)
+}
+
+// keepDirective reports whether a kind of directive is kept, given the
+// value of its --strip-* flag. --strip-directives strips every kind.
+func keepDirective(strip bool) bool {
+	return !strip && !cfg.stripDirectives
+}
+
+// compilePatterns compiles the regular expressions texts.
+func compilePatterns(texts []string) ([]*regexp.Regexp, error) {
+	patterns := make([]*regexp.Regexp, 0, len(texts))
+
+	for _, text := range texts {
+		pattern, err := regexp.Compile(text)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %w", errInvalidPattern, text, err)
+		}
+
+		patterns = append(patterns, pattern)
+	}
+
+	return patterns, nil
 }
 
 // runFunction implements the root command. It reads Go source code from
-// a file or the clipboard, removes comments, and writes the result to
-// standard output.
+// files, the Go files under directories, or the clipboard, removes
+// comments, and writes the result to the input file, a templated output
+// file, or standard output, highlighting and paging it if requested. With
+// --interactive, each comment is shown on standard error and removed only
+// if the user confirms. With --review, the comments of each input are
+// selected on a full-screen list instead.
+// Path-specific overrides from the configuration are applied to each file.
+// If the automatic engine selection falls back to the scanner engine, the
+// reason is reported on standard error. With --silent nothing is written,
+// and success or failure is conveyed by the exit status alone. With
+// --cache, results are looked up in and added to a cache keyed by the
+// content of each file and the options, except with --older-than,
+// --author, --interactive, or --review. With --since or --state, files not
+// modified since the given time or the last successful run are skipped,
+// and with --state, the start of a successful run is recorded. With
+// --report, a JSON run report is written as well, even if processing
+// fails. When several files are given, a failure is reported for each file
+// that fails and the remaining files are still processed. With
+// --serve-stdio, editor requests are answered instead; see serveStdio.
 //
 // Errors are returned in the following cases:
 //   - Both a file path and the paste flag are specified (mutually exclusive)
 //   - Neither a file path nor the paste flag is specified
+//   - Several files are given without --write, --out-template, --golden-dir,
+//     or --silent
+//   - An input directory cannot be walked or holds no Go files
+//   - The pager mode is not recognized
+//   - Both --only-line-comments and --only-block-comments are specified
+//   - Both --preserve-lines and --squeeze-blank are specified
+//   - --engine is not auto, ast, or scanner, or --preserve-format is used
+//     with another engine than scanner
+//   - --encoding is not auto, utf-8, utf-16le, utf-16be, or latin1, an
+//     input cannot be decoded from it, or --keep-encoding cannot encode
+//     an output in it
+//   - --jobs is not positive
+//   - --since is invalid, the --state file cannot be read or written or
+//     holds no time, both are specified, or either is used without input
+//     files
+//   - --older-than or --author is invalid or used without input files, or
+//     git blame fails on an input file
+//   - --interactive is used with --serve-stdio or --silent
+//   - --review is used with --interactive, --serve-stdio, or --silent, or
+//     without a terminal, or a review is cancelled
+//   - --verify is used with --lines, --older-than, --author, --interactive,
+//     or --review, or a second pass over an input or a pass over its output
+//     gives a different result
+//   - --verify-ast finds that the output of an input differs from it in more
+//     than comments, or the input does not parse
+//   - --verify-compile is used with the paste flag, or the output of an
+//     input file changes its build constraints or breaks its package build
+//   - More than one of --write, --out-template, and --golden-dir is
+//     specified, or one of them is used with the paste flag
 //   - Reading from the file or clipboard fails
 //   - Comment removal fails
-func runFunction(_ *cobra.Command, args []string) error {
-	var (
-		sourceCode string
-		err        error
-	)
+//   - The removed comments change program behavior, the result is written
+//     to a file, and --force is not given
+//   - The output template is invalid, a golden file has no stable name, or
+//     writing the output file fails
+//   - With --source-map, the input is not a file, or the template is
+//     invalid or writing the source map fails
+//   - The pager fails to run
+//   - Writing the report fails
+//   - With --serve-stdio, inputs or an output destination are given, or a
+//     message is malformed
+//   - --pprof-addr is used without --serve-stdio, or its address cannot be
+//     listened on
+func runFunction(command *cobra.Command, args []string) error {
+	if cfg.serveStdio {
+		if len(args) > 0 || cfg.useClipboard || writesFiles() || cfg.reportPath != "" {
+			return errServeArgs
+		}
+
+		if blameRequested() {
+			return errBlameNeedsFile
+		}
+
+		if cfg.interactive {
+			return errInteractiveConflict
+		}
+
+		if cfg.review {
+			return errReviewConflict
+		}
+
+		if cfg.pprofAddr != "" {
+			stop, err := servePprof(cfg.pprofAddr)
+			if err != nil {
+				return err
+			}
+
+			defer stop()
+		}
+
+		return serveStdio(command.Context(), command.Flags(), os.Stdin, os.Stdout)
+	}
+
+	inputPaths, err := expandInputs(args)
+	if err != nil {
+		return err
+	}
+
+	cfg.filePaths = inputPaths
+
+	if err := validateArgs(); err != nil {
+		return err
+	}
+
+	start := time.Now()
+
+	if cfg.blame, err = newBlameFilter(start); err != nil {
+		return err
+	}
+
+	since, rewritten, err := modifiedSince(start)
+	if err != nil {
+		return err
+	}
+
+	if !since.IsZero() {
+		cfg.filePaths = changedSince(cfg.filePaths, since, rewritten)
+	}
+
+	if cfg.interactive {
+		cfg.reviewer = newReviewer(os.Stdin, os.Stderr)
+	}
 
-	if len(args) > 0 {
-		cfg.filePath = args[0]
+	// Results that depend on git history or on the user are not cached.
+	if cfg.cacheDir != "" && !blameRequested() && !cfg.interactive && !cfg.review {
+		cfg.cache = newResultCache(cfg.cacheDir, command.Flags())
 	}
 
+	report := newRunReport(command.Flags())
+	err = processInputs(command.Context(), command.Flags(), report)
+
+	if cfg.savings {
+		printSavings(report.Files)
+	}
+
+	if cfg.summary {
+		printSummary(report.Files, time.Since(start))
+	}
+
+	if cfg.reportPath != "" {
+		if !cfg.useClipboard && len(cfg.filePaths) > 0 {
+			report.GitCommit = gitCommit(cfg.filePaths[0])
+		}
+
+		if reportErr := report.finish(cfg.reportPath); err == nil {
+			err = reportErr
+		}
+	}
+
+	if cfg.statePath != "" && err == nil {
+		err = recordRun(start)
+	}
+
+	return err
+}
+
+// validateArgs checks the command-line configuration for conflicting or
+// missing settings.
+func validateArgs() error {
 	switch {
-	case cfg.useClipboard && cfg.filePath != "":
+	case cfg.useClipboard && len(cfg.filePaths) > 0:
 		return errMutuallyExclusive
-	case !cfg.useClipboard && cfg.filePath == "":
+	case !cfg.useClipboard && len(cfg.filePaths) == 0:
 		return errNoInputMethod
+	case len(cfg.filePaths) > 1 && !writesFiles() && !cfg.silent:
+		return errMultipleNeedOutput
+	case cfg.write && cfg.outTemplate != "", cfg.goldenDir != "" && (cfg.write || cfg.outTemplate != ""):
+		return errOutputConflict
+	case writesFiles() && cfg.useClipboard:
+		return errOutputNeedsFile
+	case cfg.onlyLineComments && cfg.onlyBlockComments:
+		return errCommentKindConflict
+	case cfg.preserveLines && cfg.squeezeBlank:
+		return errLayoutConflict
+	case cfg.sourceMap != "" && cfg.useClipboard:
+		return errSourceMapNeedsFile
+	case blameRequested() && cfg.useClipboard:
+		return errBlameNeedsFile
+	case cfg.interactive && cfg.silent:
+		return errInteractiveConflict
+	case cfg.review && (cfg.interactive || cfg.silent):
+		return errReviewConflict
+	case cfg.verify && (len(cfg.lines) > 0 || blameRequested() || cfg.interactive || cfg.review):
+		return errVerifyConflict
+	case (cfg.since != "" || cfg.statePath != "") && cfg.useClipboard:
+		return errIncrementalNeedsFile
+	case cfg.verifyCompile && cfg.useClipboard:
+		return errCompileNeedsFile
+	case cfg.since != "" && cfg.statePath != "":
+		return errIncrementalConflict
+	case cfg.pprofAddr != "":
+		return errPprofNeedsServe
+	case cfg.jobs < 1:
+		return fmt.Errorf("%w: %d", errInvalidJobs, cfg.jobs)
+	}
+
+	if cfg.encoding != encodingAuto {
+		if _, err := parseEncoding(cfg.encoding); err != nil {
+			return err
+		}
+	}
+
+	if err := validateReportFormat(); err != nil {
+		return err
+	}
+
+	return validatePagerMode(cfg.pager)
+}
+
+// blameRequested reports whether comments are selected by git blame data,
+// with --older-than or --author.
+func blameRequested() bool {
+	return cfg.olderThan != "" || cfg.author != ""
+}
+
+// writesFiles reports whether results are written to files rather than to
+// standard output.
+func writesFiles() bool {
+	return cfg.write || cfg.outTemplate != "" || cfg.goldenDir != ""
+}
+
+// expandInputs replaces the directories in args with the Go files selected
+// under them by the walker options. Other arguments are kept as-is, so that
+// a missing file is reported when it is read. Build constraints are only
+// looked at once --tags is given, if only as --tags "", since matching them
+// would leave out the files for other platforms that are to be cleaned too.
+func expandInputs(args []string) ([]string, error) {
+	opts := []walker.Option{
+		walker.Include(cfg.include...),
+		walker.Exclude(cfg.exclude...),
+		walker.RespectGitignore(!cfg.noGitignore),
+		walker.SkipGenerated(!cfg.withGenerated),
+	}
+
+	if cfg.tags != nil {
+		ctxt := build.Default
+		ctxt.BuildTags = cfg.tags
+		opts = append(opts, walker.BuildContext(&ctxt))
+	}
+
+	inputPaths := make([]string, 0, len(args))
+
+	for _, arg := range args {
+		if info, err := os.Stat(arg); err != nil || !info.IsDir() {
+			inputPaths = append(inputPaths, arg)
+
+			continue
+		}
+
+		found := len(inputPaths)
+
+		for inputPath, err := range walker.Walk([]string{arg}, opts...) {
+			if err != nil {
+				return nil, fmt.Errorf("failed to list input files: %w", err)
+			}
+
+			inputPaths = append(inputPaths, inputPath)
+		}
+
+		if len(inputPaths) == found {
+			return nil, fmt.Errorf("%w: %s", errNoGoFiles, arg)
+		}
 	}
 
+	return inputPaths, nil
+}
+
+// readInput returns the source code at inputPath, or on the clipboard if
+// --paste is in effect, and a name for it to use in messages.
+func readInput(inputPath string) (string, string, error) {
 	if cfg.useClipboard {
-		sourceCode, err = clipboard.ReadAll()
+		sourceCode, err := clipboard.ReadAll()
 		if err != nil {
-			return fmt.Errorf("failed to read from clipboard: %w", err)
+			return "", "", fmt.Errorf("failed to read from clipboard: %w", err)
 		}
-	} else {
-		fileContent, err := os.ReadFile(cfg.filePath)
+
+		return sourceCode, tr("clipboard"), nil
+	}
+
+	fileContent, err := readFileString(inputPath)
+	if err != nil {
+		return "", "", fmt.Errorf("file read failed: %w", err)
+	}
+
+	return fileContent, inputPath, nil
+}
+
+// decodeInput returns source, the content of an input, decoded to UTF-8
+// from the encoding that --encoding names or, for auto, that
+// commentremover.DetectEncoding finds, along with that encoding. With
+// --strict-utf8, auto does not take invalid UTF-8 for Latin-1, so that it
+// is rejected as such.
+func decodeInput(source string) (string, commentremover.Encoding, error) {
+	enc, err := parseEncoding(cfg.encoding)
+	if cfg.encoding == encodingAuto {
+		enc, err = commentremover.DetectEncoding(source), nil
+		if enc == commentremover.EncodingLatin1 && cfg.strictUTF8 {
+			enc = commentremover.EncodingUTF8
+		}
+	}
+
+	if err == nil {
+		source, err = commentremover.DecodeSource(source, enc)
+	}
+
+	if err != nil {
+		return "", enc, fmt.Errorf("failed to decode input: %w", err)
+	}
+
+	return source, enc, nil
+}
+
+// processInputs processes the clipboard or each input file. The error of a
+// single input is returned as-is; with several files, each failure is
+// reported as it happens and a summary error is returned. Comments are
+// removed from up to --jobs files at a time, but the results are written
+// and reported in the order of the files. Unless newProgressLine rules it
+// out, the files done so far are shown on standard error. Once ctx is
+// done, the remaining files are skipped.
+func processInputs(ctx context.Context, flags *pflag.FlagSet, report *runReport) error {
+	inputPaths := cfg.filePaths
+	if cfg.useClipboard {
+		inputPaths = []string{""}
+	}
+
+	if len(inputPaths) == 1 {
+		return processInput(flags, report, inputPaths[0])
+	}
+
+	progress = newProgressLine(len(inputPaths))
+
+	defer func() {
+		progress.clear()
+		progress = nil
+	}()
+
+	outcome := func(i int) inputOutcome {
+		opts, err := optionsFor(flags, inputPaths[i])
+
+		return stripInput(inputPaths[i], opts, err)
+	}
+
+	// Questions about the comments of one file must not mix with the
+	// messages about another, so asking the user rules out a pool.
+	if cfg.jobs > 1 && !cfg.interactive && !cfg.review {
+		pool := startInputPool(ctx, flags, inputPaths, cfg.jobs)
+		defer pool.wait()
+
+		outcome = pool.outcome
+	}
+
+	failed := 0
+
+	for done, inputPath := range inputPaths {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("%w: %d/%d: %w", errStopped, done, len(inputPaths), err)
+		}
+
+		next := outcome(done)
+		if errors.Is(next.err, errStopped) {
+			return fmt.Errorf("%w: %d/%d: %w", errStopped, done, len(inputPaths), ctx.Err())
+		}
+
+		if err := finishInput(report, next); err != nil {
+			failed++
+
+			notef("%s: %s", inputPath, localizeMessage(err.Error()))
+		}
+
+		progress.update(done+1, failed)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%w: %d/%d", errFilesFailed, failed, len(inputPaths))
+	}
+
+	return nil
+}
+
+// processInput reads the input at inputPath, or the clipboard if inputPath
+// is empty, removes its comments, and writes the result, recording the
+// outcome in report.
+func processInput(flags *pflag.FlagSet, report *runReport, inputPath string) error {
+	opts, err := optionsFor(flags, inputPath)
+
+	return finishInput(report, stripInput(inputPath, opts, err))
+}
+
+// inputOutcome is the result of removing the comments of an input, before
+// it is written.
+type inputOutcome struct {
+	path     string                  // path is the input, or empty for the clipboard.
+	name     string                  // name is the input as called in messages.
+	source   string                  // source is the input.
+	encoding commentremover.Encoding // encoding is the encoding that the input was decoded from.
+	result   commentremover.Result   // result holds the stripped source, if stripped is set.
+	stripped bool                    // stripped is set once comments were removed, even if verification failed.
+	err      error                   // err is the first failure, if any.
+}
+
+// stripInput reads the input at inputPath and removes its comments with
+// opts, the options for it, asking the user about them with --interactive
+// or --review. If err, the error from determining opts, is set, nothing is
+// removed. Except for the asking, it is safe for concurrent use.
+func stripInput(inputPath string, opts []commentremover.Option, err error) inputOutcome {
+	sourceCode, inputName, readErr := readInput(inputPath)
+	if readErr != nil {
+		err = readErr
+	}
+
+	encoding := commentremover.EncodingUTF8
+	if err == nil {
+		sourceCode, encoding, err = decodeInput(sourceCode)
+	}
+
+	outcome := inputOutcome{path: inputPath, name: inputName, source: sourceCode, encoding: encoding, err: err}
+
+	if err == nil && cfg.blame != nil {
+		var filter commentremover.Option
+
+		filter, err = cfg.blame.option(inputPath)
+		opts = append(opts, filter)
+	}
+
+	if err == nil && cfg.reviewer != nil {
+		opts = append(opts, cfg.reviewer.option(inputName, sourceCode))
+	}
+
+	if err == nil && cfg.review {
+		opts, err = reviewOptions(inputName, sourceCode, opts)
+	}
+
+	if err == nil && cfg.cache != nil {
+		outcome.result, outcome.stripped = cfg.cache.load(inputPath, sourceCode)
+	}
+
+	if err == nil && !outcome.stripped {
+		outcome.result, err = commentremover.Process(sourceCode, opts...)
+		outcome.stripped = err == nil
+
+		if err != nil {
+			err = fmt.Errorf("failed to remove comments from source: %w",
+				withSourceContext(inputName, sourceCode, err))
+		} else if cfg.verify {
+			err = verifyResult(inputName, sourceCode, outcome.result.Source, opts)
+		}
+
+		if err == nil && cfg.cache != nil {
+			cfg.cache.store(inputPath, sourceCode, outcome.result)
+		}
+	}
+
+	if err == nil && cfg.verifyAST {
+		err = verifyEquivalence(inputName, sourceCode, outcome.result.Source)
+	}
+
+	if err == nil && cfg.verifyCompile {
+		err = verifyCompiles(inputPath, inputName, sourceCode, outcome.result.Source)
+	}
+
+	outcome.err = err
+
+	return outcome
+}
+
+// finishInput writes the result of outcome, and its source map if
+// requested, recording the outcome in report.
+func finishInput(report *runReport, outcome inputOutcome) error {
+	entry := fileReport{Path: outcome.name}
+	err := outcome.err
+
+	if outcome.stripped {
+		entry.Engine = outcome.result.Engine.String()
+		entry.Fallback = outcome.result.Fallback
+		entry.Unparsed = outcome.result.Unparsed
+		entry.Stats = &outcome.result.Stats
+	}
+
+	if outcome.encoding != commentremover.EncodingUTF8 {
+		entry.Encoding = outcome.encoding.String()
+	}
+
+	if err == nil {
+		var result commentremover.Result
+
+		result, err = encodeResult(outcome)
+		if err == nil {
+			err = writeResult(outcome.path, outcome.name, result)
+		}
+
+		if err == nil && cfg.sourceMap != "" {
+			err = writeSourceMap(outcome.path, outcome.source, outcome.result.Source)
+		}
+	}
+
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	report.Files = append(report.Files, entry)
+
+	return err
+}
+
+// writeResult reports an engine fallback or unparsed regions and writes the
+// stripped source of the input at inputPath, called inputName in messages,
+// to its destination.
+func writeResult(inputPath, inputName string, result commentremover.Result) error {
+	noteRecovery(inputName, result)
+
+	if cfg.stats {
+		printStats(inputName, result.Stats)
+	}
+
+	if err := guardBehavior(inputName, result); err != nil {
+		return err
+	}
+
+	switch {
+	case cfg.write:
+		if err := writeFile(inputPath, result.Source); err != nil {
+			return err
+		}
+
+		noteRewritten(inputPath)
+
+		return nil
+	case cfg.outTemplate != "":
+		path, err := outputPath(cfg.outTemplate, inputPath)
 		if err != nil {
-			return fmt.Errorf("file read failed: %w", err)
+			return err
 		}
 
-		sourceCode = string(fileContent)
+		return writeFile(path, result.Source)
+	case cfg.goldenDir != "":
+		path, err := goldenPath(cfg.goldenDir, inputPath)
+		if err != nil {
+			return err
+		}
+
+		return writeFile(path, goldenContent(result.Source))
+	case cfg.silent:
+		return nil
 	}
 
-	result, err := commentremover.RemoveComments(sourceCode)
+	output := result.Source
+	if cfg.highlight && colorEnabled() {
+		output = highlight(output)
+	}
+
+	return writeOutput(cfg.pager, output)
+}
+
+// encodeResult returns the result of outcome with its source in the
+// encoding of the input if --keep-encoding is given. Otherwise, a note
+// tells that an input not in UTF-8 is written in UTF-8.
+func encodeResult(outcome inputOutcome) (commentremover.Result, error) {
+	result := outcome.result
+	if outcome.encoding == commentremover.EncodingUTF8 {
+		return result, nil
+	}
+
+	if !cfg.keepEncoding {
+		notef("%s: decoded from %s to UTF-8", outcome.name, outcome.encoding)
+
+		return result, nil
+	}
+
+	encoded, err := commentremover.EncodeSource(result.Source, outcome.encoding)
 	if err != nil {
-		return fmt.Errorf("failed to remove comments from source: %w", err)
+		return result, fmt.Errorf("failed to encode output: %w", err)
 	}
 
-	_, _ = fmt.Fprintln(os.Stdout, result)
+	result.Source = encoded
+
+	return result, nil
+}
+
+// noteRecovery warns that the input called inputName did not parse, if its
+// result says so: that the scanner engine was used in place of the AST
+// engine, or which lines --recover-partial left unchanged.
+func noteRecovery(inputName string, result commentremover.Result) {
+	if result.Fallback != "" {
+		notef("%s: used %s engine: %s", inputName, result.Engine, localizeMessage(result.Fallback))
+	}
+
+	for _, region := range result.Unparsed {
+		notef("%s: lines %d-%d left unchanged, they do not parse: %s",
+			inputName, region.FirstLine, region.LastLine, region.Error)
+	}
+}
+
+// guardBehavior warns about each removed comment that changes how the input
+// called inputName builds or runs, such as a build constraint or a cgo
+// preamble, even if its removal was requested. Output bound for a file is
+// refused with an error. With --force, no checks are made.
+func guardBehavior(inputName string, result commentremover.Result) error {
+	if cfg.force || len(result.Hazards) == 0 {
+		return nil
+	}
+
+	for _, hazard := range result.Hazards {
+		notef("%s:%d: warning: removing %s changes program behavior", inputName, hazard.Line, tr(hazard.Kind))
+	}
+
+	if writesFiles() {
+		return fmt.Errorf("%w: %s", errBehaviorChange, inputName)
+	}
 
 	return nil
 }
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// savingsColumns are the columns of --savings in the csv and markdown
+// formats.
+var savingsColumns = []string{"path", "bytes saved", "lines removed", "percent saved", "input bytes"}
+
+// fileSavings is what removing the comments of an input saved, as printed
+// by --savings.
+type fileSavings struct {
+	Path         string  `json:"path"`
+	BytesSaved   int     `json:"bytesSaved"`
+	LinesRemoved int     `json:"linesRemoved"`
+	PercentSaved float64 `json:"percentSaved"`
+	InputBytes   int     `json:"inputBytes"`
+}
+
+// printSavings implements --savings, printing the bytes and lines saved in
+// each of files, the outcomes of a run, to standard error in the
+// --report-format, with the files that saved the most bytes first. Inputs
+// that failed are left out.
+func printSavings(files []fileReport) {
+	if cfg.silent {
+		return
+	}
+
+	savings := make([]fileSavings, 0, len(files))
+
+	for _, file := range files {
+		if file.Stats == nil {
+			continue
+		}
+
+		savings = append(savings, fileSavings{
+			Path:         file.Path,
+			BytesSaved:   file.Stats.BytesSaved,
+			LinesRemoved: file.Stats.LinesRemoved,
+			PercentSaved: file.Stats.PercentSaved(),
+			InputBytes:   file.Stats.InputBytes,
+		})
+	}
+
+	slices.SortStableFunc(savings, func(a, b fileSavings) int {
+		return cmp.Or(cmp.Compare(b.BytesSaved, a.BytesSaved), cmp.Compare(b.LinesRemoved, a.LinesRemoved),
+			strings.Compare(a.Path, b.Path))
+	})
+
+	progress.clear()
+
+	switch cfg.format {
+	case formatJSON:
+		_ = writeJSON(os.Stderr, savings)
+	case formatCSV, formatMarkdown:
+		rows := make([][]string, 0, len(savings))
+		for _, saved := range savings {
+			rows = append(rows, []string{
+				saved.Path, strconv.Itoa(saved.BytesSaved), strconv.Itoa(saved.LinesRemoved),
+				strconv.FormatFloat(saved.PercentSaved, 'f', 1, 64), strconv.Itoa(saved.InputBytes),
+			})
+		}
+
+		_ = writeTable(os.Stderr, savingsColumns, rows)
+	default:
+		var out strings.Builder
+
+		_, _ = fmt.Fprintf(&out, "%12s %14s %7s  %s\n", tr("BYTES SAVED"), tr("LINES REMOVED"), tr("SAVED"), tr("FILE"))
+
+		for _, saved := range savings {
+			_, _ = fmt.Fprintf(&out, "%12d %14d %6.1f%%  %s\n",
+				saved.BytesSaved, saved.LinesRemoved, saved.PercentSaved, saved.Path)
+		}
+
+		_, _ = fmt.Fprint(os.Stderr, out.String())
+	}
+}
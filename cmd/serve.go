@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pierow2k/nogocomments/pkg/commentremover"
+	"github.com/spf13/pflag"
+)
+
+// contentLength is the header that precedes each message of the
+// --serve-stdio protocol, as in the Language Server Protocol.
+const contentLength = "Content-Length"
+
+// serveProtocolVersion is the version of the --serve-stdio protocol, as
+// reported by the capabilities command. It is incremented whenever a change
+// would break existing clients.
+const serveProtocolVersion = 1
+
+// maxMessageSize is the largest body of a --serve-stdio message that is
+// read. It leaves room for the JSON escaping of buffers many times the
+// size at which the auto engine turns to the scanner.
+const maxMessageSize = 64 << 20
+
+var (
+	// errServeArgs is returned when --serve-stdio is combined with inputs or
+	// an output destination.
+	errServeArgs = errors.New("--serve-stdio does not take inputs or an output destination")
+
+	// errInvalidHeader is returned when a message of the --serve-stdio
+	// protocol lacks a valid Content-Length header.
+	errInvalidHeader = errors.New("invalid message header")
+
+	// errMessageTooLarge is returned when the Content-Length of a message of
+	// the --serve-stdio protocol exceeds maxMessageSize.
+	errMessageTooLarge = errors.New("message too large")
+
+	// errInvalidRequest is returned when a message of the --serve-stdio
+	// protocol is not a valid request.
+	errInvalidRequest = errors.New("invalid request")
+
+	// errInvalidSelection is returned when the selection of a request is not
+	// a range of lines counting from 1.
+	errInvalidSelection = errors.New("invalid selection")
+)
+
+// serveRequest asks for the comments to be removed from the content of an
+// editor buffer. Filename selects the path-specific overrides that apply.
+// If Selection is set, only the comments within it are removed and the
+// remaining code is left untouched.
+type serveRequest struct {
+	ID        json.RawMessage `json:"id,omitempty"`
+	Filename  string          `json:"filename"`
+	Content   string          `json:"content"`
+	Selection *lineSelection  `json:"selection,omitempty"`
+}
+
+// lineSelection is the range of lines Start through End, inclusive,
+// counting from 1.
+type lineSelection struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// serveResponse answers a serveRequest with the same ID. Applying Edits to
+// the content of the request, in order, yields the stripped source. If the
+// request fails, Error describes why and Edits is empty.
+type serveResponse struct {
+	ID    json.RawMessage `json:"id,omitempty"`
+	Edits []textEdit      `json:"edits"`
+	Error string          `json:"error,omitempty"`
+}
+
+// textEdit replaces the Length bytes of the content at the byte offset
+// Offset with NewText.
+type textEdit struct {
+	Offset  int    `json:"offset"`
+	Length  int    `json:"length"`
+	NewText string `json:"newText"`
+}
+
+// serveStdio implements --serve-stdio. It reads requests from in and writes
+// a response for each to out until in is exhausted, so that an editor can
+// keep a single process running. Every message is a JSON object preceded
+// by a Content-Length header and a blank line. A request that fails, or
+// whose message is larger than maxMessageSize, is answered with an error;
+// only a malformed or truncated message ends the session, or ctx being
+// done, which is checked before each request.
+func serveStdio(ctx context.Context, flags *pflag.FlagSet, in io.Reader, out io.Writer) error {
+	reader := bufio.NewReader(in)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("%w: %w", errStopped, err)
+		}
+
+		body, err := readMessage(reader)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		var response serveResponse
+
+		switch {
+		case errors.Is(err, errMessageTooLarge):
+			response = failedResponse(nil, err)
+		case err != nil:
+			return err
+		default:
+			response = serve(flags, body)
+		}
+
+		if err := writeMessage(out, response); err != nil {
+			return err
+		}
+	}
+}
+
+// readMessage returns the body of the next message from reader. It returns
+// io.EOF if reader is exhausted before a message begins. The body of a
+// message larger than maxMessageSize is skipped rather than read, and the
+// error wraps errMessageTooLarge.
+func readMessage(reader *bufio.Reader) ([]byte, error) {
+	length := -1
+
+	for started := false; ; started = true {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) && !started && line == "" {
+				return nil, io.EOF
+			}
+
+			return nil, fmt.Errorf("%w: %w", errInvalidHeader, io.ErrUnexpectedEOF)
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", errInvalidHeader, line)
+		}
+
+		if strings.EqualFold(strings.TrimSpace(name), contentLength) {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || length < 0 {
+				return nil, fmt.Errorf("%w: %q", errInvalidHeader, line)
+			}
+		}
+	}
+
+	if length < 0 {
+		return nil, fmt.Errorf("%w: %s", errInvalidHeader, contentLength)
+	}
+
+	if length > maxMessageSize {
+		if _, err := io.CopyN(io.Discard, reader, int64(length)); err != nil {
+			return nil, fmt.Errorf("%w: %w", errInvalidHeader, io.ErrUnexpectedEOF)
+		}
+
+		return nil, fmt.Errorf("%w: %d bytes, at most %d allowed", errMessageTooLarge, length, maxMessageSize)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidHeader, io.ErrUnexpectedEOF)
+	}
+
+	return body, nil
+}
+
+// writeMessage writes response to out as a message of the --serve-stdio
+// protocol.
+func writeMessage(out io.Writer, response serveResponse) error {
+	body, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	if _, err := fmt.Fprintf(out, "%s: %d\r\n\r\n%s", contentLength, len(body), body); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	return nil
+}
+
+// serve answers the request with the given body. Warnings about engine
+// fallbacks and removed directives are reported on standard error, as for
+// output written to standard output.
+func serve(flags *pflag.FlagSet, body []byte) serveResponse {
+	var request serveRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		return failedResponse(nil, fmt.Errorf("%w: %w", errInvalidRequest, err))
+	}
+
+	response := serveResponse{ID: request.ID, Edits: []textEdit{}}
+
+	opts, err := optionsFor(flags, request.Filename)
+	if err != nil {
+		return failedResponse(request.ID, err)
+	}
+
+	if selection := request.Selection; selection != nil {
+		if selection.Start < 1 || selection.End < selection.Start {
+			return failedResponse(request.ID, fmt.Errorf("%w: %d-%d", errInvalidSelection, selection.Start, selection.End))
+		}
+
+		opts = append(opts,
+			commentremover.WithEngine(commentremover.EngineScanner),
+			commentremover.LineRange(selection.Start, selection.End),
+		)
+	}
+
+	// An empty buffer has no comments to remove.
+	result, err := commentremover.Process(request.Content, opts...)
+	if errors.Is(err, commentremover.ErrEmptyInput) {
+		return response
+	}
+
+	if err != nil {
+		return failedResponse(request.ID, fmt.Errorf("failed to remove comments from source: %w",
+			withSourceContext(request.Filename, request.Content, err)))
+	}
+
+	inputName := request.Filename
+	noteRecovery(inputName, result)
+
+	if err := guardBehavior(inputName, result); err != nil {
+		return failedResponse(request.ID, err)
+	}
+
+	if edit, ok := diffEdit(request.Content, result.Source); ok {
+		response.Edits = append(response.Edits, edit)
+	}
+
+	return response
+}
+
+// failedResponse returns the response to the request with the given ID that
+// failed with err.
+func failedResponse(id json.RawMessage, err error) serveResponse {
+	return serveResponse{ID: id, Edits: []textEdit{}, Error: localizeMessage(err.Error())}
+}
+
+// diffEdit returns the single edit that turns before into after, replacing
+// what lies between their common prefix and suffix, and false if they are
+// equal. The edit never splits a UTF-8 sequence.
+func diffEdit(before, after string) (textEdit, bool) {
+	if before == after {
+		return textEdit{}, false
+	}
+
+	prefix := 0
+	for prefix < len(before) && prefix < len(after) && before[prefix] == after[prefix] {
+		prefix++
+	}
+
+	for prefix > 0 && prefix < len(before) && !utf8.RuneStart(before[prefix]) {
+		prefix--
+	}
+
+	suffix := 0
+	for suffix < len(before)-prefix && suffix < len(after)-prefix &&
+		before[len(before)-1-suffix] == after[len(after)-1-suffix] {
+		suffix++
+	}
+
+	for suffix > 0 && !utf8.RuneStart(before[len(before)-suffix]) {
+		suffix--
+	}
+
+	return textEdit{
+		Offset:  prefix,
+		Length:  len(before) - prefix - suffix,
+		NewText: after[prefix : len(after)-suffix],
+	}, true
+}
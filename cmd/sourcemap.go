@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/pierow2k/nogocomments/pkg/commentremover"
+)
+
+// sourceMapVersion is the version of the --source-map document format.
+const sourceMapVersion = 1
+
+// errSourceMapNeedsFile is returned when --source-map is used with input
+// that does not come from a file.
+var errSourceMapNeedsFile = errors.New("--source-map requires an input file")
+
+// sourceMap is the JSON document written by --source-map. It maps the
+// positions of the code in the output back to the input file.
+type sourceMap struct {
+	Version  int                      `json:"version"`
+	Source   string                   `json:"source"`
+	Segments []commentremover.Segment `json:"segments"`
+}
+
+// writeSourceMap writes the source map of output, the result of removing
+// comments from source, the content of the file at inputPath, to the file
+// named by the --source-map template.
+func writeSourceMap(inputPath, source, output string) error {
+	path, err := outputPath(cfg.sourceMap, inputPath)
+	if err != nil {
+		return err
+	}
+
+	segments := commentremover.MapPositions(source, output)
+	if segments == nil {
+		segments = []commentremover.Segment{}
+	}
+
+	encoded, err := json.MarshalIndent(sourceMap{
+		Version:  sourceMapVersion,
+		Source:   filepath.ToSlash(inputPath),
+		Segments: segments,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to write source map: %w", err)
+	}
+
+	return writeFile(path, string(encoded)+"\n")
+}
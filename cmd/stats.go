@@ -0,0 +1,607 @@
+package cmd
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pierow2k/nogocomments/pkg/commentremover"
+	"github.com/spf13/cobra"
+)
+
+// Output formats of the stats command.
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+// heatmapBarWidth is the width, in characters, of a full density bar.
+const heatmapBarWidth = 20
+
+// errInvalidFormat is returned when --report-format names an unknown
+// format.
+var errInvalidFormat = errors.New("invalid format (want text, json, csv, or markdown)")
+
+// statsColumns are the columns of the stats command in the csv and markdown
+// formats.
+var statsColumns = []string{
+	"kind", "path", "package", "files", "comments", "code", "lines", "ratio", "density", "documented", "exported",
+}
+
+// statsLong is the long description of the stats command. It doubles as a
+// message catalog key.
+const statsLong = `stats reports how much of the Go source code under the given files and
+directories is comments, without changing anything. Directories are
+walked like the root command walks them. With --heatmap, the totals are
+broken down by directory, largest concentration of comment lines first;
+with --packages, by package; and with --files, by file.`
+
+// statsCmd reports comment statistics.
+var statsCmd = &cobra.Command{
+	Use:   "stats [INPUT_FILE|DIR...]",
+	Short: "Report where comments are in Go source code.",
+	Long:  statsLong,
+	Args:  cobra.ArbitraryArgs,
+	RunE:  runStats,
+}
+
+// init registers the stats command and its flags.
+func init() {
+	statsCmd.Flags().BoolVar(&cfg.heatmap, "heatmap", false, "Break the statistics down by directory")
+	statsCmd.Flags().BoolVar(&cfg.statsPackages, "packages", false, "Break the statistics down by package")
+	statsCmd.Flags().BoolVar(&cfg.statsFiles, "files", false, "Break the statistics down by file")
+	statsCmd.Flags().StringVar(&cfg.format, "format", formatText, "Output format: text or json")
+	_ = statsCmd.Flags().MarkDeprecated("format", "use --report-format instead")
+	rootCmd.AddCommand(statsCmd)
+}
+
+// statsNode holds the comment statistics of a directory, including the
+// directories below it, or of a package or a file. The root node lists the
+// packages and files with --packages and --files.
+type statsNode struct {
+	Path         string       `json:"path"`
+	Package      string       `json:"package,omitempty"`
+	Files        int          `json:"files"`
+	Lines        int          `json:"lines"`
+	CommentLines int          `json:"commentLines"`
+	CodeLines    int          `json:"codeLines"`
+	Exported     int          `json:"exported"`
+	Documented   int          `json:"documented"`
+	Children     []*statsNode `json:"children,omitempty"`
+	Packages     []*statsNode `json:"packages,omitempty"`
+	Sources      []*statsNode `json:"sources,omitempty"`
+}
+
+// density returns the share of the lines of node that hold comments.
+func (node *statsNode) density() float64 {
+	if node.Lines == 0 {
+		return 0
+	}
+
+	return float64(node.CommentLines) / float64(node.Lines)
+}
+
+// ratio returns the number of comment lines of node per line of code.
+func (node *statsNode) ratio() float64 {
+	if node.CodeLines == 0 {
+		return 0
+	}
+
+	return float64(node.CommentLines) / float64(node.CodeLines)
+}
+
+// coverage returns the documented exported identifiers of node out of all
+// of them, as "documented/exported", or "-" if it has none.
+func (node *statsNode) coverage() string {
+	if node.Exported == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%d/%d", node.Documented, node.Exported)
+}
+
+// merge adds the counts of other, a file or a group of files, to node.
+func (node *statsNode) merge(other *statsNode) {
+	node.Files += other.Files
+	node.Lines += other.Lines
+	node.CommentLines += other.CommentLines
+	node.CodeLines += other.CodeLines
+	node.Exported += other.Exported
+	node.Documented += other.Documented
+}
+
+// child returns the child of node for the directory at dirPath, adding it
+// if needed.
+func (node *statsNode) child(dirPath string) *statsNode {
+	for _, child := range node.Children {
+		if child.Path == dirPath {
+			return child
+		}
+	}
+
+	child := &statsNode{Path: dirPath}
+	node.Children = append(node.Children, child)
+
+	return child
+}
+
+// sort orders the children of node, recursively, and its packages and
+// files by comment lines in descending order and then by path.
+func (node *statsNode) sort() {
+	for _, nodes := range [][]*statsNode{node.Children, node.Packages, node.Sources} {
+		slices.SortFunc(nodes, func(a, b *statsNode) int {
+			if a.CommentLines != b.CommentLines {
+				return b.CommentLines - a.CommentLines
+			}
+
+			return cmp.Or(strings.Compare(a.Path, b.Path), strings.Compare(a.Package, b.Package))
+		})
+	}
+
+	for _, child := range node.Children {
+		child.sort()
+	}
+}
+
+// runStats implements the stats command. It counts the lines, comment
+// lines, code lines, and documented exported identifiers of each selected
+// file and prints the totals, or with --heatmap, a tree of directories,
+// followed by the packages with --packages and the files with --files.
+//
+// Errors are returned in the following cases:
+//   - The report format is not recognized
+//   - An input directory cannot be walked or holds no Go files
+//   - Reading a file fails
+func runStats(command *cobra.Command, args []string) error {
+	if err := validateReportFormat(); err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+
+	inputPaths, err := expandInputs(args)
+	if err != nil {
+		return err
+	}
+
+	root := &statsNode{Path: "."}
+	packages := map[[2]string]*statsNode{}
+
+	for _, inputPath := range inputPaths {
+		src, err := os.ReadFile(inputPath)
+		if err != nil {
+			return fmt.Errorf("file read failed: %w", err)
+		}
+
+		file := measureFile(slashPath(inputPath), src)
+		dirPath := path.Dir(file.Path)
+		root.add(dirPath, file)
+
+		if cfg.statsPackages {
+			pkg := packages[[2]string{dirPath, file.Package}]
+			if pkg == nil {
+				pkg = &statsNode{Path: dirPath, Package: file.Package}
+				packages[[2]string{dirPath, file.Package}] = pkg
+				root.Packages = append(root.Packages, pkg)
+			}
+
+			pkg.merge(file)
+		}
+
+		if cfg.statsFiles {
+			root.Sources = append(root.Sources, file)
+		}
+	}
+
+	root.sort()
+
+	if !cfg.heatmap {
+		root.Children = nil
+	}
+
+	switch cfg.format {
+	case formatJSON:
+		return writeJSON(command.OutOrStdout(), root)
+	case formatCSV, formatMarkdown:
+		return writeTable(command.OutOrStdout(), statsColumns, statsRows(root, nil))
+	}
+
+	var out strings.Builder
+
+	writeStatsHeader(&out, tr("DIRECTORY"))
+	writeHeatmap(&out, root, 0)
+
+	if len(root.Packages) > 0 {
+		out.WriteByte('\n')
+		writeStatsHeader(&out, tr("PACKAGE"))
+
+		for _, pkg := range root.Packages {
+			writeStatsRow(&out, pkg.Path+" ("+pkg.Package+")", pkg)
+		}
+	}
+
+	if len(root.Sources) > 0 {
+		out.WriteByte('\n')
+		writeStatsHeader(&out, tr("FILE"))
+
+		for _, file := range root.Sources {
+			writeStatsRow(&out, file.Path, file)
+		}
+	}
+
+	_, err = fmt.Fprint(command.OutOrStdout(), out.String())
+
+	return err //nolint:wrapcheck // Writing to standard output.
+}
+
+// add records file in the directory at dirPath, a slash-separated path
+// relative to node, in node and every directory leading to it.
+func (node *statsNode) add(dirPath string, file *statsNode) {
+	current := node
+	current.merge(file)
+
+	if dirPath == "." {
+		return
+	}
+
+	prefix := ""
+
+	for segment := range strings.SplitSeq(dirPath, "/") {
+		prefix = path.Join(prefix, segment)
+		if segment == "" {
+			prefix = "/"
+		}
+
+		current = current.child(prefix)
+		current.merge(file)
+	}
+}
+
+// statsRows returns rows with the directory of node and those below it,
+// and with the packages and files of node if it is the root, appended, as
+// cells of statsColumns.
+func statsRows(node *statsNode, rows [][]string) [][]string {
+	rows = append(rows, statsRow("directory", node))
+	for _, child := range node.Children {
+		rows = statsRows(child, rows)
+	}
+
+	for _, pkg := range node.Packages {
+		rows = append(rows, statsRow("package", pkg))
+	}
+
+	for _, file := range node.Sources {
+		rows = append(rows, statsRow("file", file))
+	}
+
+	return rows
+}
+
+// statsRow returns the statistics of node, of the given kind, as cells of
+// statsColumns.
+func statsRow(kind string, node *statsNode) []string {
+	return []string{
+		kind, node.Path, node.Package, strconv.Itoa(node.Files), strconv.Itoa(node.CommentLines),
+		strconv.Itoa(node.CodeLines), strconv.Itoa(node.Lines), strconv.FormatFloat(node.ratio(), 'f', 2, 64),
+		strconv.FormatFloat(node.density(), 'f', 3, 64), strconv.Itoa(node.Documented), strconv.Itoa(node.Exported),
+	}
+}
+
+// writeStatsHeader writes the header of a table of statistics to out, with
+// name heading the first column.
+func writeStatsHeader(out *strings.Builder, name string) {
+	_, _ = fmt.Fprintf(out, "%-40s %8s %8s %8s %8s %6s %9s\n",
+		name, tr("FILES"), tr("COMMENTS"), tr("CODE"), tr("LINES"), tr("RATIO"), tr("DOCS"))
+}
+
+// writeStatsRow writes the statistics of node, called name, to out as a
+// row of a table, with a bar showing its comment density.
+func writeStatsRow(out *strings.Builder, name string, node *statsNode) {
+	filled := int(node.density()*heatmapBarWidth + 0.5)
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", heatmapBarWidth-filled)
+
+	_, _ = fmt.Fprintf(out, "%-40s %8d %8d %8d %8d %6.2f %9s %s %5.1f%%\n", name, node.Files, node.CommentLines,
+		node.CodeLines, node.Lines, node.ratio(), node.coverage(), bar, node.density()*100) //nolint:mnd // Percent.
+}
+
+// writeHeatmap writes node and its children to out as an indented tree,
+// with a bar showing the comment density of each directory.
+func writeHeatmap(out *strings.Builder, node *statsNode, depth int) {
+	name := strings.Repeat("  ", depth) + path.Base(node.Path)
+	if depth == 0 {
+		name = node.Path
+	}
+
+	writeStatsRow(out, name, node)
+
+	for _, child := range node.Children {
+		writeHeatmap(out, child, depth+1)
+	}
+}
+
+// printStats implements --stats, printing stats, the result of removing
+// comments from the input called inputName, to standard error.
+func printStats(inputName string, stats commentremover.Stats) {
+	notef("%s: removed %d comments (%d line, %d block, %d doc), %d lines, %d bytes, ~%d of %d tokens; "+
+		"kept %d directives", inputName, stats.Removed(), stats.LineComments, stats.BlockComments,
+		stats.DocComments, stats.LinesRemoved, stats.BytesSaved, stats.TokensSaved(), stats.InputTokens,
+		stats.DirectivesKept)
+}
+
+// summaryColumns are the columns of --summary in the csv and markdown
+// formats.
+var summaryColumns = []string{
+	"path", "engine", "error", "removed", "line comments", "block comments", "doc comments",
+	"directives kept", "lines removed", "bytes saved", "input tokens", "output tokens",
+}
+
+// runSummary is the --summary of a run in the json format.
+type runSummary struct {
+	Files   int                  `json:"files"`
+	Failed  int                  `json:"failed"`
+	Total   commentremover.Stats `json:"total"`
+	Seconds float64              `json:"seconds"`
+	Results []fileReport         `json:"results"`
+}
+
+// printSummary prints the totals of the stats of files, the outcomes of a
+// run that took elapsed, to standard error, with the removed and kept
+// comments by category. In the json format the outcome of each file is
+// printed as well, and in the csv and markdown formats the stats of each
+// file followed by the totals and then a table of the categories.
+func printSummary(files []fileReport, elapsed time.Duration) {
+	summary := runSummary{Files: len(files), Seconds: elapsed.Seconds(), Results: files}
+
+	for _, file := range files {
+		if file.Error != "" {
+			summary.Failed++
+		}
+
+		if file.Stats == nil {
+			continue
+		}
+
+		summary.Total.LineComments += file.Stats.LineComments
+		summary.Total.BlockComments += file.Stats.BlockComments
+		summary.Total.DocComments += file.Stats.DocComments
+		summary.Total.DirectivesKept += file.Stats.DirectivesKept
+		summary.Total.LinesRemoved += file.Stats.LinesRemoved
+		summary.Total.BytesSaved += file.Stats.BytesSaved
+		summary.Total.InputBytes += file.Stats.InputBytes
+		summary.Total.InputTokens += file.Stats.InputTokens
+		summary.Total.OutputTokens += file.Stats.OutputTokens
+		addCategories(&summary.Total.RemovedByCategory, file.Stats.RemovedByCategory)
+		addCategories(&summary.Total.KeptByCategory, file.Stats.KeptByCategory)
+	}
+
+	total := summary.Total
+
+	if cfg.format == formatText {
+		notef("%d files, %d failed: removed %d comments (%d line, %d block, %d doc), %d lines, %d bytes, "+
+			"~%d of %d tokens; kept %d directives; took %s", len(files), summary.Failed, total.Removed(),
+			total.LineComments, total.BlockComments, total.DocComments, total.LinesRemoved, total.BytesSaved,
+			total.TokensSaved(), total.InputTokens, total.DirectivesKept, elapsed.Round(time.Millisecond))
+
+		removed, kept := total.RemovedByCategory, total.KeptByCategory
+		notef("comments removed/kept by category: license %d/%d, directive %d/%d, todo %d/%d, doc %d/%d, "+
+			"inline %d/%d, block %d/%d, line %d/%d", removed.License, kept.License, removed.Directive,
+			kept.Directive, removed.Todo, kept.Todo, removed.Doc, kept.Doc, removed.Inline, kept.Inline,
+			removed.Block, kept.Block, removed.Line, kept.Line)
+
+		return
+	}
+
+	if cfg.silent {
+		return
+	}
+
+	progress.clear()
+
+	if cfg.format == formatJSON {
+		_ = writeJSON(os.Stderr, summary)
+
+		return
+	}
+
+	rows := make([][]string, 0, len(files)+1)
+	for _, file := range files {
+		stats := commentremover.Stats{}
+		if file.Stats != nil {
+			stats = *file.Stats
+		}
+
+		rows = append(rows, summaryRow(file.Path, file.Engine, file.Error, stats))
+	}
+
+	rows = append(rows, summaryRow("total", "", "", total))
+	_ = writeTable(os.Stderr, summaryColumns, rows)
+	_, _ = fmt.Fprintln(os.Stderr)
+	_ = writeTable(os.Stderr, categoryColumns, categoryRows(total))
+}
+
+// categoryColumns are the columns of the histogram of comment categories
+// of --summary in the csv and markdown formats.
+var categoryColumns = []string{"category", "removed", "kept"}
+
+// categoryRows returns the comments that stats counts by category, in the
+// order of commentremover.Categories, as cells of categoryColumns.
+func categoryRows(stats commentremover.Stats) [][]string {
+	removed, kept := stats.RemovedByCategory, stats.KeptByCategory
+
+	return [][]string{
+		{"license", strconv.Itoa(removed.License), strconv.Itoa(kept.License)},
+		{"directive", strconv.Itoa(removed.Directive), strconv.Itoa(kept.Directive)},
+		{"todo", strconv.Itoa(removed.Todo), strconv.Itoa(kept.Todo)},
+		{"doc", strconv.Itoa(removed.Doc), strconv.Itoa(kept.Doc)},
+		{"inline", strconv.Itoa(removed.Inline), strconv.Itoa(kept.Inline)},
+		{"block", strconv.Itoa(removed.Block), strconv.Itoa(kept.Block)},
+		{"line", strconv.Itoa(removed.Line), strconv.Itoa(kept.Line)},
+	}
+}
+
+// addCategories adds the counts of other to total.
+func addCategories(total *commentremover.Categories, other commentremover.Categories) {
+	total.License += other.License
+	total.Directive += other.Directive
+	total.Todo += other.Todo
+	total.Doc += other.Doc
+	total.Inline += other.Inline
+	total.Block += other.Block
+	total.Line += other.Line
+}
+
+// summaryRow returns stats, the outcome of the input at inputPath, as cells
+// of summaryColumns.
+func summaryRow(inputPath, engine, errText string, stats commentremover.Stats) []string {
+	return []string{
+		inputPath, engine, errText, strconv.Itoa(stats.Removed()), strconv.Itoa(stats.LineComments),
+		strconv.Itoa(stats.BlockComments), strconv.Itoa(stats.DocComments), strconv.Itoa(stats.DirectivesKept),
+		strconv.Itoa(stats.LinesRemoved), strconv.Itoa(stats.BytesSaved), strconv.Itoa(stats.InputTokens),
+		strconv.Itoa(stats.OutputTokens),
+	}
+}
+
+// measureFile returns the statistics of src, the content of the file at
+// filePath. The doc comments of exported identifiers are counted only in
+// files that parse and are not tests, whose exported functions go
+// undocumented by convention.
+func measureFile(filePath string, src []byte) *statsNode {
+	node := &statsNode{Path: filePath, Files: 1}
+	node.Lines, node.CommentLines, node.CodeLines = countComments(src)
+
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments|parser.SkipObjectResolution)
+	if file != nil && file.Name != nil {
+		node.Package = file.Name.Name
+	}
+
+	if err == nil && !strings.HasSuffix(filePath, "_test.go") {
+		node.Exported, node.Documented = docCoverage(file)
+	}
+
+	return node
+}
+
+// docCoverage returns the number of exported top-level identifiers of file,
+// methods of exported types among them, and the number of those with a doc
+// comment. A name in a group of declarations counts as documented by the
+// doc comment of the group.
+func docCoverage(file *ast.File) (int, int) {
+	exported, documented := 0, 0
+
+	count := func(name *ast.Ident, docs ...*ast.CommentGroup) {
+		if !name.IsExported() {
+			return
+		}
+
+		exported++
+
+		if slices.ContainsFunc(docs, func(doc *ast.CommentGroup) bool { return doc != nil }) {
+			documented++
+		}
+	}
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if decl.Recv == nil || receiverName(decl.Recv).IsExported() {
+				count(decl.Name, decl.Doc)
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					count(spec.Name, spec.Doc, decl.Doc)
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						count(name, spec.Doc, decl.Doc)
+					}
+				}
+			}
+		}
+	}
+
+	return exported, documented
+}
+
+// receiverName returns the name of the type of recv, the receiver of a
+// method, without a pointer or type parameters.
+func receiverName(recv *ast.FieldList) *ast.Ident {
+	if len(recv.List) == 0 {
+		return ast.NewIdent("")
+	}
+
+	expr := recv.List[0].Type
+
+	for {
+		switch typeExpr := expr.(type) {
+		case *ast.StarExpr:
+			expr = typeExpr.X
+		case *ast.IndexExpr:
+			expr = typeExpr.X
+		case *ast.IndexListExpr:
+			expr = typeExpr.X
+		case *ast.ParenExpr:
+			expr = typeExpr.X
+		case *ast.Ident:
+			return typeExpr
+		default:
+			return ast.NewIdent("")
+		}
+	}
+}
+
+// countComments returns the number of lines in src, the number of lines
+// that hold at least part of a comment, and the number of lines that hold
+// code. A line may hold both. Source that does not tokenize cleanly is
+// counted as far as the scanner gets.
+func countComments(src []byte) (int, int, int) {
+	var sourceScanner scanner.Scanner
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	sourceScanner.Init(file, src, func(token.Position, string) {}, scanner.ScanComments)
+
+	commentLines, codeLines := map[int]bool{}, map[int]bool{}
+
+	for {
+		pos, tok, lit := sourceScanner.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		lineSet := codeLines
+
+		switch {
+		case tok == token.COMMENT:
+			lineSet = commentLines
+		case tok == token.SEMICOLON && lit == "\n":
+			continue
+		case lit == "":
+			lit = tok.String()
+		}
+
+		line := file.PositionFor(pos, false).Line
+		for offset := range strings.Count(lit, "\n") + 1 {
+			lineSet[line+offset] = true
+		}
+	}
+
+	lines := strings.Count(string(src), "\n")
+	if len(src) > 0 && src[len(src)-1] != '\n' {
+		lines++
+	}
+
+	return lines, len(commentLines), len(codeLines)
+}
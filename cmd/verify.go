@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/build/constraint"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pierow2k/nogocomments/pkg/commentremover"
+)
+
+var (
+	// errVerifyConflict is returned when --verify is combined with a way of
+	// selecting comments that depends on line numbers or on the user, which
+	// a pass over the output cannot repeat.
+	errVerifyConflict = errors.New("--verify cannot be combined with --lines, --older-than, --author, " +
+		"--interactive, or --review")
+
+	// errVerifyFailed is returned when --verify finds that removing comments
+	// is not deterministic or not idempotent for an input, or --verify-ast
+	// that it changes more than comments.
+	errVerifyFailed = errors.New("verification failed")
+
+	// errCompileNeedsFile is returned when --verify-compile is used with
+	// input that does not come from a file.
+	errCompileNeedsFile = errors.New("--verify-compile requires input files")
+
+	// errCompileFailed is returned when --verify-compile finds that the
+	// output of an input file breaks the build of its package.
+	errCompileFailed = errors.New("compile verification failed")
+)
+
+// verifyResult checks that output, the result of removing comments from
+// source, the input called inputName, with opts, is what a second pass over
+// source gives, and that removing comments from output leaves it unchanged.
+func verifyResult(inputName, source, output string, opts []commentremover.Option) error {
+	again, err := commentremover.Process(source, opts...)
+	if err != nil {
+		return fmt.Errorf("%w: %s: second pass over the input: %w", errVerifyFailed, inputName, err)
+	}
+
+	if again.Source != output {
+		return fmt.Errorf("%w: %s: second pass over the input differs at line %d",
+			errVerifyFailed, inputName, firstDifference(output, again.Source))
+	}
+
+	// Input of comments alone leaves nothing to remove comments from.
+	twice, err := commentremover.Process(output, opts...)
+	if errors.Is(err, commentremover.ErrEmptyInput) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: %s: pass over the output: %w", errVerifyFailed, inputName, err)
+	}
+
+	if twice.Source != output {
+		return fmt.Errorf("%w: %s: pass over the output changes line %d",
+			errVerifyFailed, inputName, firstDifference(output, twice.Source))
+	}
+
+	return nil
+}
+
+// firstDifference returns the first line, counting from 1, at which a and
+// b differ.
+func firstDifference(a, b string) int {
+	aLines, bLines := strings.Split(a, "\n"), strings.Split(b, "\n")
+
+	line := 0
+	for line < len(aLines) && line < len(bLines) && aLines[line] == bLines[line] {
+		line++
+	}
+
+	return line + 1
+}
+
+// verifyEquivalence checks that output, the result of removing comments
+// from source, the input called inputName, differs from source in nothing
+// but comments and layout, as commentremover.CheckEquivalence tells.
+func verifyEquivalence(inputName, source, output string) error {
+	if err := commentremover.CheckEquivalence(source, output); err != nil {
+		return fmt.Errorf("%w: %s: %w", errVerifyFailed, inputName, err)
+	}
+
+	return nil
+}
+
+// verifyCompiles checks that output, the result of removing comments from
+// source, the file at path called inputName, keeps the build constraints
+// of source, and that the package of the file still builds with output in
+// its place, test files included if the file is one. The package is built
+// with the go command and an overlay, leaving the file alone. If it does
+// not build, the package is built once more as it is, to tell a build
+// that stripping broke from one that was broken before.
+func verifyCompiles(path, inputName, source, output string) error {
+	if before, after := buildConstraints(source), buildConstraints(output); before != after {
+		return fmt.Errorf("%w: %s: build constraints changed from %q to %q", errCompileFailed, inputName, before, after)
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", errCompileFailed, inputName, err)
+	}
+
+	overlay, cleanup, err := writeOverlay(path, output)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", errCompileFailed, inputName, err)
+	}
+	defer cleanup()
+
+	out, err := goBuild(path, overlay)
+	if err == nil {
+		return nil
+	}
+
+	if _, beforeErr := goBuild(path, ""); beforeErr != nil {
+		return fmt.Errorf("%w: %s: the input does not build either: %s", errCompileFailed, inputName, out)
+	}
+
+	return fmt.Errorf("%w: %s: the output does not build: %s", errCompileFailed, inputName, out)
+}
+
+// buildConstraints returns the //go:build and // +build lines ahead of the
+// package clause of src, each in the normal form of go/build/constraint,
+// so that reformatting them does not count as a change.
+func buildConstraints(src string) string {
+	var lines []string
+
+	for line := range strings.Lines(src) {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+
+		if !constraint.IsGoBuild(line) && !constraint.IsPlusBuild(line) {
+			continue
+		}
+
+		if expr, err := constraint.Parse(line); err == nil {
+			line = expr.String()
+		}
+
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// writeOverlay writes output and a go command overlay that puts it in
+// place of the file at path to a new temporary directory. It returns the
+// path of the overlay and a function that removes the directory.
+func writeOverlay(path, output string) (string, func(), error) {
+	dir, err := os.MkdirTemp("", "nogocomments-verify-")
+	if err != nil {
+		return "", nil, err //nolint:wrapcheck // Wrapped by verifyCompiles.
+	}
+
+	cleanup := func() { _ = os.RemoveAll(dir) }
+	replacement := filepath.Join(dir, filepath.Base(path))
+
+	overlay, err := json.Marshal(map[string]map[string]string{"Replace": {path: replacement}})
+	if err == nil {
+		err = os.WriteFile(replacement, []byte(output), outputFileMode)
+	}
+
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "overlay.json"), overlay, outputFileMode)
+	}
+
+	if err != nil {
+		cleanup()
+
+		return "", nil, err //nolint:wrapcheck // Wrapped by verifyCompiles.
+	}
+
+	return filepath.Join(dir, "overlay.json"), cleanup, nil
+}
+
+// goBuild builds the package of the Go file at path, an absolute path, and
+// discards the result, returning the errors of the go command if it fails.
+// A test file is built into its test binary. With overlay, the build uses
+// that overlay file. The build tags are those of --tags. A file outside any
+// module is built on its own.
+func goBuild(path, overlay string) (string, error) {
+	args := []string{"build"}
+	if strings.HasSuffix(path, "_test.go") {
+		args = []string{"test", "-c"}
+	}
+
+	args = append(args, "-o", os.DevNull)
+	if cfg.tags != nil {
+		args = append(args, "-tags", strings.Join(cfg.tags, ","))
+	}
+
+	if overlay != "" {
+		args = append(args, "-overlay", overlay)
+	}
+
+	target := path
+	if inModule(filepath.Dir(path)) {
+		target = "."
+	}
+
+	goCmd := exec.Command("go", append(args, target)...)
+	goCmd.Dir = filepath.Dir(path)
+
+	release := acquireFile()
+	out, err := goCmd.CombinedOutput()
+
+	release()
+
+	if err != nil {
+		// The go command heads the errors of a package with its path,
+		// which the error names the input by already.
+		if header, rest, found := bytes.Cut(out, []byte("\n")); found && bytes.HasPrefix(header, []byte("# ")) {
+			out = rest
+		}
+
+		return strings.TrimSpace(string(out)), err //nolint:wrapcheck // The output says what failed.
+	}
+
+	return "", nil
+}
+
+// inModule reports whether dir lies in a Go module or workspace, that is,
+// whether it or one of its parents holds a go.mod or go.work file.
+func inModule(dir string) bool {
+	for {
+		for _, name := range []string{"go.mod", "go.work"} {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return true
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+
+		dir = parent
+	}
+}
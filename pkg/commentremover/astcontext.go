@@ -0,0 +1,346 @@
+package commentremover
+
+import (
+	"go/ast"
+	"go/token"
+	"slices"
+)
+
+// astContext holds what the AST engine knows about where comments sit in
+// a file, gathered once per file.
+type astContext struct {
+	fset    *token.FileSet             // fset holds the positions of file.
+	file    *ast.File                  // file is the file the comments belong to.
+	src     string                     // src is the source of file, or empty if unknown.
+	skip    int                        // skip is the number of lines added ahead of the input.
+	header  token.Pos                  // header is the position of the first token of code.
+	varDocs map[*ast.CommentGroup]bool // varDocs holds the doc comments of variable declarations.
+	cgo     *ast.CommentGroup          // cgo is the cgo preamble, if the file imports "C".
+	cgoFile bool                       // cgoFile is set if the file imports "C".
+
+	exportedDocs map[*ast.CommentGroup]bool // exportedDocs holds the doc comments of exported declarations.
+	packageDoc   *ast.CommentGroup          // packageDoc is the doc comment of the package clause.
+	license      *ast.CommentGroup          // license is the leading license header, if the file has one.
+	deprecated   map[*ast.Comment]bool      // deprecated holds the comments in deprecation notices.
+	bodies       []*ast.BlockStmt           // bodies holds the bodies of functions and function literals.
+	fieldDocs    map[*ast.CommentGroup]bool // fieldDocs holds the end-of-line comments of struct fields and constants.
+
+	owners map[*ast.CommentGroup]ast.Node // owners maps Doc and Comment groups to the nodes holding them.
+}
+
+// newASTContext gathers the comment context of file, parsed from src, whose
+// positions are recorded in fset. The shape tells how a snippet was wrapped
+// to parse.
+func newASTContext(fset *token.FileSet, file *ast.File, src string, shape snippetShape) astContext {
+	ctx := astContext{
+		fset:    fset,
+		file:    file,
+		src:     src,
+		skip:    shape.lines(),
+		header:  headerEnd(file, shape),
+		varDocs: make(map[*ast.CommentGroup]bool),
+
+		exportedDocs: make(map[*ast.CommentGroup]bool),
+		deprecated:   make(map[*ast.Comment]bool),
+		fieldDocs:    make(map[*ast.CommentGroup]bool),
+		owners:       make(map[*ast.CommentGroup]ast.Node),
+	}
+
+	if shape == shapeFile {
+		ctx.packageDoc = file.Doc
+	}
+
+	if len(file.Comments) > 0 && file.Comments[0].Pos() < ctx.header && isLicenseGroup(file.Comments[0]) {
+		ctx.license = file.Comments[0]
+	}
+
+	for _, group := range file.Comments {
+		ctx.addDeprecated(group)
+	}
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		ctx.addOwner(node)
+
+		switch typed := node.(type) {
+		case *ast.FuncDecl:
+			if typed.Body != nil {
+				ctx.bodies = append(ctx.bodies, typed.Body)
+			}
+		case *ast.FuncLit:
+			ctx.bodies = append(ctx.bodies, typed.Body)
+		case *ast.StructType:
+			for _, field := range typed.Fields.List {
+				ctx.addFieldComment(field.Comment)
+			}
+		default:
+		}
+
+		return true
+	})
+
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok {
+			ctx.addFuncDoc(decl)
+
+			continue
+		}
+
+		switch genDecl.Tok {
+		case token.VAR:
+			ctx.addVarDocs(genDecl)
+			ctx.addExportedDocs(genDecl)
+		case token.IMPORT:
+			ctx.findCgoPreamble(genDecl)
+		case token.CONST:
+			ctx.addExportedDocs(genDecl)
+
+			for _, spec := range genDecl.Specs {
+				if valueSpec, ok := spec.(*ast.ValueSpec); ok {
+					ctx.addFieldComment(valueSpec.Comment)
+				}
+			}
+		case token.TYPE:
+			ctx.addExportedDocs(genDecl)
+		default:
+		}
+	}
+
+	return ctx
+}
+
+// addVarDocs records the doc comments of the variable declaration decl.
+func (ctx *astContext) addVarDocs(decl *ast.GenDecl) {
+	if decl.Doc != nil {
+		ctx.varDocs[decl.Doc] = true
+	}
+
+	for _, spec := range decl.Specs {
+		if valueSpec, ok := spec.(*ast.ValueSpec); ok && valueSpec.Doc != nil {
+			ctx.varDocs[valueSpec.Doc] = true
+		}
+	}
+}
+
+// addFuncDoc records the doc comment of the function declaration decl if
+// it is exported. A method counts as exported only if its receiver type is
+// exported too, matching what go doc shows.
+func (ctx *astContext) addFuncDoc(decl ast.Decl) {
+	funcDecl, ok := decl.(*ast.FuncDecl)
+	if !ok || funcDecl.Doc == nil || !funcDecl.Name.IsExported() {
+		return
+	}
+
+	recv := funcDecl.Recv
+	if recv != nil && len(recv.List) > 0 && !token.IsExported(receiverType(recv.List[0].Type)) {
+		return
+	}
+
+	ctx.exportedDocs[funcDecl.Doc] = true
+}
+
+// receiverType returns the name of the type in the receiver type
+// expression expr, such as "T" for *T or T[K, V].
+func receiverType(expr ast.Expr) string {
+	for {
+		switch typed := expr.(type) {
+		case *ast.StarExpr:
+			expr = typed.X
+		case *ast.ParenExpr:
+			expr = typed.X
+		case *ast.IndexExpr:
+			expr = typed.X
+		case *ast.IndexListExpr:
+			expr = typed.X
+		case *ast.Ident:
+			return typed.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// addExportedDocs records the doc comments of the const, type, or var
+// declaration decl that document exported names: the doc comment of each
+// spec declaring one, and that of the declaration if any spec does.
+func (ctx *astContext) addExportedDocs(decl *ast.GenDecl) {
+	for _, spec := range decl.Specs {
+		var (
+			names []*ast.Ident
+			doc   *ast.CommentGroup
+		)
+
+		switch typed := spec.(type) {
+		case *ast.ValueSpec:
+			names, doc = typed.Names, typed.Doc
+		case *ast.TypeSpec:
+			names, doc = []*ast.Ident{typed.Name}, typed.Doc
+		default:
+		}
+
+		if !slices.ContainsFunc(names, (*ast.Ident).IsExported) {
+			continue
+		}
+
+		if doc != nil {
+			ctx.exportedDocs[doc] = true
+		}
+
+		if decl.Doc != nil {
+			ctx.exportedDocs[decl.Doc] = true
+		}
+	}
+}
+
+// isLicenseGroup reports whether group reads like a license header.
+func isLicenseGroup(group *ast.CommentGroup) bool {
+	texts := make([]string, len(group.List))
+	for i, c := range group.List {
+		texts[i] = c.Text
+	}
+
+	return isLicenseHeader(texts)
+}
+
+// addDeprecated records the comments of group that belong to a deprecation
+// notice.
+func (ctx *astContext) addDeprecated(group *ast.CommentGroup) {
+	texts := make([]string, len(group.List))
+	for i, c := range group.List {
+		texts[i] = c.Text
+	}
+
+	for i, notice := range deprecatedNotices(texts) {
+		if notice {
+			ctx.deprecated[group.List[i]] = true
+		}
+	}
+}
+
+// findCgoPreamble records the cgo preamble if the import declaration decl
+// imports "C". Like cgo itself, it takes the doc comment of the import
+// spec, or of the declaration when it holds a single spec.
+func (ctx *astContext) findCgoPreamble(decl *ast.GenDecl) {
+	for _, spec := range decl.Specs {
+		importSpec, ok := spec.(*ast.ImportSpec)
+		if !ok || importSpec.Path.Value != `"C"` {
+			continue
+		}
+
+		ctx.cgoFile = true
+
+		switch {
+		case importSpec.Doc != nil:
+			ctx.cgo = importSpec.Doc
+		case len(decl.Specs) == 1:
+			ctx.cgo = decl.Doc
+		default:
+		}
+	}
+}
+
+// describe returns the policy view of comment c, a member of group.
+func (ctx *astContext) describe(c *ast.Comment, group *ast.CommentGroup) comment {
+	return comment{
+		text:     c.Text,
+		line:     ctx.fset.PositionFor(c.Pos(), false).Line - ctx.skip,
+		inHeader: c.Pos() < ctx.header,
+		varDoc:   ctx.varDocs[group],
+
+		cgoPreamble: group == ctx.cgo,
+		cgoFile:     ctx.cgoFile,
+		exportedDoc: ctx.exportedDocs[group],
+		packageDoc:  group == ctx.packageDoc,
+		license:     group == ctx.license,
+		deprecated:  ctx.deprecated[c],
+		inFuncBody:  ctx.inFuncBody(c.Pos()),
+
+		fieldComment: ctx.fieldDocs[group],
+		inline:       followsCode(ctx.src, ctx.fset.File(c.Pos()).Offset(c.Pos())),
+
+		node:  c,
+		group: group,
+		owner: func() ast.Node { return ctx.owner(c, group) },
+	}
+}
+
+// addOwner records node as the owner of its Doc and Comment groups.
+func (ctx *astContext) addOwner(node ast.Node) {
+	var groups []*ast.CommentGroup
+
+	switch typed := node.(type) {
+	case *ast.File:
+		groups = []*ast.CommentGroup{typed.Doc}
+	case *ast.FuncDecl:
+		groups = []*ast.CommentGroup{typed.Doc}
+	case *ast.GenDecl:
+		groups = []*ast.CommentGroup{typed.Doc}
+	case *ast.Field:
+		groups = []*ast.CommentGroup{typed.Doc, typed.Comment}
+	case *ast.ImportSpec:
+		groups = []*ast.CommentGroup{typed.Doc, typed.Comment}
+	case *ast.ValueSpec:
+		groups = []*ast.CommentGroup{typed.Doc, typed.Comment}
+	case *ast.TypeSpec:
+		groups = []*ast.CommentGroup{typed.Doc, typed.Comment}
+	default:
+	}
+
+	for _, group := range groups {
+		if group != nil {
+			ctx.owners[group] = node
+		}
+	}
+}
+
+// owner returns the node that c, a comment of group, belongs to: the node
+// documented or annotated by group, or else the innermost node enclosing
+// c.
+func (ctx *astContext) owner(c *ast.Comment, group *ast.CommentGroup) ast.Node {
+	if node, ok := ctx.owners[group]; ok {
+		return node
+	}
+
+	var owner ast.Node = ctx.file
+
+	ast.Inspect(ctx.file, func(node ast.Node) bool {
+		if node == nil || c.Pos() < node.Pos() || c.Pos() >= node.End() {
+			return false
+		}
+
+		owner = node
+
+		return true
+	})
+
+	return owner
+}
+
+// addFieldComment records group, the end-of-line comment of a struct field
+// or top-level constant, if there is one.
+func (ctx *astContext) addFieldComment(group *ast.CommentGroup) {
+	if group != nil {
+		ctx.fieldDocs[group] = true
+	}
+}
+
+// inFuncBody reports whether pos lies inside the braces of a function body.
+func (ctx *astContext) inFuncBody(pos token.Pos) bool {
+	return slices.ContainsFunc(ctx.bodies, func(body *ast.BlockStmt) bool {
+		return body.Lbrace < pos && pos < body.Rbrace
+	})
+}
+
+// headerEnd returns the position of the first token of code in file. When
+// a dummy package clause was prefixed, that is the first declaration of the
+// original snippet rather than the injected package clause.
+func headerEnd(file *ast.File, shape snippetShape) token.Pos {
+	switch {
+	case shape == shapeFile:
+		return file.Package
+	case len(file.Decls) > 0:
+		return file.Decls[0].Pos()
+	default:
+		return file.FileEnd
+	}
+}
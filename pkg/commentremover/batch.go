@@ -0,0 +1,61 @@
+package commentremover
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// Batch removes comments from many sources at once, with a pool of worker
+// goroutines. The zero value processes with default options and one worker
+// per CPU.
+type Batch struct {
+	// Workers is the number of sources processed at the same time. Values
+	// below 1 select runtime.GOMAXPROCS(0).
+	Workers int
+
+	// Options apply to every source. They are shared by the workers, so
+	// filters given with RemoveIf must be safe for concurrent use.
+	Options []Option
+}
+
+// BatchResult is the outcome for one source of a Batch.
+type BatchResult struct {
+	Result       // Result is the outcome of the source, if Err is nil.
+	Err    error // Err is the error of the source, if it failed.
+}
+
+// Process removes comments from each of sources like ProcessContext and
+// returns the outcomes in the order of sources. A failing source does not
+// stop the others. Once ctx is done, the sources not yet started fail with
+// its error.
+func (batch Batch) Process(ctx context.Context, sources []string) []BatchResult {
+	results := make([]BatchResult, len(sources))
+
+	workers := batch.Workers
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	next := make(chan int)
+
+	var group sync.WaitGroup
+
+	for range min(workers, len(sources)) {
+		group.Go(func() {
+			for i := range next {
+				result, err := ProcessContext(ctx, sources[i], batch.Options...)
+				results[i] = BatchResult{Result: result, Err: err}
+			}
+		})
+	}
+
+	for i := range sources {
+		next <- i
+	}
+
+	close(next)
+	group.Wait()
+
+	return results
+}
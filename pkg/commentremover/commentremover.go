@@ -1,107 +1,507 @@
 // Package commentremover removes comments from Go source code. It handles
 // both complete packages and individual code snippets using the go/parser,
-// go/ast, go/token, and go/printer packages.
+// go/ast, go/token, and go/printer packages. A scanner-based engine that
+// edits the original bytes is available for input that the AST pipeline
+// cannot handle; see Engine.
+//
+// The package is what the nogocomments command is built on, and its exported
+// API is covered by the module's semantic version. RemoveComments and
+// Process take Options, one for each setting the command offers.
+//
+// Failures can be told apart with errors.Is: ErrEmptyInput for a source
+// without any code, ErrParse for one that is not Go code, ErrPanic for an
+// internal error, and the errors of package os for files that cannot be
+// read or written.
 package commentremover
 
 import (
-	"bytes"
+	"context"
 	"fmt"
 	"go/ast"
+	"go/format"
 	"go/parser"
 	"go/printer"
-	"go/scanner"
 	"go/token"
 	"strings"
 )
 
-const dummyPackage = "package main\n"
+// parseSourceCode parses sourceCode into an AST using the provided file set.
+func parseSourceCode(fset *token.FileSet, sourceCode string) (*ast.File, error) {
+	file, err := parser.ParseFile(fset, "", sourceCode, parser.ParseComments)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // Callers wrap it with parseError.
+	}
 
-// ensurePackageDeclaration prepends a "package main" declaration to
-// sourceCode if it doesn't already start with one. This is necessary to
-// properly parse a snippet since go/parser requires a package declaration.
-// It returns the potentially modified source and a boolean indicating
-// whether the dummy package was added.
-func ensurePackageDeclaration(sourceCode string) (string, bool) {
-	var sourceScanner scanner.Scanner
+	return file, nil
+}
 
-	fset := token.NewFileSet()
-	file := fset.AddFile("", fset.Base(), len(sourceCode))
-	sourceScanner.Init(file, []byte(sourceCode), nil, scanner.ScanComments)
+// removeCommentsFromAST removes the comments that cfg does not keep from
+// file, parsed from src, in-place, recording them in result. src may be
+// empty if it is not known, so that no comment counts as inline in the
+// stats. Comment groups left empty are
+// dropped. The removed comments are returned as spans of the parsed
+// source, for splicing them out of it instead of printing file.
+//
+// When file was parsed from a snippet of declarations, kept comments from
+// the header of the snippet would be printed around the injected package
+// clause, so they are removed from file as well and returned as text for
+// the caller to emit ahead of the printed code.
+func removeCommentsFromAST(
+	fset *token.FileSet, file *ast.File, src string, cfg config, shape snippetShape, result *Result,
+) (string, []commentSpan) {
+	var (
+		header  strings.Builder
+		removed []commentSpan
+	)
 
-	// Scan through tokens to find the first non-comment token.
-	for {
-		_, tok, _ := sourceScanner.Scan()
-		if tok == token.EOF {
-			break
-		}
-		// Return immediately once we determine if package exists.
-		if tok != token.COMMENT && tok != token.PACKAGE {
-			return dummyPackage + sourceCode, true
+	ctx := newASTContext(fset, file, src, shape)
+	groups := make([]*ast.CommentGroup, 0, len(file.Comments))
+
+	for _, group := range file.Comments {
+		var kept []*ast.Comment
+
+		for _, c := range group.List {
+			described := ctx.describe(c, group)
+			if cfg.keepComment(described) {
+				retainComment(result, described)
+				kept = append(kept, c)
+			} else {
+				removeComment(result, described)
+				removed = append(removed, commentSpan{comment: described, start: fset.Position(c.Pos()).Offset})
+			}
 		}
-		// If we find a package token, no need to prepend dummy package.
-		if tok == token.PACKAGE {
-			return sourceCode, false
+
+		switch {
+		case len(kept) == 0:
+		case shape != shapeFile && group.Pos() < ctx.header:
+			for _, c := range kept {
+				header.WriteString(c.Text + "\n")
+			}
+
+			header.WriteString("\n")
+		default:
+			groups = append(groups, &ast.CommentGroup{List: kept})
 		}
 	}
 
-	// Default to not modifying source code if it's unclear what action to
-	// take. This might happen for files that only contain comments.
+	file.Comments = groups
 
-	return sourceCode, false
+	return header.String(), removed
 }
 
-// parseSourceCode parses sourceCode into an AST using the provided file set.
-func parseSourceCode(fset *token.FileSet, sourceCode string) (*ast.File, error) {
-	file, err := parser.ParseFile(fset, "", sourceCode, parser.ParseComments)
+// formatAST converts the AST back into a Go source code string, indented
+// as cfg selects. With the default indentation, it prints exactly as gofmt
+// does, including sorting imports and normalizing number literals.
+func formatAST(file *ast.File, fset *token.FileSet, cfg config) (string, error) {
+	var err error
+
+	buf := printBuffer()
+	defer releasePrintBuffer(buf)
+
+	if cfg.indent == IndentTabs && cfg.tabWidth == defaultTabWidth {
+		err = format.Node(buf, fset, file)
+	} else {
+		printerConfig := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: cfg.tabWidth}
+		if cfg.indent == IndentSpaces {
+			printerConfig.Mode = printer.UseSpaces
+		}
+
+		err = printerConfig.Fprint(buf, fset, file)
+	}
+
 	if err != nil {
-		return nil, fmt.Errorf("error parsing source code: %w", err)
+		return "", fmt.Errorf("error formatting source code: %w", err)
 	}
 
-	return file, nil
+	return buf.String(), nil
 }
 
-// removeCommentsFromAST removes all comment groups from file in-place.
-func removeCommentsFromAST(file *ast.File) {
-	file.Comments = []*ast.CommentGroup{}
+// removeDummyPackage removes the dummy package clause from printed, the
+// printed code of a snippet, along with the blank lines the printer emits
+// after a package clause. The clause is found by its tokens, which must
+// come first, rather than by its text, so the line breaks of the output do
+// not matter and the text of the snippet is never matched.
+func removeDummyPackage(printed string) string {
+	return probeTokens(printed, func(scan *tokenScanner) (string, bool) {
+		if _, tok, _ := scan.Scan(); tok != token.PACKAGE {
+			return printed, tok != token.EOF
+		}
+
+		pos, tok, lit := scan.Scan()
+		if tok != token.IDENT {
+			return printed, tok != token.EOF
+		}
+
+		return strings.TrimLeft(printed[scan.file.Offset(pos)+len(lit):], "\r\n"), true
+	})
 }
 
-// formatAST converts the AST back into a Go source code string.
-func formatAST(file *ast.File, fset *token.FileSet) (string, error) {
-	var buf bytes.Buffer
-	if err := printer.Fprint(&buf, fset, file); err != nil {
-		return "", fmt.Errorf("error formatting source code: %w", err)
+// spliceSource deletes the removed comments from sourceCode, the parsed
+// source, rather than printing the AST, so that everything else keeps its
+// bytes. The text that shape put around a snippet is dropped again. If
+// keepLines is set, every line survives as in spliceComments.
+func spliceSource(sourceCode string, removed []commentSpan, shape snippetShape, keepLines bool) string {
+	// The text around a snippet holds no comments, so it is cut off by
+	// length, the suffix before splicing so no removal can reach it.
+	src := []byte(strings.TrimSuffix(sourceCode, shape.suffix()))
+
+	for i := range removed {
+		removed[i].end = commentEnd(src, removed[i].start)
 	}
 
-	return buf.String(), nil
+	return string(spliceComments(src, removed, keepLines))[len(shape.prefix()):]
 }
 
-// removeDummyPackage removes the leading "package main\n" from sourceCode.
-func removeDummyPackage(sourceCode string) string {
-	return strings.TrimPrefix(sourceCode, dummyPackage)
+// Result describes the outcome of a call to Process.
+type Result struct {
+	Source   string   // Source is the source code with comments removed.
+	Engine   Engine   // Engine is the pipeline that produced Source.
+	Fallback string   // Fallback explains why EngineAuto chose EngineScanner, if it did.
+	Hazards  []Hazard // Hazards lists the removed comments that change how the code builds or runs.
+	Path     string   // Path is the file the source was read from, if it was read from one.
+	Stats    Stats    // Stats counts what was removed and kept.
+
+	Unparsed []Unparsed // Unparsed lists the regions that RecoverPartial left unchanged.
 }
 
-// RemoveComments removes all comments from the provided Go source code.
-// It handles both complete packages and standalone code snippets. If the
+// Stats counts what removing comments from a source did. Reformatting can
+// add lines or bytes, so LinesRemoved and BytesSaved may be negative.
+type Stats struct {
+	LineComments   int `json:"lineComments"`   // LineComments is the number of // comments removed.
+	BlockComments  int `json:"blockComments"`  // BlockComments is the number of /* */ comments removed.
+	DocComments    int `json:"docComments"`    // DocComments counts removed ones documenting the package or exports.
+	DirectivesKept int `json:"directivesKept"` // DirectivesKept counts kept directives, as listed at KeepDirectives.
+	LinesRemoved   int `json:"linesRemoved"`   // LinesRemoved is the number of lines of the input less the output's.
+	BytesSaved     int `json:"bytesSaved"`     // BytesSaved is the size of the input less that of the output.
+	InputBytes     int `json:"inputBytes"`     // InputBytes is the size of the input.
+	InputTokens    int `json:"inputTokens"`    // InputTokens is the EstimateTokens estimate of the input.
+	OutputTokens   int `json:"outputTokens"`   // OutputTokens is the EstimateTokens estimate of the output.
+
+	RemovedByCategory Categories `json:"removedByCategory"` // RemovedByCategory counts removed comments by category.
+	KeptByCategory    Categories `json:"keptByCategory"`    // KeptByCategory counts kept comments by category.
+}
+
+// Categories counts comments by what they are, to tell what kind of
+// commentary dominates a codebase. Each comment is counted once, in the
+// first of the categories that it falls in, in the order of the fields.
+type Categories struct {
+	License   int `json:"license"`   // License counts the comments of a leading copyright or license header.
+	Directive int `json:"directive"` // Directive counts directives, as listed at KeepDirectives.
+	Todo      int `json:"todo"`      // Todo counts comments beginning with a TODO, FIXME, or HACK marker.
+	Doc       int `json:"doc"`       // Doc counts the doc comments of the package and of exported declarations.
+	Inline    int `json:"inline"`    // Inline counts comments that follow code on the same line.
+	Block     int `json:"block"`     // Block counts the other /* */ comments.
+	Line      int `json:"line"`      // Line counts the other // comments.
+}
+
+// Total returns the number of comments counted.
+func (categories Categories) Total() int {
+	return categories.License + categories.Directive + categories.Todo + categories.Doc +
+		categories.Inline + categories.Block + categories.Line
+}
+
+// Removed returns the number of comments removed.
+func (stats Stats) Removed() int {
+	return stats.LineComments + stats.BlockComments
+}
+
+// PercentSaved returns BytesSaved as a percentage of the size of the input,
+// or 0 for an empty input.
+func (stats Stats) PercentSaved() float64 {
+	if stats.InputBytes == 0 {
+		return 0
+	}
+
+	return float64(stats.BytesSaved) * 100 / float64(stats.InputBytes) //nolint:mnd // Percent.
+}
+
+// TokensSaved returns the estimated number of tokens of the input less
+// those of the output, the room that removing comments makes in the context
+// window of a language model.
+func (stats Stats) TokensSaved() int {
+	return stats.InputTokens - stats.OutputTokens
+}
+
+// RemoveComments removes comments from the provided Go source code. It
+// handles both complete packages and standalone code snippets. If the
 // source lacks a package declaration, a temporary one is added for parsing
 // and removed from the output.
-func RemoveComments(sourceCode string) (string, error) {
-	fset := token.NewFileSet()
-	sourceCode, prefixed := ensurePackageDeclaration(sourceCode)
-
-	file, err := parseSourceCode(fset, sourceCode)
+//
+// Build constraints are retained unless KeepBuildConstraints(false) is
+// given, since removing them changes how the code builds.
+func RemoveComments(sourceCode string, opts ...Option) (string, error) {
+	result, err := Process(sourceCode, opts...)
 	if err != nil {
 		return "", err
 	}
 
-	removeCommentsFromAST(file)
+	return result.Source, nil
+}
+
+// Process removes comments from sourceCode like RemoveComments, and also
+// reports which engine was used and why. It returns ErrEmptyInput if
+// sourceCode holds nothing but blanks.
+func Process(sourceCode string, opts ...Option) (Result, error) {
+	return ProcessContext(context.Background(), sourceCode, opts...)
+}
+
+// ProcessContext is like Process, but returns the error of ctx instead if
+// ctx is done before the work starts. A single source is not interrupted
+// once it is being processed, since that takes little time.
+func ProcessContext(ctx context.Context, sourceCode string, opts ...Option) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, err //nolint:wrapcheck // Callers compare it to context.Canceled.
+	}
+
+	if strings.TrimSpace(strings.TrimPrefix(sourceCode, byteOrderMark)) == "" {
+		return Result{}, ErrEmptyInput
+	}
+
+	cfg := newConfig(opts)
+
+	if cfg.strictUTF8 {
+		if err := checkUTF8(sourceCode); err != nil {
+			return Result{}, err
+		}
+	}
+
+	// A byte order mark would end up in the middle of a snippet behind the
+	// dummy package clause, so it is set aside until the end.
+	sourceCode, hasBOM := strings.CutPrefix(sourceCode, byteOrderMark)
+
+	var (
+		result Result
+		err    error
+	)
+
+	switch cfg.engine {
+	case EngineAuto:
+		result, err = processAuto(sourceCode, cfg)
+	case EngineScanner:
+		result, err = processScanner(sourceCode, cfg)
+	case EngineAST:
+		if cfg.bestEffort {
+			result, err = processBestEffort(sourceCode, cfg)
+
+			break
+		}
+
+		fallthrough
+	default:
+		result, err = processAST(sourceCode, cfg)
+	}
 
-	result, err := formatAST(file, fset)
 	if err != nil {
-		return "", err
+		return Result{}, err
 	}
 
-	if prefixed {
-		result = removeDummyPackage(result)
+	if cfg.squeezeBlank && !cfg.preserveLines {
+		result.Source = squeezeBlankLines(result.Source)
+	}
+
+	if cfg.finalNewline && !cfg.preserveLines {
+		result.Source = matchFinalNewline(result.Source, sourceCode)
+	}
+
+	if cfg.lineEnding != LineEndingPreserve {
+		result.Source = convertLineEndings(result.Source, cfg.lineEnding == LineEndingCRLF)
+	}
+
+	if hasBOM && cfg.keepBOM {
+		result.Source = byteOrderMark + result.Source
+	}
+
+	if hasBOM {
+		sourceCode = byteOrderMark + sourceCode
+	}
+
+	result.Stats.LinesRemoved = countLines(sourceCode) - countLines(result.Source)
+	result.Stats.BytesSaved = len(sourceCode) - len(result.Source)
+	result.Stats.InputBytes = len(sourceCode)
+	result.Stats.InputTokens = EstimateTokens(sourceCode)
+	result.Stats.OutputTokens = EstimateTokens(result.Source)
+
+	return result, nil
+}
+
+// StripFile removes comments from file in place, as RemoveComments would
+// for its source, for tools that hold a parsed file already. file must be
+// parsed with parser.ParseComments into fset. Comment groups left empty
+// are dropped from file.Comments, and the Doc and Comment fields of the
+// nodes of file are pointed at what is left of their groups, or cleared.
+// StripFile returns the hazards among the removed comments. Options about
+// the printed output, such as MinimalDiff or WithIndent, and WithEngine
+// have no effect.
+func StripFile(fset *token.FileSet, file *ast.File, opts ...Option) []Hazard {
+	var result Result
+
+	previous := file.Comments
+	removeCommentsFromAST(fset, file, "", newConfig(opts), shapeFile, &result)
+	relinkCommentGroups(file, previous)
+
+	return result.Hazards
+}
+
+// RemovableComments returns the comments of file that StripFile would
+// remove with opts, in source order, leaving file as it is. file must be
+// parsed with parser.ParseComments into fset.
+func RemovableComments(fset *token.FileSet, file *ast.File, opts ...Option) []*ast.Comment {
+	var removable []*ast.Comment
+
+	cfg := newConfig(opts)
+	ctx := newASTContext(fset, file, "", shapeFile)
+
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			if !cfg.keepComment(ctx.describe(c, group)) {
+				removable = append(removable, c)
+			}
+		}
+	}
+
+	return removable
+}
+
+// relinkCommentGroups points the Doc and Comment fields of the nodes of
+// file, which refer to the groups in previous, at the groups of
+// file.Comments that their kept comments went to, or at nil.
+func relinkCommentGroups(file *ast.File, previous []*ast.CommentGroup) {
+	groups := make(map[*ast.Comment]*ast.CommentGroup)
+
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			groups[c] = group
+		}
+	}
+
+	kept := make(map[*ast.CommentGroup]*ast.CommentGroup, len(previous))
+
+	for _, group := range previous {
+		for _, c := range group.List {
+			if groups[c] != nil {
+				kept[group] = groups[c]
+
+				break
+			}
+		}
+	}
+
+	relink := func(group **ast.CommentGroup) {
+		if *group != nil {
+			*group = kept[*group]
+		}
+	}
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch typed := node.(type) {
+		case *ast.File:
+			relink(&typed.Doc)
+		case *ast.FuncDecl:
+			relink(&typed.Doc)
+		case *ast.GenDecl:
+			relink(&typed.Doc)
+		case *ast.Field:
+			relink(&typed.Doc)
+			relink(&typed.Comment)
+		case *ast.ImportSpec:
+			relink(&typed.Doc)
+			relink(&typed.Comment)
+		case *ast.ValueSpec:
+			relink(&typed.Doc)
+			relink(&typed.Comment)
+		case *ast.TypeSpec:
+			relink(&typed.Doc)
+			relink(&typed.Comment)
+		default:
+		}
+
+		return true
+	})
+}
+
+// processAST implements EngineAST, returning a panic of stripAST as a
+// *PanicError.
+func processAST(sourceCode string, cfg config) (Result, error) {
+	var (
+		result Result
+		err    error
+	)
+
+	func() {
+		defer recoverPanic(&err)
+
+		result, err = stripAST(sourceCode, cfg)
+	}()
+
+	return result, err
+}
+
+// stripAST runs the AST pipeline. With RecoverPartial, source that does
+// not parse is handed to processPartial.
+func stripAST(sourceCode string, cfg config) (Result, error) {
+	fset, file, wrapped, shape, err := parseSnippet(sourceCode)
+	if err != nil && cfg.recoverPartial {
+		return processPartial(sourceCode, cfg, err)
+	} else if err != nil {
+		return Result{}, err
+	}
+
+	result := Result{Engine: EngineAST}
+	header, removed := removeCommentsFromAST(fset, file, wrapped, cfg, shape, &result)
+
+	if cfg.minimalDiff || cfg.preserveLines {
+		result.Source = spliceSource(wrapped, removed, shape, cfg.preserveLines)
+
+		return result, nil
+	}
+
+	result.Source, err = formatAST(file, fset, cfg)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if cfg.style == StyleGofumpt {
+		result.Source, err = applyGofumpt(result.Source, cfg)
+		if err != nil {
+			return Result{}, err
+		}
+	}
+
+	if shape != shapeFile {
+		result.Source = removeDummyPackage(result.Source)
+	}
+
+	switch shape {
+	case shapeStmts:
+		result.Source = unwrapStatements(result.Source, cfg)
+	case shapeExpr:
+		result.Source = unwrapExpression(result.Source)
+	case shapeFile, shapeDecls:
+	}
+
+	// The printer indents a snippet as the wrapping has it, so restore the
+	// indentation it had where it was copied from.
+	if shape != shapeFile {
+		indent, unit := leadingIndent(sourceCode), ""
+		if cfg.indent == IndentTabs {
+			unit = indentUnit(sourceCode, indent)
+		}
+
+		result.Source = indentLines(result.Source, indent, unit)
+	}
+
+	// A kept header is separated from the code by a blank line, unless
+	// no code follows.
+	if result.Source == "" {
+		header = strings.TrimSuffix(header, "\n")
+	}
+
+	result.Source = header + result.Source
+
+	// go/printer ends every line in "\n", so restore the line breaks of
+	// CRLF input.
+	if cfg.lineEnding == LineEndingPreserve && mostlyCRLF(sourceCode) {
+		result.Source = convertLineEndings(result.Source, true)
 	}
 
 	return result, nil
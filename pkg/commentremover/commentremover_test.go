@@ -1,6 +1,22 @@
 package commentremover_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -53,6 +69,11 @@ func example() {
 			commentMarker: "commentWithUniqueNoPackageMarker", // This should not appear in the output
 			wantErr:       false,
 		},
+		{
+			name:  "snippet holding the dummy package clause in a raw string",
+			input: "var s = `package main\n` // trailing\n",
+			want:  "var s = `package main\n`\n",
+		},
 		{
 			name:    "invalid Go code",
 			input:   `package main func main() {`,
@@ -82,3 +103,2235 @@ func example() {
 		})
 	}
 }
+
+// TestProcessEngines verifies engine selection and the output of the
+// scanner engine.
+//
+//nolint:funlen
+func TestProcessEngines(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		input        string
+		engine       commentremover.Engine
+		bestEffort   bool
+		want         string
+		wantEngine   commentremover.Engine
+		wantFallback bool
+		wantErr      bool
+	}{
+		{
+			name:       "scanner keeps formatting",
+			input:      "package main\n\n// doc\nfunc main()  {\n\tx := 1 // trailing\n\t_ = x\n}\n",
+			engine:     commentremover.EngineScanner,
+			want:       "package main\n\nfunc main()  {\n\tx := 1\n\t_ = x\n}\n",
+			wantEngine: commentremover.EngineScanner,
+		},
+		{
+			name:       "scanner separates tokens joined by a block comment",
+			input:      "package main\n\nvar x = 1/**/+/* two */2\n",
+			engine:     commentremover.EngineScanner,
+			want:       "package main\n\nvar x = 1 + 2\n",
+			wantEngine: commentremover.EngineScanner,
+		},
+		{
+			name:       "scanner keeps the newline of a multi-line block comment",
+			input:      "package main\n\nvar x = 1 /* a\nb */ var y = 2\n",
+			engine:     commentremover.EngineScanner,
+			want:       "package main\n\nvar x = 1\n var y = 2\n",
+			wantEngine: commentremover.EngineScanner,
+		},
+		{
+			name:       "scanner preserves CRLF line endings",
+			input:      "package main\r\n\r\n// doc\r\nvar x = 1 // trailing\r\n",
+			engine:     commentremover.EngineScanner,
+			want:       "package main\r\n\r\nvar x = 1\r\n",
+			wantEngine: commentremover.EngineScanner,
+		},
+		{
+			name:    "scanner rejects unterminated comments",
+			input:   "package main\n\n/* unterminated",
+			engine:  commentremover.EngineScanner,
+			wantErr: true,
+		},
+		{
+			name:       "auto uses the AST engine for valid code",
+			input:      "package main\n\n// doc\nvar x  = 1\n",
+			engine:     commentremover.EngineAuto,
+			want:       "package main\n\nvar x = 1\n",
+			wantEngine: commentremover.EngineAST,
+		},
+		{
+			name:         "auto falls back to the scanner when parsing fails",
+			input:        "package main func main() { // comment\n",
+			engine:       commentremover.EngineAuto,
+			want:         "package main func main() {\n",
+			wantEngine:   commentremover.EngineScanner,
+			wantFallback: true,
+		},
+		{
+			name:         "best effort falls back from the AST engine when parsing fails",
+			input:        "package main func main() { // comment\n",
+			engine:       commentremover.EngineAST,
+			bestEffort:   true,
+			want:         "package main func main() {\n",
+			wantEngine:   commentremover.EngineScanner,
+			wantFallback: true,
+		},
+		{
+			name:       "best effort removes comments around an unterminated string",
+			input:      "x := \"open\ny := 1 // y\n",
+			engine:     commentremover.EngineScanner,
+			bestEffort: true,
+			want:       "x := \"open\ny := 1\n",
+			wantEngine: commentremover.EngineScanner,
+		},
+		{
+			name:       "best effort keeps unterminated comments",
+			input:      "package main\n\n// doc\n/* unterminated",
+			engine:     commentremover.EngineScanner,
+			bestEffort: true,
+			want:       "package main\n\n/* unterminated",
+			wantEngine: commentremover.EngineScanner,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := commentremover.Process(testCase.input,
+				commentremover.WithEngine(testCase.engine), commentremover.BestEffort(testCase.bestEffort))
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("Process() error = %v, wantErr %v", err, testCase.wantErr)
+			}
+
+			if testCase.wantErr {
+				return
+			}
+
+			if got.Source != testCase.want {
+				t.Errorf("Process() source = %q, want %q", got.Source, testCase.want)
+			}
+
+			if got.Engine != testCase.wantEngine {
+				t.Errorf("Process() engine = %v, want %v", got.Engine, testCase.wantEngine)
+			}
+
+			if (got.Fallback != "") != testCase.wantFallback {
+				t.Errorf("Process() fallback = %q, wantFallback %v", got.Fallback, testCase.wantFallback)
+			}
+		})
+	}
+}
+
+// TestRecoverPartial verifies that RecoverPartial removes the comments of
+// the declarations that parse and leaves those with syntax errors as they
+// are, listing them.
+func TestRecoverPartial(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		input        string
+		engine       commentremover.Engine
+		want         string
+		wantUnparsed []commentremover.Unparsed
+		wantErr      bool
+	}{
+		{
+			name:   "declaration with a syntax error",
+			engine: commentremover.EngineAST,
+			input: "package main\n\n// A is one.\nvar A = 1 // one\n\n// F is broken.\n" +
+				"func F() {\n\tx := ] // x\n}\n\n// G is fine.\nfunc G() {} // g\n",
+			want: "package main\n\nvar A = 1\n\n// F is broken.\nfunc F() {\n\tx := ] // x\n}\n\nfunc G() {}\n",
+			wantUnparsed: []commentremover.Unparsed{
+				{FirstLine: 6, LastLine: 9, Error: "expected operand, found ']'"},
+			},
+		},
+		{
+			name:   "missing closing brace",
+			engine: commentremover.EngineAST,
+			input:  "package main\n\nfunc F() {\n\tx := 1 // x\n\nfunc G() {} // g\n",
+			want:   "package main\n\nfunc F() {\n\tx := 1 // x\n\nfunc G() {}\n",
+			wantUnparsed: []commentremover.Unparsed{
+				{FirstLine: 3, LastLine: 4, Error: "expected '}', found 'EOF'"},
+			},
+		},
+		{
+			name:   "auto engine recovers before falling back",
+			input:  "package main\n\nvar a = 1 // a\n\nvar b = ( // b\n",
+			engine: commentremover.EngineAuto,
+			want:   "package main\n\nvar a = 1\n\nvar b = ( // b\n",
+			wantUnparsed: []commentremover.Unparsed{
+				{FirstLine: 5, LastLine: 5, Error: "expected operand, found 'EOF'"},
+			},
+		},
+		{
+			name:   "snippet of declarations",
+			engine: commentremover.EngineAST,
+			input:  "type T struct{} // t\n\nfunc (T) M() { return ) } // m\n",
+			want:   "type T struct{}\n\nfunc (T) M() { return ) } // m\n",
+			wantUnparsed: []commentremover.Unparsed{
+				{FirstLine: 3, LastLine: 3, Error: "expected operand, found ')'"},
+			},
+		},
+		{
+			name:    "error ahead of the declarations",
+			engine:  commentremover.EngineAST,
+			input:   "package main ( // open\n\nvar a = 1 // a\n",
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := commentremover.Process(testCase.input,
+				commentremover.WithEngine(testCase.engine), commentremover.RecoverPartial(true))
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("Process() error = %v, wantErr %v", err, testCase.wantErr)
+			}
+
+			if testCase.wantErr {
+				return
+			}
+
+			if got.Source != testCase.want {
+				t.Errorf("Process() source = %q, want %q", got.Source, testCase.want)
+			}
+
+			if got.Engine != commentremover.EngineAST || got.Fallback != "" {
+				t.Errorf("Process() engine = %v, fallback %q, want ast without fallback", got.Engine, got.Fallback)
+			}
+
+			if !slices.Equal(got.Unparsed, testCase.wantUnparsed) {
+				t.Errorf("Process() unparsed = %+v, want %+v", got.Unparsed, testCase.wantUnparsed)
+			}
+		})
+	}
+}
+
+// TestStatementSnippets verifies that snippets of bare statements and of a
+// single expression are parsed in a synthetic function or declaration and
+// taken out of it again, with their original indentation and with line
+// numbers counted from the start of the snippet.
+func TestStatementSnippets(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		opts  []commentremover.Option
+		want  string
+	}{
+		{
+			name:  "loop",
+			input: "for i := 0; i < 3; i++ {\n\tfmt.Println(i) // print\n}\n",
+			want:  "for i := 0; i < 3; i++ {\n\tfmt.Println(i)\n}\n",
+		},
+		{
+			name:  "raw string",
+			input: "// lead\nif x {\n\ts := `\n\tq`\n}\n",
+			want:  "if x {\n\ts := `\n\tq`\n}\n",
+		},
+		{
+			name:  "spaces",
+			input: "if x {\n\ty() // call\n}\n",
+			opts:  []commentremover.Option{commentremover.WithIndent(commentremover.IndentSpaces), commentremover.TabWidth(4)},
+			want:  "if x {\n    y()\n}\n",
+		},
+		{
+			name:  "line range",
+			input: "// one\nx := 1 // two\n// three\n",
+			opts:  []commentremover.Option{commentremover.LineRange(2, 2)},
+			want:  "// one\nx := 1\n// three\n",
+		},
+		{
+			name:  "minimal diff",
+			input: "x  := 1 // one\ny := 2\n// two",
+			opts:  []commentremover.Option{commentremover.MinimalDiff(true)},
+			want:  "x  := 1\ny := 2",
+		},
+		{
+			name:  "indented body",
+			input: "\tx := 1 // one\n\n\tif x > 0 {\n\t\ts := `\nraw`\n\t}\n",
+			want:  "\tx := 1\n\n\tif x > 0 {\n\t\ts := `\nraw`\n\t}\n",
+		},
+		{
+			name:  "space-indented method",
+			input: "    func (s S) M() {\n        if s.ok { // ok\n            return\n        }\n    }\n",
+			want:  "    func (s S) M() {\n        if s.ok {\n            return\n        }\n    }\n",
+		},
+		{
+			name:  "space-indented body",
+			input: "  x := 1 // one\n  if x > 0 {\n    y()\n  }\n",
+			want:  "  x := 1\n  if x > 0 {\n    y()\n  }\n",
+		},
+		{
+			name:  "indented expression",
+			input: "\t\tT{\n\t\t\tA: 1, // a\n\t\t}",
+			want:  "\t\tT{\n\t\t\tA: 1,\n\t\t}",
+		},
+		{
+			name:  "composite literal",
+			input: "map[string]int{\n\t\"a\": 1, // one\n\t// two\n\t\"b\": 2,\n}\n",
+			want:  "map[string]int{\n\t\"a\": 1,\n\n\t\"b\": 2,\n}\n",
+		},
+		{
+			name:  "expression",
+			input: "x /* sum */ + y",
+			want:  "x + y",
+		},
+		{
+			name:  "expression minimal diff",
+			input: "[]int{1, 2} // two",
+			opts:  []commentremover.Option{commentremover.MinimalDiff(true)},
+			want:  "[]int{1, 2}",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := append([]commentremover.Option{commentremover.WithEngine(commentremover.EngineAST)}, testCase.opts...)
+
+			got, err := commentremover.RemoveComments(testCase.input, opts...)
+			if err != nil {
+				t.Fatalf("RemoveComments() error = %v", err)
+			}
+
+			if got != testCase.want {
+				t.Errorf("RemoveComments() = %q, want %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+// TestSnippetShapes verifies that each kind of snippet is recognized
+// without being named, and that input of no kind fails to parse.
+func TestSnippetShapes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "file", input: "package p // p\n\nvar x = 1\n", want: "package p\n\nvar x = 1\n"},
+		{name: "declarations", input: "type T int // t\n\nfunc f() {}\n", want: "type T int\n\nfunc f() {}\n"},
+		{name: "expression", input: "f(x) // call\n", want: "f(x)\n"},
+		{name: "statements", input: "x := 1 // one\nx++\n", want: "x := 1\nx++\n"},
+		{name: "expression then declaration", input: "g() // call\nvar y = 2\n", want: "g()\nvar y = 2\n"},
+		{name: "file after comment", input: "// c\n\npackage p\n", want: "package p\n"},
+		{
+			name:  "file after long comment",
+			input: "/*\n" + strings.Repeat("c\n", 4096) + "*/\npackage p\n",
+			want:  "package p\n",
+		},
+		{
+			name:  "expression after long comment",
+			input: strings.Repeat("// c\n", 2048) + "f(x)\n",
+			want:  "f(x)\n",
+		},
+		{name: "none", input: "func { // broken\n", wantErr: true},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := commentremover.RemoveComments(testCase.input, commentremover.WithEngine(commentremover.EngineAST))
+			if (err != nil) != testCase.wantErr {
+				t.Fatalf("RemoveComments() error = %v, wantErr %v", err, testCase.wantErr)
+			}
+
+			if got != testCase.want {
+				t.Errorf("RemoveComments() = %q, want %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+// TestMinimalDiff verifies that MinimalDiff deletes the comments chosen on
+// the AST from the original bytes, leaving the rest of the input as-is.
+func TestMinimalDiff(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		opts  []commentremover.Option
+		want  string
+	}{
+		{
+			name:  "formatting is kept",
+			input: "package main\n\n// doc\nfunc main()  {\n\tx := 1 // trailing\n\t_ = x\n}\n",
+			want:  "package main\n\nfunc main()  {\n\tx := 1\n\t_ = x\n}\n",
+		},
+		{
+			name:  "CRLF line endings are kept",
+			input: "package main\r\n\r\n// doc\r\nvar x  = 1 // trailing\r\n",
+			want:  "package main\r\n\r\nvar x  = 1\r\n",
+		},
+		{
+			name:  "snippets are kept",
+			input: "// doc\nfunc f()  {}\n",
+			want:  "func f()  {}\n",
+		},
+		{
+			name:  "kept comments are untouched",
+			input: "package p\n\n// F is   exported.\nfunc F() {} // trailing\n\n// g is not.\nfunc g() {}\n",
+			opts:  []commentremover.Option{commentremover.KeepDoc(true)},
+			want:  "package p\n\n// F is   exported.\nfunc F() {}\n\nfunc g() {}\n",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := append([]commentremover.Option{commentremover.MinimalDiff(true)}, testCase.opts...)
+
+			got, err := commentremover.Process(testCase.input, opts...)
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+
+			if got.Source != testCase.want {
+				t.Errorf("Process() source = %q, want %q", got.Source, testCase.want)
+			}
+
+			if got.Engine != commentremover.EngineAST {
+				t.Errorf("Process() engine = %v, want %v", got.Engine, commentremover.EngineAST)
+			}
+		})
+	}
+}
+
+// TestSqueezeBlank verifies that SqueezeBlank collapses runs of blank lines
+// outside raw string literals.
+func TestSqueezeBlank(t *testing.T) {
+	t.Parallel()
+
+	input := "package p\n\n// a\n\n \t\n// b\n\nvar s = `x\n\n\n`\r\n\r\n// c\r\n\r\nvar t = 1\n"
+	want := "package p\n\nvar s = `x\n\n\n`\r\n\r\nvar t = 1\n"
+
+	got, err := commentremover.RemoveComments(input, commentremover.WithEngine(commentremover.EngineScanner),
+		commentremover.SqueezeBlank(true))
+	if err != nil {
+		t.Fatalf("RemoveComments() error = %v", err)
+	}
+
+	if got != want {
+		t.Errorf("RemoveComments() = %q, want %q", got, want)
+	}
+}
+
+// TestPreserveLines verifies that both engines keep every line at its line
+// number with PreserveLines.
+func TestPreserveLines(t *testing.T) {
+	t.Parallel()
+
+	input := "package p\n\n// doc\n/* long\r\n   doc */\nfunc f()  {\n\tx := 1 /* a\nb */ + 2 // c\n\t_ = x\n}\n"
+	want := "package p\n\n\n\r\n\nfunc f()  {\n\tx := 1\n + 2\n\t_ = x\n}\n"
+
+	for _, engine := range []commentremover.Engine{commentremover.EngineAST, commentremover.EngineScanner} {
+		t.Run(engine.String(), func(t *testing.T) {
+			t.Parallel()
+
+			got, err := commentremover.RemoveComments(input, commentremover.WithEngine(engine),
+				commentremover.PreserveLines(true), commentremover.SqueezeBlank(true))
+			if err != nil {
+				t.Fatalf("RemoveComments() error = %v", err)
+			}
+
+			if got != want {
+				t.Errorf("RemoveComments() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+// TestLineEndings verifies that the line breaks of the input are kept by
+// default, even where the AST engine reprints the code, and converted on
+// request.
+func TestLineEndings(t *testing.T) {
+	t.Parallel()
+
+	crlf := "package main\r\n\r\n// doc\r\nvar x  = 1 // trailing\r\nvar s = `a\r\nb`\r\n"
+	lf := "package main\n\n// doc\nvar x  = 1 // trailing\nvar s = `a\nb`\n"
+
+	tests := []struct {
+		name   string
+		input  string
+		engine commentremover.Engine
+		ending commentremover.LineEnding
+		want   string
+	}{
+		{
+			name:   "AST engine preserves CRLF",
+			input:  crlf,
+			engine: commentremover.EngineAST,
+			want:   "package main\r\n\r\nvar x = 1\r\nvar s = `a\r\nb`\r\n",
+		},
+		{
+			name:   "AST engine preserves LF",
+			input:  lf,
+			engine: commentremover.EngineAST,
+			want:   "package main\n\nvar x = 1\nvar s = `a\nb`\n",
+		},
+		{
+			name:   "AST engine preserves CRLF in a snippet",
+			input:  "// doc\r\nvar x = 1 // trailing\r\n",
+			engine: commentremover.EngineAST,
+			want:   "var x = 1\r\n",
+		},
+		{
+			name:   "scanner engine preserves CRLF around a multi-line block comment",
+			input:  "package p\r\n/* a\r\nb */ var x = 1\r\n",
+			engine: commentremover.EngineScanner,
+			want:   "package p\r\n\r\n var x = 1\r\n",
+		},
+		{
+			name:   "AST engine preserves CRLF around a multi-line block comment",
+			input:  "package p\r\n/* a\r\nb */ var x = 1\r\n",
+			engine: commentremover.EngineAST,
+			want:   "package p\r\n\r\nvar x = 1\r\n",
+		},
+		{
+			name:   "CRLF converted to LF",
+			input:  crlf,
+			engine: commentremover.EngineScanner,
+			ending: commentremover.LineEndingLF,
+			want:   "package main\n\nvar x  = 1\nvar s = `a\nb`\n",
+		},
+		{
+			name:   "LF converted to CRLF",
+			input:  lf,
+			engine: commentremover.EngineAST,
+			ending: commentremover.LineEndingCRLF,
+			want:   "package main\r\n\r\nvar x = 1\r\nvar s = `a\r\nb`\r\n",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := commentremover.RemoveComments(testCase.input, commentremover.WithEngine(testCase.engine),
+				commentremover.WithLineEnding(testCase.ending))
+			if err != nil {
+				t.Fatalf("RemoveComments() error = %v", err)
+			}
+
+			if got != testCase.want {
+				t.Errorf("RemoveComments() = %q, want %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+// TestIndent verifies that EngineAST indents printed code as WithIndent
+// and TabWidth select.
+func TestIndent(t *testing.T) {
+	t.Parallel()
+
+	input := "package main\n\ntype T struct {\n\tA int // a\n\tLong string\n}\n\n" +
+		"func f() {\n\tif true {\n\t\treturn\n\t}\n}\n"
+
+	tests := []struct {
+		name string
+		opts []commentremover.Option
+		want string
+	}{
+		{
+			name: "tabs by default",
+			want: "package main\n\ntype T struct {\n\tA    int\n\tLong string\n}\n\n" +
+				"func f() {\n\tif true {\n\t\treturn\n\t}\n}\n",
+		},
+		{
+			name: "four spaces",
+			opts: []commentremover.Option{commentremover.WithIndent(commentremover.IndentSpaces), commentremover.TabWidth(4)},
+			want: "package main\n\ntype T struct {\n    A    int\n    Long string\n}\n\n" +
+				"func f() {\n    if true {\n        return\n    }\n}\n",
+		},
+		{
+			name: "minimal diff keeps the input indentation",
+			opts: []commentremover.Option{
+				commentremover.WithIndent(commentremover.IndentSpaces), commentremover.MinimalDiff(true),
+			},
+			want: "package main\n\ntype T struct {\n\tA int\n\tLong string\n}\n\n" +
+				"func f() {\n\tif true {\n\t\treturn\n\t}\n}\n",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := commentremover.RemoveComments(input, testCase.opts...)
+			if err != nil {
+				t.Fatalf("RemoveComments() error = %v", err)
+			}
+
+			if got != testCase.want {
+				t.Errorf("RemoveComments() = %q, want %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+// TestStyle verifies that StyleGofumpt applies the gofumpt rules to the
+// printed code, and that its output is stable.
+func TestStyle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name: "blank lines left by removed comments",
+			input: "package main\n\nfunc f() error {\n\t// lead\n\n\terr := g()\n\n\t// check\n" +
+				"\tif err != nil {\n\n\t\treturn err\n\t}\n\tx := []int{\n\n\t\t1,\n\t}\n\t_ = x\n\n\t// tail\n\treturn nil\n}\n",
+			want: "package main\n\nfunc f() error {\n\terr := g()\n\tif err != nil {\n\t\treturn err\n\t}\n" +
+				"\tx := []int{\n\t\t1,\n\t}\n\t_ = x\n\n\treturn nil\n}\n",
+		},
+		{
+			name: "imports, var groups, and octal literals",
+			input: "package main\n\nimport (\n\t\"github.com/x/y\"\n\t\"os\"\n\n\t\"fmt\"\n)\n\n" +
+				"var (\n\tmode = 0755 // rw\n)\n\nvar _, _, _ = fmt.Println, os.Args, y.Z\n",
+			want: "package main\n\nimport (\n\t\"fmt\"\n\t\"os\"\n\n\t\"github.com/x/y\"\n)\n\n" +
+				"var mode = 0o755\n\nvar _, _, _ = fmt.Println, os.Args, y.Z\n",
+		},
+		{
+			name:  "snippet",
+			input: "func f() {\n\t// lead\n\n\tx()\n}\n",
+			want:  "func f() {\n\tx()\n}\n",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			opt := commentremover.WithStyle(commentremover.StyleGofumpt)
+
+			got, err := commentremover.RemoveComments(testCase.input, opt)
+			if err != nil {
+				t.Fatalf("RemoveComments() error = %v", err)
+			}
+
+			if got != testCase.want {
+				t.Errorf("RemoveComments() = %q, want %q", got, testCase.want)
+			}
+
+			if again, _ := commentremover.RemoveComments(got, opt); again != got {
+				t.Errorf("RemoveComments() is not stable: %q, then %q", got, again)
+			}
+		})
+	}
+}
+
+// TestGofmtClean verifies that the AST engine output is left unchanged by
+// gofmt, for the source files of this package and for input that gofmt
+// would reformat.
+func TestGofmtClean(t *testing.T) {
+	t.Parallel()
+
+	inputs := map[string]string{
+		"unformatted": "package main\nimport (\n\"os\" // os\n\"fmt\"\n)\nvar x = 0X1F // hex\n" +
+			"func f() {\n\n\t// lead\n\n\n\tfmt.Println(os.Args)\n\t/* tail */\n}\n",
+	}
+
+	paths, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatalf("Glob() error = %v", err)
+	}
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile() error = %v", err)
+		}
+
+		inputs[path] = string(data)
+	}
+
+	for name, input := range inputs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := commentremover.RemoveComments(input, commentremover.WithEngine(commentremover.EngineAST))
+			if err != nil {
+				t.Fatalf("RemoveComments() error = %v", err)
+			}
+
+			formatted, err := format.Source([]byte(got))
+			if err != nil {
+				t.Fatalf("format.Source() error = %v", err)
+			}
+
+			if string(formatted) != got {
+				t.Errorf("RemoveComments() = %q, gofmt gives %q", got, formatted)
+			}
+		})
+	}
+}
+
+// TestFinalNewline verifies that the output ends in a line break exactly if
+// the input does, unless MatchFinalNewline(false) is given.
+func TestFinalNewline(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		input  string
+		engine commentremover.Engine
+		opts   []commentremover.Option
+		want   string
+	}{
+		{
+			name:   "AST engine input without final newline",
+			input:  "package main\n\nvar x = 1 // c",
+			engine: commentremover.EngineAST,
+			want:   "package main\n\nvar x = 1",
+		},
+		{
+			name:   "AST engine CRLF input without final newline",
+			input:  "package main\r\n\r\nvar x = 1",
+			engine: commentremover.EngineAST,
+			want:   "package main\r\n\r\nvar x = 1",
+		},
+		{
+			name:   "scanner engine input ending in a comment",
+			input:  "package main\n\nvar x = 1\n// c",
+			engine: commentremover.EngineScanner,
+			want:   "package main\n\nvar x = 1",
+		},
+		{
+			name:   "scanner engine input ending in a comment and a newline",
+			input:  "package main\n\nvar x = 1 // c\n",
+			engine: commentremover.EngineScanner,
+			want:   "package main\n\nvar x = 1\n",
+		},
+		{
+			name:   "not matched",
+			input:  "var x = 1",
+			engine: commentremover.EngineAST,
+			opts:   []commentremover.Option{commentremover.MatchFinalNewline(false)},
+			want:   "var x = 1\n",
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := append([]commentremover.Option{commentremover.WithEngine(testCase.engine)}, testCase.opts...)
+
+			got, err := commentremover.RemoveComments(testCase.input, opts...)
+			if err != nil {
+				t.Fatalf("RemoveComments() error = %v", err)
+			}
+
+			if got != testCase.want {
+				t.Errorf("RemoveComments() = %q, want %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+// TestByteOrderMark verifies that both engines handle input beginning with a
+// byte order mark, restoring it unless KeepBOM(false) is given.
+func TestByteOrderMark(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		opts  []commentremover.Option
+		want  string
+	}{
+		{
+			name:  "file",
+			input: "\uFEFFpackage main\n\n// doc\nvar x = 1\n",
+			want:  "\uFEFFpackage main\n\nvar x = 1\n",
+		},
+		{
+			name:  "snippet",
+			input: "\uFEFF// doc\nvar x = 1\n",
+			want:  "\uFEFFvar x = 1\n",
+		},
+		{
+			name:  "stripped",
+			input: "\uFEFFpackage main\n\n// doc\nvar x = 1\n",
+			opts:  []commentremover.Option{commentremover.KeepBOM(false)},
+			want:  "package main\n\nvar x = 1\n",
+		},
+	}
+
+	for _, testCase := range tests {
+		for _, engine := range []commentremover.Engine{commentremover.EngineAST, commentremover.EngineScanner} {
+			t.Run(testCase.name+"/"+engine.String(), func(t *testing.T) {
+				t.Parallel()
+
+				opts := append([]commentremover.Option{commentremover.WithEngine(engine)}, testCase.opts...)
+
+				got, err := commentremover.RemoveComments(testCase.input, opts...)
+				if err != nil {
+					t.Fatalf("RemoveComments() error = %v", err)
+				}
+
+				if got != testCase.want {
+					t.Errorf("RemoveComments() = %q, want %q", got, testCase.want)
+				}
+			})
+		}
+	}
+}
+
+// TestMapPositions verifies that MapPositions maps the code in the output
+// of either engine back to the input.
+func TestMapPositions(t *testing.T) {
+	t.Parallel()
+
+	input := "package p\n\n// doc\nvar x  = []int{\n\t1, // one\n\t2 /* two */}\n"
+	tests := []struct {
+		engine commentremover.Engine
+		want   []commentremover.Segment
+	}{
+		{
+			engine: commentremover.EngineAST,
+			want: []commentremover.Segment{
+				{Line: 1, Column: 1, SourceLine: 1, SourceColumn: 1},
+				{Line: 3, Column: 1, SourceLine: 4, SourceColumn: 1},
+				{Line: 3, Column: 7, SourceLine: 4, SourceColumn: 8},
+				{Line: 4, Column: 2, SourceLine: 5, SourceColumn: 2},
+				{Line: 5, Column: 2, SourceLine: 6, SourceColumn: 2},
+				{Line: 5, Column: 3, SourceLine: 6, SourceColumn: 13},
+			},
+		},
+		{
+			engine: commentremover.EngineScanner,
+			want: []commentremover.Segment{
+				{Line: 1, Column: 1, SourceLine: 1, SourceColumn: 1},
+				{Line: 3, Column: 1, SourceLine: 4, SourceColumn: 1},
+				{Line: 4, Column: 2, SourceLine: 5, SourceColumn: 2},
+				{Line: 5, Column: 2, SourceLine: 6, SourceColumn: 2},
+				{Line: 5, Column: 4, SourceLine: 6, SourceColumn: 13},
+			},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.engine.String(), func(t *testing.T) {
+			t.Parallel()
+
+			output, err := commentremover.RemoveComments(input, commentremover.WithEngine(testCase.engine))
+			if err != nil {
+				t.Fatalf("RemoveComments() error = %v", err)
+			}
+
+			if got := commentremover.MapPositions(input, output); !slices.Equal(got, testCase.want) {
+				t.Errorf("MapPositions() = %v, want %v\noutput %q", got, testCase.want, output)
+			}
+		})
+	}
+}
+
+// TestRetainedComments verifies which comments each engine keeps under
+// the various keep options.
+//
+//nolint:funlen
+func TestRetainedComments(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		input   string
+		opts    []commentremover.Option
+		kept    []string // kept lists text that must survive in the output.
+		removed []string // removed lists text that must not appear in the output.
+	}{
+		{
+			name: "build constraints are kept by default",
+			input: `// Copyright notice
+//go:build linux && amd64
+// +build linux,amd64
+
+// Package p does things.
+package p
+
+//go:build ignored
+var x = 1
+`,
+			kept:    []string{"//go:build linux && amd64", "// +build linux,amd64"},
+			removed: []string{"Copyright", "Package p", "ignored"},
+		},
+		{
+			name:    "build constraints in snippets are kept by default",
+			input:   "//go:build linux\n\nfunc f() {}\n",
+			kept:    []string{"//go:build linux"},
+			removed: []string{"package"},
+		},
+		{
+			name:    "build constraints can be removed",
+			input:   "//go:build linux\n\npackage p\n",
+			opts:    []commentremover.Option{commentremover.KeepBuildConstraints(false)},
+			removed: []string{"go:build"},
+		},
+		{
+			name: "generate directives are kept by default",
+			input: `package p
+
+// Use stringer for Kind.
+//go:generate stringer -type=Kind
+type Kind int
+`,
+			kept:    []string{"//go:generate stringer -type=Kind"},
+			removed: []string{"Use stringer"},
+		},
+		{
+			name:    "generate directives can be removed",
+			input:   "package p\n\n//go:generate stringer -type=Kind\ntype Kind int\n",
+			opts:    []commentremover.Option{commentremover.KeepGenerate(false)},
+			removed: []string{"go:generate"},
+		},
+		{
+			name: "embed directives on variables are kept by default",
+			input: `package p
+
+import "embed"
+
+// static holds the assets.
+//go:embed static/*
+var static embed.FS
+
+var (
+	// index is the landing page.
+	//go:embed index.html
+	index string
+)
+
+//go:embed stray.txt
+func f() {}
+`,
+			kept:    []string{"//go:embed static/*", "//go:embed index.html"},
+			removed: []string{"static holds", "landing page", "stray.txt"},
+		},
+		{
+			name:    "embed directives can be removed",
+			input:   "package p\n\n//go:embed a.txt\nvar a string\n",
+			opts:    []commentremover.Option{commentremover.KeepEmbed(false)},
+			removed: []string{"go:embed"},
+		},
+		{
+			name: "cgo preamble is kept by default",
+			input: `package p
+
+// #include <stdio.h>
+// static void hello(void) { puts("hi"); }
+import "C"
+
+// Hello says hi.
+func Hello() { C.hello() }
+`,
+			kept:    []string{"// #include <stdio.h>", `// static void hello(void) { puts("hi"); }`},
+			removed: []string{"says hi"},
+		},
+		{
+			name: "cgo preamble in an import block is kept by default",
+			input: `package p
+
+import (
+	// Standard library.
+	"fmt"
+
+	/*
+	#include <stdlib.h>
+	*/
+	"C"
+)
+`,
+			kept:    []string{"#include <stdlib.h>"},
+			removed: []string{"Standard library"},
+		},
+		{
+			name:    "cgo preamble can be removed",
+			input:   "package p\n\n// #include <stdio.h>\nimport \"C\"\n",
+			opts:    []commentremover.Option{commentremover.KeepCgoPreamble(false)},
+			removed: []string{"#include"},
+		},
+		{
+			name: "export directives in cgo files are kept by default",
+			input: `package p
+
+import "C"
+
+// Add adds.
+//export Add
+func Add(a, b C.int) C.int { return a + b }
+`,
+			kept:    []string{"//export Add"},
+			removed: []string{"Add adds"},
+		},
+		{
+			name:    "export directives outside cgo files are removed",
+			input:   "package p\n\nimport \"fmt\"\n\n//export Add\nfunc Add() { fmt.Println() }\n",
+			removed: []string{"//export"},
+		},
+		{
+			name:    "export directives can be removed",
+			input:   "package p\n\nimport \"C\"\n\n//export Add\nfunc Add() {}\n",
+			opts:    []commentremover.Option{commentremover.KeepCgoExports(false)},
+			removed: []string{"//export"},
+		},
+		{
+			name: "compiler directives are kept by default",
+			input: `package p
+
+import _ "unsafe"
+
+// nanotime returns the time.
+//go:linkname nanotime runtime.nanotime
+func nanotime() int64
+
+//go:noinline
+//go:nosplit
+func f() {}
+
+// go:noinline is prose, not a directive.
+func g() {}
+`,
+			kept: []string{
+				"//go:linkname nanotime runtime.nanotime", "//go:noinline\n", "//go:nosplit",
+			},
+			removed: []string{"returns the time", "prose"},
+		},
+		{
+			name: "syscall directives are kept by default",
+			input: `package unix
+
+// Getpid returns the process id.
+//sysnb	Getpid() (pid int)
+
+//sys	Close(fd int) (err error)
+//sys Read(fd int, p []byte) (n int, err error)
+
+//system is prose, not a directive.
+func f() {}
+`,
+			kept: []string{
+				"//sysnb\tGetpid() (pid int)", "//sys\tClose(fd int) (err error)",
+				"//sys Read(fd int, p []byte) (n int, err error)",
+			},
+			removed: []string{"process id", "prose"},
+		},
+		{
+			name:    "syscall directives can be removed",
+			input:   "package unix\n\n//sys\tClose(fd int) (err error)\n",
+			opts:    []commentremover.Option{commentremover.KeepSyscallDirectives(false)},
+			removed: []string{"//sys"},
+		},
+		{
+			name: "license headers are kept by default",
+			input: `// Copyright 2024 The Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package p does things.
+package p // Copyright 2024 is not a header here.
+`,
+			kept:    []string{"// Copyright 2024 The Authors.", "// license that can be found"},
+			removed: []string{"Package p", "not a header"},
+		},
+		{
+			name:    "block license headers in snippets are kept by default",
+			input:   "/*\n * Licensed under the Apache License, Version 2.0.\n */\n\n// f does things.\nfunc f() {}\n",
+			kept:    []string{"Licensed under the Apache License"},
+			removed: []string{"f does things"},
+		},
+		{
+			name:    "only the first comment group can be a license header",
+			input:   "// Package p does things.\n\n// Copyright 2024 The Authors.\npackage p\n",
+			removed: []string{"Package p", "Copyright"},
+		},
+		{
+			name:    "license headers can be removed",
+			input:   "// Copyright 2024 The Authors.\n// Licensed under the MIT License.\n\npackage p\n",
+			opts:    []commentremover.Option{commentremover.KeepLicenseHeader(false)},
+			removed: []string{"Copyright", "Licensed"},
+		},
+		{
+			name: "SPDX identifiers are kept by default",
+			input: `// Copyright 2024 The Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package p
+
+/* SPDX-License-Identifier: MIT */
+var x = 1 // SPDX-License-Identifier: BSD-3-Clause
+`,
+			opts: []commentremover.Option{commentremover.KeepLicenseHeader(false)},
+			kept: []string{
+				"// SPDX-License-Identifier: Apache-2.0",
+				"/* SPDX-License-Identifier: MIT */",
+				"// SPDX-License-Identifier: BSD-3-Clause",
+			},
+			removed: []string{"Copyright"},
+		},
+		{
+			name:    "SPDX identifiers can be removed",
+			input:   "// SPDX-License-Identifier: MIT\n\npackage p\n",
+			opts:    []commentremover.Option{commentremover.KeepLicenseHeader(false), commentremover.KeepSPDX(false)},
+			removed: []string{"SPDX"},
+		},
+		{
+			name: "comments matching keep patterns are kept",
+			input: `package p
+
+// +kubebuilder:object:root=true
+type T struct{} // lint:ignore U1000 unused
+
+/* @generated-by tool */
+var x = 1 // plain
+`,
+			opts: []commentremover.Option{
+				commentremover.KeepPattern(regexp.MustCompile(`^// \+kubebuilder:`)),
+				commentremover.KeepPattern(regexp.MustCompile(`lint:ignore`), regexp.MustCompile(`@generated`)),
+			},
+			kept:    []string{"// +kubebuilder:object:root=true", "// lint:ignore U1000 unused", "/* @generated-by tool */"},
+			removed: []string{"plain"},
+		},
+		{
+			name: "remove patterns restrict removal",
+			input: `//go:build debug
+
+package p
+
+// DEBUG: trace everything
+var x = 1 // DEBUG: remove me
+
+// x is documented.
+var y = 2 /* keep me */
+`,
+			opts: []commentremover.Option{
+				commentremover.RemovePattern(regexp.MustCompile(`DEBUG:`), regexp.MustCompile(`^//go:build`)),
+			},
+			kept:    []string{"//go:build debug", "// x is documented.", "/* keep me */"},
+			removed: []string{"DEBUG"},
+		},
+		{
+			name: "task markers can be kept",
+			input: `package p
+
+// TODO: split this up.
+var x = 1 /* FIXME(ann) overflow */
+
+// HACKER is not a marker.
+var y = 2 // HACK around the parser.
+`,
+			opts:    []commentremover.Option{commentremover.KeepTodos(true)},
+			kept:    []string{"// TODO: split this up.", "/* FIXME(ann) overflow */", "// HACK around the parser."},
+			removed: []string{"HACKER"},
+		},
+		{
+			name: "only task markers are removed",
+			input: `package p
+
+// x is documented.
+var x = 1 // TODO: remove me
+
+var y = 2 // DEBUG: remove me too
+`,
+			opts: []commentremover.Option{
+				commentremover.OnlyTodos(true), commentremover.RemovePattern(regexp.MustCompile(`DEBUG:`)),
+			},
+			kept:    []string{"// x is documented."},
+			removed: []string{"TODO", "DEBUG"},
+		},
+		{
+			name: "deprecation notices can be kept",
+			input: `package p
+
+// T does things.
+//
+// Deprecated: Use U instead,
+// which does more.
+//
+// More details.
+type T struct {
+	// F is a field.
+	// Deprecated: Use G.
+	F int // Deprecated: trailing.
+
+	/*
+	 * H is a field.
+	 *
+	 * Deprecated: Use G.
+	 */
+	H int
+}
+
+// Deprecated is not a notice here.
+var x = 1
+`,
+			opts: []commentremover.Option{commentremover.KeepDeprecated(true)},
+			kept: []string{
+				"// Deprecated: Use U instead,", "// which does more.", "// Deprecated: trailing.",
+				"* Deprecated: Use G.",
+			},
+			removed: []string{"T does things", "More details", "F is a field", "// Deprecated: Use G.", "not a notice"},
+		},
+		{
+			name: "annotations can be kept",
+			input: `package p
+
+// GetUser returns a user.
+//
+//	@Summary	Get a user
+// @Router /users/{id} [get]
+/* @entgen:skip */
+func GetUser() {} // send mail to admin@example.com
+`,
+			opts:    []commentremover.Option{commentremover.KeepAnnotations(true)},
+			kept:    []string{"@Summary\tGet a user", "// @Router /users/{id} [get]", "/* @entgen:skip */"},
+			removed: []string{"GetUser returns", "admin@example.com"},
+		},
+		{
+			name:    "line comments can be kept",
+			input:   "package p\n\n// line\nvar x = 1 /* block */\n\n/*\n * multi\n */\nvar y = 2 // trailing\n",
+			opts:    []commentremover.Option{commentremover.KeepLineComments(true)},
+			kept:    []string{"// line", "// trailing"},
+			removed: []string{"block", "multi"},
+		},
+		{
+			name:    "block comments can be kept",
+			input:   "package p\n\n// line\nvar x = 1 /* block */\n\n/*\n * multi\n */\nvar y = 2 // trailing\n",
+			opts:    []commentremover.Option{commentremover.KeepBlockComments(true)},
+			kept:    []string{"/* block */", "* multi"},
+			removed: []string{"line", "trailing"},
+		},
+		{
+			name: "removal can be restricted to function bodies",
+			input: `package p
+
+// F does things.
+func F(g func() int) struct{ x int } { // opening
+	// inside
+	h := func() {
+		/* literal */
+	}
+	_ = h
+
+	return struct{ x int }{} // result
+} // after
+
+// T is a type.
+type T struct {
+	f func() // field
+}
+
+// M is a method.
+func (T) M() {
+	// method body
+}
+
+var v = func() int { return 1 /* initializer */ }
+`,
+			opts: []commentremover.Option{commentremover.OnlyFuncBodies(true)},
+			kept: []string{
+				"// F does things.", "// after", "// T is a type.", "// field", "// M is a method.",
+			},
+			removed: []string{"opening", "inside", "literal", "result", "method body", "initializer"},
+		},
+		{
+			name: "field comments can be kept",
+			input: `package p
+
+// T is a type.
+type T struct {
+	// Timeout is documented.
+	Timeout int // seconds
+	Nested  struct {
+		Name string ` + "`json:\"name\"`" + ` // omitted if empty
+	} // nested
+}
+
+const (
+	// A is documented.
+	A = iota // first
+	B        // second
+) // after
+
+const C = 1 // single
+
+var v = 1 // variable
+
+func f() {
+	x := 1 // statement
+	_ = x
+}
+`,
+			opts: []commentremover.Option{commentremover.KeepFieldComments(true)},
+			kept: []string{
+				"// seconds", "// omitted if empty", "// nested", "// first", "// second", "// single",
+			},
+			removed: []string{"T is a type", "documented", "after", "variable", "statement"},
+		},
+		{
+			name:    "line ranges restrict removal",
+			input:   "package p\n\n// one\nvar a = 1 // two\n\n/* three\n */\nvar b = 2 // four\n",
+			opts:    []commentremover.Option{commentremover.LineRange(4, 6)},
+			kept:    []string{"// one", "// four"},
+			removed: []string{"two", "three"},
+		},
+		{
+			name:  "line ranges accumulate",
+			input: "package p\n\n// one\nvar a = 1 // two\n\n// three\nvar b = 2 // four\n",
+			opts: []commentremover.Option{
+				commentremover.LineRange(3, 3), commentremover.LineRange(7, 9),
+			},
+			kept:    []string{"// two", "// three"},
+			removed: []string{"one", "four"},
+		},
+		{
+			name:  "line filters restrict removal",
+			input: "package p\n\n// one\nvar a = 1 // two\n\n/* three\n */\nvar b = 2 // four\n",
+			opts: []commentremover.Option{
+				commentremover.RemoveIf(func(first, last int) bool { return last < 8 }),
+				commentremover.RemoveIf(func(first, last int) bool { return first > 3 }),
+			},
+			kept:    []string{"// one", "// four"},
+			removed: []string{"two", "three"},
+		},
+		{
+			name: "nolint comments can be kept",
+			input: `package p
+
+// f does things.
+//
+//nolint:funlen
+func f() {
+	_ = g() //nolint:errcheck // Best effort.
+	_ = g() // nolint
+	_ = g() // nolintable is prose.
+}
+`,
+			opts:    []commentremover.Option{commentremover.KeepNolint(true)},
+			kept:    []string{"//nolint:funlen\nfunc f", "//nolint:errcheck // Best effort.\n", "// nolint\n"},
+			removed: []string{"does things", "prose"},
+		},
+		{
+			name: "doc comments of exported declarations can be kept",
+			input: `package p
+
+// Kind is exported.
+type Kind int
+
+// Kinds doc.
+const (
+	// A is exported.
+	A Kind = iota
+	// b is not.
+	b
+)
+
+// both doc.
+var x, Y = 1, 2
+
+// detached is separated by a blank line.
+
+// F is exported.
+func F() {
+	// inside the body.
+	_ = 1 // trailing.
+}
+
+// f is not exported.
+func f() {}
+
+// String is a method of an exported type.
+func (k *Kind) String() string { return "" }
+
+type hidden struct{}
+
+// Method is a method of an unexported type.
+func (hidden) Method() {}
+
+// Get is generic.
+func (l List[T]) Get() T { var zero T; return zero }
+
+// List is generic.
+type List[T any] []T
+`,
+			opts: []commentremover.Option{commentremover.KeepDoc(true)},
+			kept: []string{
+				"// Kind is exported.", "// Kinds doc.", "// A is exported.", "// both doc.",
+				"// F is exported.", "// String is a method", "// Get is generic.", "// List is generic.",
+			},
+			removed: []string{"b is not", "detached", "inside the body", "trailing", "f is not", "unexported type"},
+		},
+		{
+			name: "package doc comments can be kept",
+			input: `// Copyright notice.
+
+// Package p does things.
+//
+// It does them well.
+package p
+
+// F is exported.
+func F() {}
+`,
+			opts:    []commentremover.Option{commentremover.KeepPackageDoc(true)},
+			kept:    []string{"// Package p does things.\n//\n// It does them well.\npackage p"},
+			removed: []string{"Copyright", "F is exported"},
+		},
+		{
+			name:    "package doc comments are removed by default",
+			input:   "// Package p does things.\npackage p\n",
+			removed: []string{"Package p"},
+		},
+		{
+			name:    "doc comments are removed by default",
+			input:   "package p\n\n// F is exported.\nfunc F() {}\n",
+			removed: []string{"F is exported"},
+		},
+		{
+			name:    "nolint comments are removed by default",
+			input:   "package p\n\nvar x = 1 //nolint:gochecknoglobals\n",
+			removed: []string{"nolint"},
+		},
+		{
+			name:    "compiler directives can be removed",
+			input:   "package p\n\n//go:noinline\nfunc f() {}\n",
+			opts:    []commentremover.Option{commentremover.KeepCompilerDirectives(false)},
+			removed: []string{"//go:noinline"},
+		},
+	}
+
+	engines := []commentremover.Engine{commentremover.EngineAST, commentremover.EngineScanner}
+
+	for _, testCase := range tests {
+		for _, engine := range engines {
+			t.Run(testCase.name+"/"+engine.String(), func(t *testing.T) {
+				t.Parallel()
+
+				opts := append([]commentremover.Option{commentremover.WithEngine(engine)}, testCase.opts...)
+
+				got, err := commentremover.RemoveComments(testCase.input, opts...)
+				if err != nil {
+					t.Fatalf("RemoveComments() error = %v", err)
+				}
+
+				for _, text := range testCase.kept {
+					if !strings.Contains(got, text) {
+						t.Errorf("RemoveComments() lost %q, got = %q", text, got)
+					}
+				}
+
+				for _, text := range testCase.removed {
+					if strings.Contains(got, text) {
+						t.Errorf("RemoveComments() kept %q, got = %q", text, got)
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestRemoveIfOrder verifies that both engines consult RemoveIf filters in
+// source order, and only about the comments that no other option keeps.
+func TestRemoveIfOrder(t *testing.T) {
+	t.Parallel()
+
+	input := "package p\n\n//go:generate echo\n\n/* one\n */\nvar a = 1 // two\n\n// three\nvar b = 2\n"
+	want := [][2]int{{5, 6}, {7, 7}, {9, 9}}
+
+	for _, engine := range []commentremover.Engine{commentremover.EngineAST, commentremover.EngineScanner} {
+		t.Run(engine.String(), func(t *testing.T) {
+			t.Parallel()
+
+			var got [][2]int
+
+			_, err := commentremover.RemoveComments(input, commentremover.WithEngine(engine),
+				commentremover.RemoveIf(func(first, last int) bool {
+					got = append(got, [2]int{first, last})
+
+					return true
+				}))
+			if err != nil {
+				t.Fatalf("RemoveComments() error = %v", err)
+			}
+
+			if !slices.Equal(got, want) {
+				t.Errorf("RemoveIf() consulted for lines %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// TestStrictUTF8 verifies that StrictUTF8 rejects invalid input and
+// reports the positions of the invalid bytes.
+func TestStrictUTF8(t *testing.T) {
+	t.Parallel()
+
+	input := "package p\n\nvar s = \"ok \xff\"\nvar t = \"\xfe\"\n"
+
+	_, err := commentremover.RemoveComments(input, commentremover.StrictUTF8(true))
+	if !errors.Is(err, commentremover.ErrInvalidUTF8) {
+		t.Fatalf("RemoveComments() error = %v, want ErrInvalidUTF8", err)
+	}
+
+	if want := "3:13, 4:10"; !strings.Contains(err.Error(), want) {
+		t.Errorf("RemoveComments() error = %q, want positions %q", err, want)
+	}
+}
+
+// TestHazards verifies that both engines report removed comments that
+// change how the code builds or runs.
+//
+//nolint:funlen
+func TestHazards(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		opts  []commentremover.Option
+		want  []commentremover.Hazard
+	}{
+		{
+			name:  "kept directives are not hazards",
+			input: "//go:build linux\n\npackage p\n\n//go:embed a.txt\nvar a string\n\n//line gen.y:10\nfunc g() {}\n",
+		},
+		{
+			name:  "all directives can be stripped at once",
+			input: "//go:build linux\n\npackage p\n\n//line gen.y:10\nfunc g() {}\n",
+			opts:  []commentremover.Option{commentremover.KeepDirectives(false), commentremover.KeepBuildConstraints(true)},
+			want:  []commentremover.Hazard{{Line: 5, Kind: commentremover.HazardLineDirective, Text: "//line gen.y:10"}},
+		},
+		{
+			name: "stripped directives are hazards",
+			input: `//go:build linux
+
+package p
+
+// #include <stdio.h>
+import "C"
+
+//go:embed a.txt
+var a string
+
+//export F
+func F() {}
+
+//line gen.y:10
+func g() {}
+`,
+			opts: []commentremover.Option{
+				commentremover.KeepBuildConstraints(false), commentremover.KeepEmbed(false),
+				commentremover.KeepCgoPreamble(false), commentremover.KeepCgoExports(false),
+				commentremover.KeepLineDirectives(false),
+			},
+			want: []commentremover.Hazard{
+				{Line: 1, Kind: commentremover.HazardBuildConstraint, Text: "//go:build linux"},
+				{Line: 5, Kind: commentremover.HazardCgoPreamble, Text: "// #include <stdio.h>"},
+				{Line: 8, Kind: commentremover.HazardEmbed, Text: "//go:embed a.txt"},
+				{Line: 11, Kind: commentremover.HazardCgoExport, Text: "//export F"},
+				{Line: 14, Kind: commentremover.HazardLineDirective, Text: "//line gen.y:10"},
+			},
+		},
+		{
+			name:  "snippet lines are not shifted by the dummy package",
+			input: "// doc\n//go:embed a.txt\nvar a string\n",
+			opts:  []commentremover.Option{commentremover.KeepEmbed(false)},
+			want:  []commentremover.Hazard{{Line: 2, Kind: commentremover.HazardEmbed, Text: "//go:embed a.txt"}},
+		},
+	}
+
+	engines := []commentremover.Engine{commentremover.EngineAST, commentremover.EngineScanner}
+
+	for _, testCase := range tests {
+		for _, engine := range engines {
+			t.Run(testCase.name+"/"+engine.String(), func(t *testing.T) {
+				t.Parallel()
+
+				opts := append([]commentremover.Option{commentremover.WithEngine(engine)}, testCase.opts...)
+
+				got, err := commentremover.Process(testCase.input, opts...)
+				if err != nil {
+					t.Fatalf("Process() error = %v", err)
+				}
+
+				if !slices.Equal(got.Hazards, testCase.want) {
+					t.Errorf("Process() hazards = %+v, want %+v", got.Hazards, testCase.want)
+				}
+			})
+		}
+	}
+}
+
+// TestStripFile verifies that StripFile removes comments from a parsed
+// file in place and keeps the Doc and Comment fields of its nodes in step.
+func TestStripFile(t *testing.T) {
+	t.Parallel()
+
+	input := `//go:build linux
+
+// Package p does things.
+package p
+
+// T is documented.
+type T struct {
+	A int // A is a field.
+}
+
+// f is not exported.
+func f() {} // f ends here.
+`
+
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "p.go", input, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("ParseFile() error = %v", err)
+	}
+
+	hazards := commentremover.StripFile(fset, file,
+		commentremover.KeepDoc(true), commentremover.KeepBuildConstraints(false))
+	if len(hazards) != 1 || hazards[0].Kind != commentremover.HazardBuildConstraint {
+		t.Errorf("StripFile() = %v, want one build constraint hazard", hazards)
+	}
+
+	if file.Doc != nil {
+		t.Errorf("file.Doc = %q, want nil", file.Doc.Text())
+	}
+
+	var typeDoc, fieldComment *ast.CommentGroup
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch typed := node.(type) {
+		case *ast.GenDecl:
+			typeDoc = typed.Doc
+		case *ast.Field:
+			fieldComment = typed.Comment
+		}
+
+		return true
+	})
+
+	if typeDoc == nil || !slices.Contains(file.Comments, typeDoc) {
+		t.Errorf("type doc = %v, want a group of file.Comments", typeDoc)
+	}
+
+	if fieldComment != nil {
+		t.Errorf("field comment = %q, want nil", fieldComment.Text())
+	}
+
+	var out strings.Builder
+	if err := format.Node(&out, fset, file); err != nil {
+		t.Fatalf("format.Node() error = %v", err)
+	}
+
+	want := "package p\n\n// T is documented.\ntype T struct {\n\tA int\n}\n\nfunc f() {}\n"
+	if out.String() != want {
+		t.Errorf("printed file = %q, want %q", out.String(), want)
+	}
+}
+
+// TestRemoveCommentsFromDir verifies that the Go files under a directory
+// are processed and, with WriteBack, rewritten, and that a failing file
+// does not stop the others.
+func TestRemoveCommentsFromDir(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"a.go":           "package p // p\n",
+		"sub/b.go":       "package p\n\nvar x = 1\n",
+		"broken.go":      "package p\n\nfunc {\n",
+		"vendor/v/v.go":  "package v // v\n",
+		"notes/read.txt": "// not Go\n",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := commentremover.RemoveCommentsFromDir(root, commentremover.WriteBack(true))
+	if err == nil || !strings.Contains(err.Error(), "broken.go") {
+		t.Errorf("RemoveCommentsFromDir() error = %v, want an error for broken.go", err)
+	}
+
+	var paths []string
+	for _, result := range results {
+		rel, _ := filepath.Rel(root, result.Path)
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+
+	if want := []string{"a.go", "sub/b.go"}; !slices.Equal(paths, want) {
+		t.Errorf("RemoveCommentsFromDir() paths = %v, want %v", paths, want)
+	}
+
+	for name, want := range map[string]string{"a.go": "package p\n", "vendor/v/v.go": "package v // v\n"} {
+		got, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// TestRemoveCommentsFromFileVerbatim verifies that a file is processed as
+// the exact bytes it holds: line endings are not normalized, no newline is
+// added at the end, and lines longer than a bufio.Scanner token are kept.
+func TestRemoveCommentsFromFileVerbatim(t *testing.T) {
+	t.Parallel()
+
+	content := "package p // p\r\n\nvar s = `a\rb`\r\nvar long = \"" + strings.Repeat("x", 1<<17) + "\" // long"
+
+	path := filepath.Join(t.TempDir(), "verbatim.go")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, engine := range []commentremover.Engine{commentremover.EngineAST, commentremover.EngineScanner} {
+		want, err := commentremover.RemoveComments(content, commentremover.WithEngine(engine))
+		if err != nil {
+			t.Fatalf("RemoveComments() with %v error = %v", engine, err)
+		}
+
+		result, err := commentremover.RemoveCommentsFromFile(path, commentremover.WithEngine(engine))
+		if err != nil || result.Source != want {
+			t.Errorf("RemoveCommentsFromFile() with %v = %.40q, %v, want %.40q", engine, result.Source, err, want)
+		}
+	}
+}
+
+// TestContextCanceled verifies that the context variants stop once their
+// context is done.
+func TestContextCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	if _, err := commentremover.ProcessContext(ctx, "package p // p\n"); !errors.Is(err, context.Canceled) {
+		t.Errorf("ProcessContext() error = %v, want context.Canceled", err)
+	}
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.go"), []byte("package p // p\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := commentremover.RemoveCommentsFromDirContext(ctx, root, commentremover.WriteBack(true))
+	if !errors.Is(err, context.Canceled) || len(results) != 0 {
+		t.Errorf("RemoveCommentsFromDirContext() = %v, %v, want no results and context.Canceled", results, err)
+	}
+}
+
+// TestBatch verifies that a Batch returns the outcomes of its sources in
+// order, with the errors of failing sources alongside the others.
+func TestBatch(t *testing.T) {
+	t.Parallel()
+
+	sources := make([]string, 20)
+	for i := range sources {
+		sources[i] = "package p\n\nvar x = " + strconv.Itoa(i) + " // x\n"
+	}
+
+	sources[7] = "package p\n\nfunc {\n"
+
+	batch := commentremover.Batch{
+		Workers: 3,
+		Options: []commentremover.Option{commentremover.WithEngine(commentremover.EngineAST)},
+	}
+
+	for i, result := range batch.Process(t.Context(), sources) {
+		if i == 7 {
+			if result.Err == nil {
+				t.Errorf("source %d: Process() error = nil, want a parse error", i)
+			}
+
+			continue
+		}
+
+		if want := "package p\n\nvar x = " + strconv.Itoa(i) + "\n"; result.Err != nil || result.Source != want {
+			t.Errorf("source %d: Process() = %q, %v, want %q", i, result.Source, result.Err, want)
+		}
+	}
+}
+
+// TestProcessAfterLargerSource verifies that the state kept for reuse from
+// one source to the next does not carry over any of an earlier, larger
+// source, with either engine.
+func TestProcessAfterLargerSource(t *testing.T) {
+	t.Parallel()
+
+	large := "package p\n\n" + strings.Repeat("var x = `raw\nstring` // x\n", 100)
+
+	for _, engine := range []commentremover.Engine{commentremover.EngineAST, commentremover.EngineScanner} {
+		for _, source := range []string{large, "x := 1 // x\n"} {
+			if _, err := commentremover.Process(source, commentremover.WithEngine(engine)); err != nil {
+				t.Fatalf("%v: Process() error = %v", engine, err)
+			}
+		}
+
+		got, err := commentremover.Process("y := `a\nb` // y\n", commentremover.WithEngine(engine))
+		if want := "y := `a\nb`\n"; err != nil || got.Source != want {
+			t.Errorf("%v: Process() = %q, %v, want %q", engine, got.Source, err, want)
+		}
+	}
+}
+
+// TestRemoveIfComment verifies that comment filters see each comment with
+// its group and owner, and keep the comments they reject.
+func TestRemoveIfComment(t *testing.T) {
+	t.Parallel()
+
+	input := `package p
+
+// T is a type.
+type T struct {
+	A int // A is a field.
+}
+
+func f() {
+	// Inside the body.
+}
+`
+
+	owners := make(map[string]string)
+	filter := func(c *ast.Comment, group *ast.CommentGroup, owner ast.Node) bool {
+		if !slices.Contains(group.List, c) {
+			t.Errorf("comment %q is not in its group", c.Text)
+		}
+
+		owners[c.Text] = fmt.Sprintf("%T", owner)
+		_, field := owner.(*ast.Field)
+
+		return !field
+	}
+
+	got, err := commentremover.RemoveComments(input, commentremover.RemoveIfComment(filter))
+	if err != nil {
+		t.Fatalf("RemoveComments() error = %v", err)
+	}
+
+	want := "package p\n\ntype T struct {\n\tA int // A is a field.\n}\n\nfunc f() {\n\n}\n"
+	if got != want {
+		t.Errorf("RemoveComments() = %q, want %q", got, want)
+	}
+
+	wantOwners := map[string]string{
+		"// T is a type.":     "*ast.GenDecl",
+		"// A is a field.":    "*ast.Field",
+		"// Inside the body.": "*ast.BlockStmt",
+	}
+	if !maps.Equal(owners, wantOwners) {
+		t.Errorf("owners = %v, want %v", owners, wantOwners)
+	}
+}
+
+// TestStats verifies that both engines count the removed comments by kind,
+// the kept directives, and the lines and bytes removed.
+func TestStats(t *testing.T) {
+	t.Parallel()
+
+	input := "package p\n\n//go:generate stringer -type T\n\n// T is documented.\n// It is exported.\n" +
+		"type T int /* trailing */\n"
+
+	for _, engine := range []commentremover.Engine{commentremover.EngineAST, commentremover.EngineScanner} {
+		t.Run(engine.String(), func(t *testing.T) {
+			t.Parallel()
+
+			result, err := commentremover.Process(input, commentremover.WithEngine(engine))
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+
+			want := commentremover.Stats{
+				LineComments:   2,
+				BlockComments:  1,
+				DocComments:    2,
+				DirectivesKept: 1,
+				LinesRemoved:   2,
+				BytesSaved:     len(" /* trailing */") + len("// T is documented.\n// It is exported.\n"),
+				InputBytes:     len(input),
+				InputTokens:    commentremover.EstimateTokens(input),
+				OutputTokens:   commentremover.EstimateTokens(result.Source),
+
+				RemovedByCategory: commentremover.Categories{Doc: 2, Inline: 1},
+				KeptByCategory:    commentremover.Categories{Directive: 1},
+			}
+			if result.Stats != want || result.Stats.Removed() != 3 || result.Stats.TokensSaved() <= 0 {
+				t.Errorf("Process() stats = %+v, want %+v", result.Stats, want)
+			}
+
+			wantPercent := float64(want.BytesSaved) * 100 / float64(len(input))
+			if percent := result.Stats.PercentSaved(); percent != wantPercent {
+				t.Errorf("PercentSaved() = %v, want %v", percent, wantPercent)
+			}
+		})
+	}
+}
+
+// TestCategories verifies that both engines count every removed and kept
+// comment in the first category it falls in.
+func TestCategories(t *testing.T) {
+	t.Parallel()
+
+	input := "// Copyright 2026 The Authors. All rights reserved.\n\n// Package p is documented.\npackage p\n\n" +
+		"//go:generate stringer -type T\n\n// TODO: export fewer names.\n\n// T is documented.\ntype T int\n\n" +
+		"/* internal block */\nvar x = 1 // inline\n\n// internal line\nvar y = /* inline */ 2\n\n" +
+		"// FIXME: documented, but a task first.\nfunc F() {}\n"
+
+	for _, engine := range []commentremover.Engine{commentremover.EngineAST, commentremover.EngineScanner} {
+		t.Run(engine.String(), func(t *testing.T) {
+			t.Parallel()
+
+			result, err := commentremover.Process(input, commentremover.WithEngine(engine),
+				commentremover.KeepLicenseHeader(true))
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+
+			wantRemoved := commentremover.Categories{Todo: 2, Doc: 2, Inline: 2, Block: 1, Line: 1}
+			wantKept := commentremover.Categories{License: 1, Directive: 1}
+
+			if result.Stats.RemovedByCategory != wantRemoved {
+				t.Errorf("Process() removed by category = %+v, want %+v", result.Stats.RemovedByCategory, wantRemoved)
+			}
+
+			if result.Stats.KeptByCategory != wantKept {
+				t.Errorf("Process() kept by category = %+v, want %+v", result.Stats.KeptByCategory, wantKept)
+			}
+
+			if total := result.Stats.RemovedByCategory.Total(); total != result.Stats.Removed() {
+				t.Errorf("RemovedByCategory.Total() = %d, want %d", total, result.Stats.Removed())
+			}
+		})
+	}
+}
+
+// TestEstimateTokens verifies that token estimates follow the pieces that
+// byte-pair encodings split source into.
+func TestEstimateTokens(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		src  string
+		want int
+	}{
+		{src: "", want: 0},
+		{src: "// TODO: fix this\n", want: 6},
+		{src: "x := 12345\n", want: 5},
+		{src: "func removeCommentsFromAST(fset *token.FileSet) error {\n\treturn nil\n}\n", want: 19},
+		{src: "type ASTContext struct{}", want: 5},
+		{src: "\t\t\t\tindented", want: 2},
+		{src: "héllo", want: 3},
+	}
+
+	for _, tt := range tests {
+		if got := commentremover.EstimateTokens(tt.src); got != tt.want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", tt.src, got, tt.want)
+		}
+	}
+}
+
+// TestFindLicenseHeader verifies that the leading license header of a
+// source is found, with its lines and the license it names, even in code
+// that does not parse, and that other leading comments are not taken for
+// one.
+func TestFindLicenseHeader(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		src    string
+		want   commentremover.LicenseHeader
+		wantOK bool
+	}{
+		{
+			name: "go project",
+			src: "// Copyright 2026 The Go Authors. All rights reserved.\n" +
+				"// Use of this source code is governed by a BSD-style\n// license that can be found in the LICENSE file.\n" +
+				"\n// Package p does things.\npackage p\n",
+			want:   commentremover.LicenseHeader{FirstLine: 1, LastLine: 3, License: "BSD-style"},
+			wantOK: true,
+		},
+		{
+			name:   "spdx",
+			src:    "/*\n * Copyright 2026 Example Inc.\n * SPDX-License-Identifier: Apache-2.0 OR MIT\n */\n\npackage p\n",
+			want:   commentremover.LicenseHeader{FirstLine: 1, LastLine: 4, License: "Apache-2.0 OR MIT"},
+			wantOK: true,
+		},
+		{
+			name: "mit text",
+			src: "// Copyright (c) 2026 Someone\n//\n// Permission is hereby granted, free of charge, to any person\n" +
+				"package p\n",
+			want:   commentremover.LicenseHeader{FirstLine: 1, LastLine: 3, License: "MIT"},
+			wantOK: true,
+		},
+		{
+			name:   "bare copyright",
+			src:    "\n// Copyright 2026 Someone\n\npackage p\n\nfunc f( {\n",
+			want:   commentremover.LicenseHeader{FirstLine: 2, LastLine: 2},
+			wantOK: true,
+		},
+		{
+			name: "package doc",
+			src:  "// Package p is not licensed here.\npackage p\n",
+		},
+		{
+			name: "later comment",
+			src:  "package p\n\n// Copyright 2026 Someone\nvar x int\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := commentremover.FindLicenseHeader(tt.src)
+			got.Text = ""
+
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("FindLicenseHeader() = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// TestParseErrors verifies that input that is not Go code fails with
+// ErrParse and diagnostics positioned in the input, that a missing file
+// fails with an I/O error instead, and that blank input fails with
+// ErrEmptyInput.
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		input  string
+		engine commentremover.Engine
+		want   string
+	}{
+		{name: "file", input: "package p\n\nfunc {\n", engine: commentremover.EngineAST, want: "3:6"},
+		{name: "snippet", input: "var x = 1\n\nfunc {\n", engine: commentremover.EngineAST, want: "3:6"},
+		{name: "statements", input: "x := 1\nif x {\n\ty := f(\n}\n", engine: commentremover.EngineAST, want: "4:1"},
+		{name: "expression", input: "a + (b *)", engine: commentremover.EngineAST, want: "1:9"},
+		{name: "scanner", input: "package p\n\nvar s = \"open\n", engine: commentremover.EngineScanner, want: "3:9"},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := commentremover.RemoveComments(testCase.input, commentremover.WithEngine(testCase.engine))
+			if !errors.Is(err, commentremover.ErrParse) {
+				t.Fatalf("RemoveComments() error = %v, want ErrParse", err)
+			}
+
+			var list scanner.ErrorList
+			if !errors.As(err, &list) || len(list) == 0 {
+				t.Fatalf("RemoveComments() error = %v, want a scanner.ErrorList", err)
+			}
+
+			if got := fmt.Sprintf("%d:%d", list[0].Pos.Line, list[0].Pos.Column); got != testCase.want {
+				t.Errorf("RemoveComments() error at %s, want %s", got, testCase.want)
+			}
+		})
+	}
+
+	_, err := commentremover.RemoveCommentsFromFile(filepath.Join(t.TempDir(), "missing.go"))
+	if !errors.Is(err, fs.ErrNotExist) || errors.Is(err, commentremover.ErrParse) {
+		t.Errorf("RemoveCommentsFromFile() error = %v, want fs.ErrNotExist", err)
+	}
+
+	for _, input := range []string{"", " \n\t\r\n", "\ufeff\n"} {
+		_, err := commentremover.RemoveComments(input)
+		if !errors.Is(err, commentremover.ErrEmptyInput) || errors.Is(err, commentremover.ErrParse) {
+			t.Errorf("RemoveComments(%q) error = %v, want ErrEmptyInput", input, err)
+		}
+	}
+
+	if _, err := commentremover.RemoveComments("// only a comment\n"); err != nil {
+		t.Errorf("RemoveComments() of a comment error = %v, want nil", err)
+	}
+}
+
+// TestPanicError verifies that a panic of the AST pipeline, here of a
+// RemoveIf filter, is returned as a *PanicError wrapping ErrPanic, with
+// its stack trace, rather than a fallback to the scanner of EngineAuto.
+func TestPanicError(t *testing.T) {
+	t.Parallel()
+
+	crash := commentremover.RemoveIf(func(_, _ int) bool { panic("boom") })
+
+	for _, engine := range []commentremover.Engine{commentremover.EngineAST, commentremover.EngineAuto} {
+		_, err := commentremover.Process("package p\n\n// c\n", commentremover.WithEngine(engine), crash)
+		if !errors.Is(err, commentremover.ErrPanic) {
+			t.Fatalf("Process() with %v error = %v, want ErrPanic", engine, err)
+		}
+
+		var panicErr *commentremover.PanicError
+		if !errors.As(err, &panicErr) || panicErr.Value != "boom" ||
+			!bytes.Contains(panicErr.Stack, []byte("commentremover")) {
+			t.Errorf("Process() with %v error = %#v, want a PanicError for boom with its stack", engine, err)
+		}
+	}
+}
+
+// TestCheckEquivalence verifies that outputs differing from their inputs
+// only in comments and in what gofmt and gofumpt rewrite are accepted, and
+// that others are rejected at the line where they differ.
+func TestCheckEquivalence(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		input  string
+		output string
+		want   error
+		line   string
+	}{
+		{
+			name:   "comments",
+			input:  "package p\n\n// f does it.\nfunc f() {\n\tx := 1 // one\n\t_ = x\n}\n",
+			output: "package p\n\nfunc f() {\n\tx := 1\n\t_ = x\n}\n",
+		},
+		{
+			name:   "gofumpt",
+			input:  "package p\n\nimport (\n\t\"example.com/m\"\n\t\"fmt\"\n)\n\nvar x = 0755 + m.X\n\nvar _ = fmt.Sprint\n",
+			output: "package p\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/m\"\n)\n\nvar x = 0o755 + m.X\n\nvar _ = fmt.Sprint\n",
+		},
+		{name: "snippet", input: "x := 1 // one\n_ = x\n", output: "x := 1\n_ = x\n"},
+		{
+			name:   "changed",
+			input:  "package p\n\nfunc f() {\n\t// c\n\tg(1)\n}\n",
+			output: "package p\n\nfunc f() {\n\tg(2)\n}\n",
+			want:   commentremover.ErrNotEquivalent,
+			line:   "line 5 of the input and line 4 of the output",
+		},
+		{
+			name:   "dropped",
+			input:  "package p\n\nvar (\n\ta = 1 // a\n\tb = 2\n)\n",
+			output: "package p\n\nvar (\n\ta = 1\n)\n",
+			want:   commentremover.ErrNotEquivalent,
+			line:   "line 3 of the input and line 3 of the output",
+		},
+		{name: "broken output", input: "x := 1\n", output: "x := \n", want: commentremover.ErrNotEquivalent},
+		{name: "broken input", input: "func {\n", output: "func {\n", want: commentremover.ErrParse},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := commentremover.CheckEquivalence(testCase.input, testCase.output)
+			if !errors.Is(err, testCase.want) || testCase.want == nil && err != nil {
+				t.Fatalf("CheckEquivalence() error = %v, want %v", err, testCase.want)
+			}
+
+			if err != nil && !strings.Contains(err.Error(), testCase.line) {
+				t.Errorf("CheckEquivalence() error = %v, want it to name %s", err, testCase.line)
+			}
+		})
+	}
+}
+
+// TestEncodings verifies that sources in UTF-16 and Latin-1 are detected,
+// decoded to UTF-8 for Process, and encoded back unchanged but for the
+// comments.
+func TestEncodings(t *testing.T) {
+	t.Parallel()
+
+	const (
+		input = "\uFEFFpackage p\n\n// Grüße\nvar s = \"café\"\n"
+		want  = "\uFEFFpackage p\n\nvar s = \"café\"\n"
+	)
+
+	tests := []struct {
+		name     string
+		encoding commentremover.Encoding
+		detected commentremover.Encoding
+		source   string
+	}{
+		{name: "utf-8", encoding: commentremover.EncodingUTF8, detected: commentremover.EncodingUTF8, source: input},
+		{name: "utf-16le", encoding: commentremover.EncodingUTF16LE, detected: commentremover.EncodingUTF16LE, source: input},
+		{name: "utf-16be", encoding: commentremover.EncodingUTF16BE, detected: commentremover.EncodingUTF16BE, source: input},
+		{
+			name: "utf-16le without bom", encoding: commentremover.EncodingUTF16LE,
+			detected: commentremover.EncodingUTF16LE, source: strings.TrimPrefix(input, "\uFEFF"),
+		},
+		{
+			name: "latin1", encoding: commentremover.EncodingLatin1,
+			detected: commentremover.EncodingLatin1, source: strings.TrimPrefix(input, "\uFEFF"),
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := commentremover.EncodeSource(testCase.source, testCase.encoding)
+			if err != nil {
+				t.Fatalf("EncodeSource() error = %v", err)
+			}
+
+			if got := commentremover.DetectEncoding(data); got != testCase.detected {
+				t.Fatalf("DetectEncoding() = %v, want %v", got, testCase.detected)
+			}
+
+			decoded, err := commentremover.DecodeSource(data, testCase.detected)
+			if err != nil || decoded != testCase.source {
+				t.Fatalf("DecodeSource() = %q, %v, want %q", decoded, err, testCase.source)
+			}
+
+			stripped, err := commentremover.RemoveComments(decoded)
+			if err != nil {
+				t.Fatalf("RemoveComments() error = %v", err)
+			}
+
+			wantOutput := want
+			if !strings.HasPrefix(testCase.source, "\uFEFF") {
+				wantOutput = strings.TrimPrefix(want, "\uFEFF")
+			}
+
+			encoded, err := commentremover.EncodeSource(stripped, testCase.encoding)
+			if wantData, _ := commentremover.EncodeSource(wantOutput, testCase.encoding); err != nil || encoded != wantData {
+				t.Errorf("EncodeSource() = %q, %v, want %q", encoded, err, wantData)
+			}
+		})
+	}
+
+	_, err := commentremover.EncodeSource("var s = \"€\"\n", commentremover.EncodingLatin1)
+	if !errors.Is(err, commentremover.ErrEncoding) {
+		t.Errorf("EncodeSource() of € in latin1 error = %v, want ErrEncoding", err)
+	}
+
+	_, err = commentremover.DecodeSource("abc", commentremover.EncodingUTF16LE)
+	if !errors.Is(err, commentremover.ErrEncoding) {
+		t.Errorf("DecodeSource() of an odd number of bytes error = %v, want ErrEncoding", err)
+	}
+}
@@ -0,0 +1,76 @@
+package commentremover
+
+import "strings"
+
+// deprecatedPrefix starts a paragraph announcing a deprecation, following
+// the godoc convention.
+const deprecatedPrefix = "Deprecated:"
+
+// deprecatedNotices reports, for each comment of a comment group given by
+// texts, whether it belongs to a paragraph starting with "Deprecated:". A
+// paragraph of line comments ends at a blank comment line. A block comment
+// is part of a notice if it holds one.
+func deprecatedNotices(texts []string) []bool {
+	notices := make([]bool, len(texts))
+	paragraph := newParagraphs()
+
+	for i, text := range texts {
+		body, ok := strings.CutPrefix(text, "//")
+		if !ok {
+			notices[i] = hasDeprecatedParagraph(text)
+			paragraph = newParagraphs()
+
+			continue
+		}
+
+		notices[i] = paragraph.next(body)
+	}
+
+	return notices
+}
+
+// hasDeprecatedParagraph reports whether the block comment text holds a
+// paragraph starting with "Deprecated:". A leading "*" on each line, as in
+// C-style block comments, is ignored.
+func hasDeprecatedParagraph(text string) bool {
+	body := strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+	paragraph := newParagraphs()
+
+	for line := range strings.SplitSeq(body, "\n") {
+		if paragraph.next(strings.TrimPrefix(strings.TrimSpace(line), "*")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// paragraphs follows the paragraphs of a comment line by line.
+type paragraphs struct {
+	start  bool // start is set when the next non-blank line begins a paragraph.
+	notice bool // notice is set within a paragraph starting with "Deprecated:".
+}
+
+// newParagraphs returns a paragraphs positioned at the start of a comment.
+func newParagraphs() paragraphs {
+	return paragraphs{start: true}
+}
+
+// next consumes line and reports whether it belongs to a deprecation
+// notice.
+func (p *paragraphs) next(line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		p.start, p.notice = true, false
+
+		return false
+	}
+
+	if p.start {
+		p.notice = strings.HasPrefix(line, deprecatedPrefix)
+	}
+
+	p.start = false
+
+	return p.notice
+}
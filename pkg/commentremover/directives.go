@@ -0,0 +1,108 @@
+package commentremover
+
+import (
+	"go/build/constraint"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// taskMarkerPattern matches comments that begin with a task marker, such
+// as "// TODO: ..." or "/* FIXME(name) ... */".
+var taskMarkerPattern = regexp.MustCompile(`^(//|/\*)\s*(TODO|FIXME|HACK)\b`)
+
+// isBuildConstraint reports whether text is a //go:build or // +build
+// line. Such lines only take effect in the file header, so callers must
+// check the comment's position as well.
+func isBuildConstraint(text string) bool {
+	return constraint.IsGoBuild(text) || constraint.IsPlusBuild(text)
+}
+
+// isGenerateDirective reports whether text is a //go:generate directive.
+func isGenerateDirective(text string) bool {
+	return strings.HasPrefix(text, "//go:generate ")
+}
+
+// isEmbedDirective reports whether text is a //go:embed directive. The
+// directive only takes effect in the doc comment of a variable declaration.
+func isEmbedDirective(text string) bool {
+	return strings.HasPrefix(text, "//go:embed ")
+}
+
+// isExportDirective reports whether text is a cgo //export directive. The
+// directive only has meaning in a file that imports "C".
+func isExportDirective(text string) bool {
+	return strings.HasPrefix(text, "//export ")
+}
+
+// isLineDirective reports whether text is a //line or /*line*/ directive,
+// which sets the positions reported for the code that follows it.
+func isLineDirective(text string) bool {
+	return strings.HasPrefix(text, "//line ") || strings.HasPrefix(text, "/*line ")
+}
+
+// isSyscallDirective reports whether text is a //sys or //sysnb directive,
+// which drive syscall wrapper generation in packages that follow the
+// golang.org/x/sys conventions.
+func isSyscallDirective(text string) bool {
+	for _, prefix := range []string{"//sys", "//sysnb"} {
+		if rest, ok := strings.CutPrefix(text, prefix); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isNolintDirective reports whether text is a //nolint lint-suppression
+// comment, optionally naming linters as in //nolint:errcheck,gosec. The
+// older spaced form // nolint is accepted as well.
+func isNolintDirective(text string) bool {
+	rest, ok := strings.CutPrefix(text, "//")
+	if !ok {
+		return false
+	}
+
+	rest, ok = strings.CutPrefix(strings.TrimLeft(rest, " "), "nolint")
+
+	return ok && (rest == "" || rest[0] == ':' || rest[0] == ' ' || rest[0] == '\t')
+}
+
+// isSPDXIdentifier reports whether text is an SPDX-License-Identifier
+// comment, in line or block form.
+func isSPDXIdentifier(text string) bool {
+	body := strings.TrimPrefix(strings.TrimPrefix(text, "//"), "/*")
+
+	return strings.HasPrefix(strings.TrimLeft(body, " \t"), "SPDX-License-Identifier:")
+}
+
+// isAnnotation reports whether text is an annotation for a code generator,
+// a comment beginning with an @ marker such as swaggo's // @Router.
+func isAnnotation(text string) bool {
+	body, ok := strings.CutPrefix(text, "//")
+	if !ok {
+		body = strings.TrimPrefix(text, "/*")
+	}
+
+	name, ok := strings.CutPrefix(strings.TrimLeft(body, " \t"), "@")
+
+	return ok && name != "" && unicode.IsLetter(rune(name[0]))
+}
+
+// isTaskMarker reports whether text is a comment beginning with a TODO,
+// FIXME, or HACK marker.
+func isTaskMarker(text string) bool {
+	return taskMarkerPattern.MatchString(text)
+}
+
+// isCompilerDirective reports whether text is a //go: pragma such as
+// //go:noinline or //go:linkname. Build constraints, //go:generate, and
+// //go:embed are excluded because they have options of their own.
+func isCompilerDirective(text string) bool {
+	name, ok := strings.CutPrefix(text, "//go:")
+	if !ok || name == "" || name[0] < 'a' || name[0] > 'z' {
+		return false
+	}
+
+	return !isBuildConstraint(text) && !isGenerateDirective(text) && !isEmbedDirective(text)
+}
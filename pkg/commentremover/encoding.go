@@ -0,0 +1,176 @@
+package commentremover
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ErrEncoding is returned, wrapped with the reason, when a source cannot be
+// decoded from or encoded in an Encoding.
+var ErrEncoding = errors.New("cannot transcode source")
+
+// maxDetectedBytes is the number of bytes at the start of a source that
+// DetectEncoding looks at for the zero bytes of UTF-16.
+const maxDetectedBytes = 4096
+
+// Encoding is a character encoding of source files. Go source is UTF-8,
+// but files saved by Windows tooling may come in UTF-16 or Latin-1. Process
+// takes UTF-8 only: DecodeSource turns other encodings into UTF-8 first,
+// and EncodeSource turns the result back.
+type Encoding int
+
+const (
+	// EncodingUTF8 is UTF-8, the encoding of Go source.
+	EncodingUTF8 Encoding = iota
+
+	// EncodingUTF16LE is UTF-16 with the low byte of each unit first.
+	EncodingUTF16LE
+
+	// EncodingUTF16BE is UTF-16 with the high byte of each unit first.
+	EncodingUTF16BE
+
+	// EncodingLatin1 is ISO 8859-1, in which each byte is the code point of
+	// the same value.
+	EncodingLatin1
+)
+
+// String returns the lower-case name of the encoding.
+func (e Encoding) String() string {
+	switch e {
+	case EncodingUTF8:
+		return "utf-8"
+	case EncodingUTF16LE:
+		return "utf-16le"
+	case EncodingUTF16BE:
+		return "utf-16be"
+	case EncodingLatin1:
+		return "latin1"
+	default:
+		return "unknown"
+	}
+}
+
+// DetectEncoding returns the encoding that data, the content of a source
+// file, is most likely in. UTF-16 is told by its byte order mark or, as Go
+// code is mostly ASCII, by zero bytes in every other place, which Go source
+// cannot hold. Data that is not valid UTF-8 either is taken to be Latin-1,
+// in which any bytes are valid. Anything else is UTF-8.
+func DetectEncoding(data string) Encoding {
+	switch {
+	case strings.HasPrefix(data, "\xff\xfe"):
+		return EncodingUTF16LE
+	case strings.HasPrefix(data, "\xfe\xff"):
+		return EncodingUTF16BE
+	}
+
+	if strings.IndexByte(data, 0) >= 0 {
+		var zeros [2]int
+
+		sample := data[:min(len(data), maxDetectedBytes)]
+		for i := range len(sample) {
+			if sample[i] == 0 {
+				zeros[i%2]++
+			}
+		}
+
+		// Each ASCII character leaves a zero byte behind it in the
+		// little-endian form and ahead of it in the big-endian one.
+		switch units := len(sample) / 2; {
+		case zeros[1] > units/2 && zeros[0] < zeros[1]/4:
+			return EncodingUTF16LE
+		case zeros[0] > units/2 && zeros[1] < zeros[0]/4:
+			return EncodingUTF16BE
+		}
+	}
+
+	if !utf8.ValidString(data) {
+		return EncodingLatin1
+	}
+
+	return EncodingUTF8
+}
+
+// DecodeSource returns data, text in enc, as UTF-8. A byte order mark is
+// kept as the UTF-8 one, which Go accepts at the start of a file, so that
+// EncodeSource writes it back. UTF-16 units that do not pair up decode to
+// U+FFFD. The error wraps ErrEncoding if data has an odd number of bytes for
+// UTF-16.
+func DecodeSource(data string, enc Encoding) (string, error) {
+	switch enc {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		if len(data)%2 != 0 {
+			return "", fmt.Errorf("%w: odd number of bytes for %v", ErrEncoding, enc)
+		}
+
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			low, high := data[2*i], data[2*i+1]
+			if enc == EncodingUTF16BE {
+				low, high = high, low
+			}
+
+			units[i] = uint16(high)<<8 | uint16(low)
+		}
+
+		return string(utf16.Decode(units)), nil
+	case EncodingLatin1:
+		var out strings.Builder
+
+		out.Grow(len(data))
+
+		for i := range len(data) {
+			out.WriteRune(rune(data[i]))
+		}
+
+		return out.String(), nil
+	default:
+		return data, nil
+	}
+}
+
+// EncodeSource returns src, UTF-8 text such as the result of Process, in
+// enc. The error wraps ErrEncoding, with the line and column of the
+// character, if src holds one that Latin-1 cannot represent.
+func EncodeSource(src string, enc Encoding) (string, error) {
+	switch enc {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		units := utf16.Encode([]rune(src))
+		out := make([]byte, 0, 2*len(units))
+
+		for _, unit := range units {
+			high, low := byte(unit>>8), byte(unit) //nolint:gosec // The two bytes of the unit.
+			if enc == EncodingUTF16BE {
+				out = append(out, high, low)
+			} else {
+				out = append(out, low, high)
+			}
+		}
+
+		return string(out), nil
+	case EncodingLatin1:
+		out := make([]byte, 0, len(src))
+		line, column := 1, 1
+
+		for _, char := range src {
+			if char > unicode.MaxLatin1 {
+				return "", fmt.Errorf("%w: %q at %d:%d is not in %v", ErrEncoding, char, line, column, enc)
+			}
+
+			out = append(out, byte(char))
+
+			if char == '\n' {
+				line, column = line+1, 1
+			} else {
+				column += utf8.RuneLen(char)
+			}
+		}
+
+		return string(out), nil
+	default:
+		return src, nil
+	}
+}
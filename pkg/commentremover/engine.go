@@ -0,0 +1,89 @@
+package commentremover
+
+import "errors"
+
+// Engine identifies the pipeline used to remove comments from source code.
+type Engine int
+
+const (
+	// EngineAuto selects EngineAST for typical input and falls back to
+	// EngineScanner for very large input or input that fails to parse.
+	EngineAuto Engine = iota
+
+	// EngineAST parses the source into an AST, drops the comments, and
+	// re-prints the result as gofmt does.
+	EngineAST
+
+	// EngineScanner deletes comment tokens directly from the original
+	// bytes, leaving all other formatting untouched.
+	EngineScanner
+)
+
+// autoSizeLimit is the input size, in bytes, above which EngineAuto skips
+// the AST pipeline. Parsing and re-printing multi-megabyte generated files
+// is slow and memory hungry, while the scanner handles them in one pass.
+const autoSizeLimit = 4 << 20
+
+// String returns the lower-case name of the engine.
+func (e Engine) String() string {
+	switch e {
+	case EngineAuto:
+		return "auto"
+	case EngineAST:
+		return "ast"
+	case EngineScanner:
+		return "scanner"
+	default:
+		return "unknown"
+	}
+}
+
+// processAuto implements EngineAuto. It prefers the AST pipeline and
+// records the reason in the result whenever it falls back to the scanner.
+// If both pipelines fail, the AST error is returned since it carries the
+// more useful diagnostics.
+func processAuto(sourceCode string, cfg config) (Result, error) {
+	if len(sourceCode) > autoSizeLimit {
+		result, err := processScanner(sourceCode, cfg)
+		result.Fallback = "input exceeds AST size limit"
+
+		return result, err
+	}
+
+	result, astErr := processAST(sourceCode, cfg)
+	if astErr == nil {
+		return result, nil
+	}
+
+	return fallBack(sourceCode, cfg, astErr)
+}
+
+// processBestEffort implements EngineAST with BestEffort: like EngineAuto,
+// it falls back to the scanner for input that fails to parse.
+func processBestEffort(sourceCode string, cfg config) (Result, error) {
+	result, astErr := processAST(sourceCode, cfg)
+	if astErr == nil {
+		return result, nil
+	}
+
+	return fallBack(sourceCode, cfg, astErr)
+}
+
+// fallBack processes sourceCode with the scanner after astErr kept the AST
+// pipeline from parsing it, recording why in the result. If the scanner
+// fails as well, astErr is returned, as it is right away if the pipeline
+// panicked, so that the crash is not hidden.
+func fallBack(sourceCode string, cfg config, astErr error) (Result, error) {
+	if errors.Is(astErr, ErrPanic) {
+		return Result{}, astErr
+	}
+
+	result, err := processScanner(sourceCode, cfg)
+	if err != nil {
+		return Result{}, astErr
+	}
+
+	result.Fallback = "parse failed: " + astErr.Error()
+
+	return result, nil
+}
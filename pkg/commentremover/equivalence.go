@@ -0,0 +1,225 @@
+package commentremover
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ErrNotEquivalent is returned by CheckEquivalence when an output differs
+// from its input in more than comments and layout.
+var ErrNotEquivalent = errors.New("output differs from input in more than comments")
+
+// ignoredSyntaxTypes are the types of the fields of syntax trees that
+// CheckEquivalence skips: positions, comments, and the objects and scopes
+// of identifier resolution, which point back into the tree.
+var ignoredSyntaxTypes = []reflect.Type{
+	reflect.TypeFor[token.Pos](),
+	reflect.TypeFor[*ast.CommentGroup](),
+	reflect.TypeFor[[]*ast.CommentGroup](),
+	reflect.TypeFor[*ast.Object](),
+	reflect.TypeFor[*ast.Scope](),
+}
+
+// ignoredSyntaxFields are the fields of ast.File that CheckEquivalence
+// skips: the imports, which it compares among the declarations, the Go
+// version, which comes from a //go:build line, and the identifiers that
+// resolution left unresolved.
+var ignoredSyntaxFields = []string{"Imports", "GoVersion", "Unresolved"}
+
+// CheckEquivalence checks that output, the result of removing comments from
+// input, has the syntax tree of input but for comments and positions, so
+// that the removal changed nothing else. A snippet is parsed in the shape
+// that Process finds for input, and output in the same shape. Imports are
+// compared as a set, since gofmt sorts them and StyleGofumpt groups them
+// anew, and number literals by value, since StyleGofumpt rewrites octal
+// prefixes. Removing a directive leaves the tree as it is, so StripFile
+// is what reports the directives whose removal changes a program.
+//
+// If input does not parse, the error wraps ErrParse. If output does not, or
+// the trees differ, it wraps ErrNotEquivalent and names the lines of the
+// input and the output at which they first do.
+func CheckEquivalence(input, output string) error {
+	inFset, inFile, _, shape, err := parseSnippet(input)
+	if err != nil {
+		return err
+	}
+
+	outFset := token.NewFileSet()
+
+	outFile, err := parseSourceCode(outFset, shape.wrap(output))
+	if err != nil {
+		return fmt.Errorf("%w: output does not parse: %w", ErrNotEquivalent, parseError(err, shape.prefix()))
+	}
+
+	comparer := &syntaxComparer{in: inFile, out: outFile}
+	if comparer.equal(reflect.ValueOf(inFile), reflect.ValueOf(outFile)) {
+		return nil
+	}
+
+	skip := shape.lines()
+	inLine := max(inFset.Position(comparer.in.Pos()).Line-skip, 1)
+	outLine := max(outFset.Position(comparer.out.Pos()).Line-skip, 1)
+
+	return fmt.Errorf("%w: %s differs at line %d of the input and line %d of the output",
+		ErrNotEquivalent, comparer.field, inLine, outLine)
+}
+
+// syntaxComparer compares two syntax trees for CheckEquivalence, keeping
+// track of where it is in them so that a difference can be reported.
+type syntaxComparer struct {
+	in, out ast.Node // in and out are the innermost nodes being compared, or that differ.
+	field   string   // field names the field that differs, once a difference is found.
+}
+
+// equal reports whether in and out, values of the same type in the two
+// trees, are the same but for the fields that CheckEquivalence skips.
+func (comparer *syntaxComparer) equal(in, out reflect.Value) bool {
+	switch in.Kind() {
+	case reflect.Interface:
+		if in.IsNil() || out.IsNil() {
+			return in.IsNil() == out.IsNil()
+		}
+
+		return in.Elem().Type() == out.Elem().Type() && comparer.equal(in.Elem(), out.Elem())
+	case reflect.Pointer:
+		if in.IsNil() || out.IsNil() {
+			return in.IsNil() == out.IsNil()
+		}
+
+		return comparer.equalNodes(in, out)
+	case reflect.Slice:
+		if in.Len() != out.Len() {
+			return false
+		}
+
+		for i := range in.Len() {
+			if !comparer.equal(in.Index(i), out.Index(i)) {
+				return false
+			}
+		}
+
+		return true
+	case reflect.Struct:
+		return comparer.equalFields(in, out)
+	default:
+		return in.Equal(out)
+	}
+}
+
+// equalNodes reports whether in and out, pointers of the same type, point
+// to equal values. If they are nodes with positions, a difference found in
+// them is reported at them. Number literals and import declarations are
+// compared as CheckEquivalence describes.
+func (comparer *syntaxComparer) equalNodes(in, out reflect.Value) bool {
+	inNode, isNode := in.Interface().(ast.Node)
+	if !isNode || !inNode.Pos().IsValid() {
+		return comparer.equal(in.Elem(), out.Elem())
+	}
+
+	outNode, _ := out.Interface().(ast.Node)
+	parentIn, parentOut := comparer.in, comparer.out
+	comparer.in, comparer.out = inNode, outNode
+
+	var equal bool
+
+	switch inNode := inNode.(type) {
+	case *ast.BasicLit:
+		outLit, _ := outNode.(*ast.BasicLit)
+		if equal = equalLiterals(inNode, outLit); !equal {
+			comparer.field = "BasicLit.Value"
+		}
+	case *ast.GenDecl:
+		outDecl, _ := outNode.(*ast.GenDecl)
+		if inNode.Tok == token.IMPORT && outDecl.Tok == token.IMPORT {
+			if equal = slices.Equal(importKeys(inNode), importKeys(outDecl)); !equal {
+				comparer.field = "GenDecl.Specs"
+			}
+
+			break
+		}
+
+		equal = comparer.equal(in.Elem(), out.Elem())
+	default:
+		equal = comparer.equal(in.Elem(), out.Elem())
+	}
+
+	if equal {
+		comparer.in, comparer.out = parentIn, parentOut
+	}
+
+	return equal
+}
+
+// equalFields reports whether the structs in and out have equal fields, not
+// counting those that CheckEquivalence skips.
+func (comparer *syntaxComparer) equalFields(in, out reflect.Value) bool {
+	structType := in.Type()
+
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+		if slices.Contains(ignoredSyntaxTypes, field.Type) ||
+			structType == reflect.TypeFor[ast.File]() && slices.Contains(ignoredSyntaxFields, field.Name) {
+			continue
+		}
+
+		if !comparer.equal(in.Field(i), out.Field(i)) {
+			if comparer.field == "" {
+				comparer.field = structType.Name() + "." + field.Name
+			}
+
+			return false
+		}
+	}
+
+	return true
+}
+
+// equalLiterals reports whether the literals in and out are the same, or
+// number literals of the same kind and value.
+func equalLiterals(in, out *ast.BasicLit) bool {
+	if in.Kind != out.Kind {
+		return false
+	}
+
+	switch in.Kind {
+	case token.INT, token.FLOAT, token.IMAG:
+		inValue, outValue := constant.MakeFromLiteral(in.Value, in.Kind, 0),
+			constant.MakeFromLiteral(out.Value, out.Kind, 0)
+
+		return inValue.Kind() != constant.Unknown && constant.Compare(inValue, token.EQL, outValue)
+	default:
+		return in.Value == out.Value
+	}
+}
+
+// importKeys returns the imports of decl, an import declaration, each as
+// its name, if it has one, and path, in sorted order.
+func importKeys(decl *ast.GenDecl) []string {
+	keys := make([]string, 0, len(decl.Specs))
+
+	for _, spec := range decl.Specs {
+		importSpec, isImport := spec.(*ast.ImportSpec)
+		if !isImport {
+			continue
+		}
+
+		var key strings.Builder
+
+		if importSpec.Name != nil {
+			key.WriteString(importSpec.Name.Name + " ")
+		}
+
+		key.WriteString(importSpec.Path.Value)
+		keys = append(keys, key.String())
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
@@ -0,0 +1,83 @@
+package commentremover
+
+import (
+	"errors"
+	"fmt"
+	"go/scanner"
+	"runtime/debug"
+	"strings"
+)
+
+// ErrParse is returned, wrapped with the diagnostics of the Go parser or
+// scanner, when the source is not Go code that the engine can handle. The
+// diagnostics can be had with errors.As as a scanner.ErrorList, whose
+// positions count lines and columns from the start of the input, snippet
+// or not. Errors reading or writing files wrap the error of package os
+// instead, so the two can be told apart.
+var ErrParse = errors.New("error parsing source code")
+
+// ErrEmptyInput is returned when the source holds nothing but blanks, and
+// so no Go code to remove comments from, as a paste from an empty
+// clipboard or an empty file would. It is not wrapped in ErrParse, so
+// that callers can tell missing input from input that is not Go.
+var ErrEmptyInput = errors.New("empty input")
+
+// ErrPanic is returned, as a *PanicError, when the AST pipeline panics, as
+// go/parser or go/printer may on input no one thought of.
+var ErrPanic = errors.New("internal error")
+
+// PanicError is a panic of the AST pipeline, recovered so that the caller
+// can report it and go on with other sources. It wraps ErrPanic.
+type PanicError struct {
+	Value any    // Value is the value passed to panic.
+	Stack []byte // Stack is the stack trace of the goroutine that panicked.
+}
+
+// Error returns ErrPanic along with the value passed to panic.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%v: panic: %v", ErrPanic, e.Value)
+}
+
+// Unwrap returns ErrPanic.
+func (e *PanicError) Unwrap() error {
+	return ErrPanic
+}
+
+// recoverPanic, deferred with the error that a function returns, turns a
+// panic of that function into a *PanicError in err.
+func recoverPanic(err *error) {
+	if value := recover(); value != nil {
+		*err = &PanicError{Value: value, Stack: debug.Stack()}
+	}
+}
+
+// parseError wraps err, a failure of the Go parser or scanner, with
+// ErrParse, moving the positions in it back by prefix, the text that was
+// put ahead of the input, so that they point into the input.
+func parseError(err error, prefix string) error {
+	var list scanner.ErrorList
+	if prefix == "" || !errors.As(err, &list) {
+		return fmt.Errorf("%w: %w", ErrParse, err)
+	}
+
+	skip := strings.Count(prefix, "\n")
+	indent := len(prefix) - strings.LastIndexByte(prefix, '\n') - 1
+	shifted := make(scanner.ErrorList, 0, len(list))
+
+	for _, diagnostic := range list {
+		moved := *diagnostic
+		moved.Pos.Offset = max(moved.Pos.Offset-len(prefix), 0)
+
+		if moved.Pos.Line == skip+1 {
+			moved.Pos.Column = max(moved.Pos.Column-indent, 1)
+		}
+
+		if moved.Pos.Line > skip {
+			moved.Pos.Line -= skip
+		}
+
+		shifted = append(shifted, &moved)
+	}
+
+	return fmt.Errorf("%w: %w", ErrParse, shifted)
+}
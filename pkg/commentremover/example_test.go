@@ -55,3 +55,56 @@ func ExampleRemoveComments_snippet() {
 	// 	fmt.Println("Hello, World!")
 	// }
 }
+
+// The Process function reports the engine that ran along with the result,
+// and options select the comments that are kept.
+func ExampleProcess() {
+	sourceCode := `package main
+
+// Greeting is printed by main.
+const Greeting = "Hello, World!"
+
+func main() {
+	fmt.Println(Greeting) // Print the greeting.
+}
+`
+
+	result, err := commentremover.Process(sourceCode, commentremover.KeepDoc(true))
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(result.Engine)
+	fmt.Print(result.Source)
+	// Output:
+	// ast
+	// package main
+	//
+	// // Greeting is printed by main.
+	// const Greeting = "Hello, World!"
+	//
+	// func main() {
+	// 	fmt.Println(Greeting)
+	// }
+}
+
+// With EngineScanner, comments are deleted from the original bytes, so
+// the rest of the code keeps its formatting, even where gofmt would change
+// it, and need not parse.
+func ExampleWithEngine() {
+	sourceCode := `x  :=  map[string]int{"a":1,  // The first entry.
+	"b":2}
+`
+
+	result, err := commentremover.Process(sourceCode, commentremover.WithEngine(commentremover.EngineScanner))
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(result.Engine)
+	fmt.Print(result.Source)
+	// Output:
+	// scanner
+	// x  :=  map[string]int{"a":1,
+	// 	"b":2}
+}
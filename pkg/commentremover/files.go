@@ -0,0 +1,136 @@
+package commentremover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pierow2k/nogocomments/pkg/walker"
+)
+
+// RemoveCommentsFromFile removes comments from the Go file at path like
+// Process, recording path in the result. With WriteBack(true), the result
+// is written back to the file.
+func RemoveCommentsFromFile(path string, opts ...Option) (Result, error) {
+	return RemoveCommentsFromFileContext(context.Background(), path, opts...)
+}
+
+// RemoveCommentsFromFileContext is like RemoveCommentsFromFile, but returns
+// the error of ctx instead if ctx is done before the file is read.
+func RemoveCommentsFromFileContext(ctx context.Context, path string, opts ...Option) (Result, error) {
+	if err := ctx.Err(); err != nil {
+		return Result{}, err //nolint:wrapcheck // Callers compare it to context.Canceled.
+	}
+
+	content, err := readSource(path)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	result, err := ProcessContext(ctx, content, opts...)
+	if err != nil {
+		return Result{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	result.Path = path
+
+	if newConfig(opts).writeBack && result.Source != content {
+		if err := writeBack(path, result.Source); err != nil {
+			return Result{}, err
+		}
+	}
+
+	return result, nil
+}
+
+// RemoveCommentsFromDir removes comments from the Go files under root, or
+// from root itself if it is a file, like RemoveCommentsFromFile. Files are
+// selected as the nogocomments command selects them by default: see
+// walker.Walk. A file that fails does not stop the rest; the results of
+// the others are returned along with the joined errors of the failures.
+func RemoveCommentsFromDir(root string, opts ...Option) ([]Result, error) {
+	return RemoveCommentsFromDirContext(context.Background(), root, opts...)
+}
+
+// RemoveCommentsFromDirContext is like RemoveCommentsFromDir, but stops
+// before the next file once ctx is done, adding the error of ctx to the
+// errors returned.
+func RemoveCommentsFromDirContext(ctx context.Context, root string, opts ...Option) ([]Result, error) {
+	var (
+		results []Result
+		errs    []error
+	)
+
+	for path, err := range walker.Walk([]string{root}) {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			errs = append(errs, ctxErr)
+
+			break
+		}
+
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		result, err := RemoveCommentsFromFileContext(ctx, path, opts...)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		results = append(results, result)
+	}
+
+	return results, errors.Join(errs...)
+}
+
+// readSource returns the content of the file at path byte for byte, with
+// no line endings normalized or added. The file is read
+// straight into the string rather than into a byte slice that is then
+// copied, so a large file is held in memory only once.
+func readSource(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err //nolint:wrapcheck // Callers add the path.
+	}
+
+	defer func() { _ = file.Close() }()
+
+	var content strings.Builder
+
+	if info, err := file.Stat(); err == nil {
+		content.Grow(int(info.Size()))
+	}
+
+	if _, err := io.Copy(&content, file); err != nil {
+		return "", err //nolint:wrapcheck // Callers add the path.
+	}
+
+	return content.String(), nil
+}
+
+// writeBack replaces the content of the file at path with content, keeping
+// its permissions. The string is written as it is, without a copy.
+func writeBack(path, content string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	_, err = file.WriteString(content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return nil
+}
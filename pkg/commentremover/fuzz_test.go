@@ -0,0 +1,272 @@
+package commentremover_test
+
+import (
+	"go/format"
+	"go/scanner"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/pierow2k/nogocomments/pkg/commentremover"
+)
+
+// fuzzSeeds are the seed corpus shared by the fuzz targets. They focus on
+// the shapes that exercise snippet wrapping: missing package clauses,
+// comment-only prefixes, and text that looks like the injected prefix.
+var fuzzSeeds = []string{
+	"package main\n\n// doc\nfunc main() {}\n",
+	"// comment\nfunc f() {}\n",
+	"func f() {\n\t// inner\n\tx := 1\n\t_ = x\n}\n",
+	"/* block */ var x = 1\n",
+	"var s = `package main\n`\n",
+	"const c = \"package main\\n\" // trailing\n",
+	"type T struct {\n\tA int // a\n}\n",
+	"// only a comment\n",
+	"package p; var x = 1/**/+2",
+	"package main\r\n\r\n// crlf\r\nvar x = 1\r\n",
+	"for {\n\t// statement\n\tbreak\n}\n",
+	"x := `\n\tq` // raw\n",
+}
+
+// stripAll are the options that make every comment removable, so the fuzz
+// targets can assert that no comments survive.
+var stripAll = []commentremover.Option{
+	commentremover.KeepBuildConstraints(false),
+	commentremover.KeepGenerate(false),
+	commentremover.KeepEmbed(false),
+	commentremover.KeepCgoPreamble(false),
+	commentremover.KeepCgoExports(false),
+	commentremover.KeepCompilerDirectives(false),
+	commentremover.KeepSyscallDirectives(false),
+	commentremover.KeepLineDirectives(false),
+	commentremover.KeepLicenseHeader(false),
+	commentremover.KeepSPDX(false),
+}
+
+// tokenKinds returns the non-comment tokens of src, or false if src does
+// not tokenize cleanly. Semicolons are recorded by kind only, since an
+// automatically inserted semicolon and an explicit one are equivalent.
+func tokenKinds(src string) ([]string, bool) {
+	var (
+		sourceScanner scanner.Scanner
+		errs          scanner.ErrorList
+		tokens        []string
+	)
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	sourceScanner.Init(file, []byte(src), errs.Add, 0)
+
+	for {
+		_, tok, lit := sourceScanner.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		if tok == token.SEMICOLON {
+			lit = ""
+		}
+
+		tokens = append(tokens, tok.String()+" "+lit)
+	}
+
+	return tokens, errs.Len() == 0
+}
+
+// hasComments reports whether src contains any comment tokens.
+func hasComments(src string) bool {
+	var sourceScanner scanner.Scanner
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	sourceScanner.Init(file, []byte(src), nil, scanner.ScanComments)
+
+	for {
+		_, tok, _ := sourceScanner.Scan()
+		switch tok {
+		case token.EOF:
+			return false
+		case token.COMMENT:
+			return true
+		default:
+		}
+	}
+}
+
+// FuzzRemoveComments checks the AST engine invariants: the output holds no
+// comments, a removal never injects or loses the package clause nor leaves
+// a gap where the injected one was, and removing comments from the output
+// is a no-op.
+func FuzzRemoveComments(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		got, err := commentremover.RemoveComments(input, stripAll...)
+		if err != nil {
+			return
+		}
+
+		if hasComments(got) {
+			t.Fatalf("output contains comments: %q", got)
+		}
+
+		inputTokens, _ := tokenKinds(input)
+		gotTokens, _ := tokenKinds(got)
+
+		inputHasPackage := len(inputTokens) > 0 && inputTokens[0] == "package "
+		gotHasPackage := len(gotTokens) > 0 && gotTokens[0] == "package "
+
+		if inputHasPackage != gotHasPackage {
+			t.Fatalf("package clause changed: input %q, output %q", input, got)
+		}
+
+		if !inputHasPackage && strings.HasPrefix(got, "\n") {
+			t.Fatalf("snippet output starts with a blank line: %q", got)
+		}
+
+		// Input of comments alone leaves nothing to remove comments from.
+		if strings.TrimSpace(got) == "" {
+			return
+		}
+
+		again, err := commentremover.RemoveComments(got, stripAll...)
+		if err != nil {
+			t.Fatalf("output does not parse: %v\n%q", err, got)
+		}
+
+		if again != got {
+			t.Fatalf("not idempotent:\nfirst  %q\nsecond %q", got, again)
+		}
+	})
+}
+
+// FuzzGofmtClean checks that the AST engine output for a complete file is
+// left unchanged by gofmt, in either style. Final line breaks are not
+// matched to the input, since gofmt always adds one.
+func FuzzGofmtClean(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		for _, style := range []commentremover.Style{commentremover.StyleGofmt, commentremover.StyleGofumpt} {
+			opts := append([]commentremover.Option{
+				commentremover.WithEngine(commentremover.EngineAST), commentremover.WithStyle(style),
+				commentremover.WithLineEnding(commentremover.LineEndingLF), commentremover.MatchFinalNewline(false),
+			}, stripAll...)
+
+			got, err := commentremover.RemoveComments(input, opts...)
+			if err != nil || !strings.HasPrefix(got, "package ") {
+				return
+			}
+
+			formatted, err := format.Source([]byte(got))
+			if err != nil {
+				t.Fatalf("%s output does not parse: %v\n%q", style, err, got)
+			}
+
+			if string(formatted) != got {
+				t.Fatalf("%s output is not gofmt-clean:\noutput %q\ngofmt  %q", style, got, formatted)
+			}
+		}
+	})
+}
+
+// FuzzScannerEngine checks that the scanner engine removes every comment
+// and leaves the token stream of the remaining code unchanged.
+func FuzzScannerEngine(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		inputTokens, ok := tokenKinds(input)
+		if !ok {
+			return
+		}
+
+		opts := append([]commentremover.Option{commentremover.WithEngine(commentremover.EngineScanner)}, stripAll...)
+
+		got, err := commentremover.RemoveComments(input, opts...)
+		if err != nil {
+			return
+		}
+
+		if hasComments(got) {
+			t.Fatalf("output contains comments: %q", got)
+		}
+
+		gotTokens, _ := tokenKinds(got)
+		if strings.Join(gotTokens, "\n") != strings.Join(inputTokens, "\n") {
+			t.Fatalf("token stream changed:\ninput  %q\noutput %q", input, got)
+		}
+	})
+}
+
+// FuzzMinimalDiff checks that MinimalDiff removes every comment from input
+// that parses and leaves the token stream of the remaining code unchanged.
+func FuzzMinimalDiff(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		inputTokens, ok := tokenKinds(input)
+		if !ok {
+			return
+		}
+
+		opts := append([]commentremover.Option{
+			commentremover.WithEngine(commentremover.EngineAST), commentremover.MinimalDiff(true),
+		}, stripAll...)
+
+		got, err := commentremover.RemoveComments(input, opts...)
+		if err != nil {
+			return
+		}
+
+		if hasComments(got) {
+			t.Fatalf("output contains comments: %q", got)
+		}
+
+		gotTokens, _ := tokenKinds(got)
+		if strings.Join(gotTokens, "\n") != strings.Join(inputTokens, "\n") {
+			t.Fatalf("token stream changed:\ninput  %q\noutput %q", input, got)
+		}
+	})
+}
+
+// FuzzPreserveLines checks that PreserveLines keeps the number of lines and
+// the token stream of the remaining code.
+func FuzzPreserveLines(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		inputTokens, ok := tokenKinds(input)
+		if !ok {
+			return
+		}
+
+		opts := append([]commentremover.Option{
+			commentremover.WithEngine(commentremover.EngineScanner), commentremover.PreserveLines(true),
+		}, stripAll...)
+
+		got, err := commentremover.RemoveComments(input, opts...)
+		if err != nil {
+			return
+		}
+
+		if strings.Count(got, "\n") != strings.Count(input, "\n") {
+			t.Fatalf("line count changed:\ninput  %q\noutput %q", input, got)
+		}
+
+		gotTokens, _ := tokenKinds(got)
+		if strings.Join(gotTokens, "\n") != strings.Join(inputTokens, "\n") {
+			t.Fatalf("token stream changed:\ninput  %q\noutput %q", input, got)
+		}
+	})
+}
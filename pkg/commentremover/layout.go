@@ -0,0 +1,189 @@
+package commentremover
+
+import (
+	"go/token"
+	"strings"
+)
+
+// byteOrderMark is the UTF-8 encoding of U+FEFF, which some editors write
+// at the start of a file.
+const byteOrderMark = "\uFEFF"
+
+// LineEnding selects the line breaks of the output.
+type LineEnding int
+
+const (
+	// LineEndingPreserve keeps the line breaks of the input. Where the
+	// output is reprinted, as by EngineAST, every line ends in the break
+	// that ends most lines of the input.
+	LineEndingPreserve LineEnding = iota
+
+	// LineEndingLF ends every line of the output in "\n".
+	LineEndingLF
+
+	// LineEndingCRLF ends every line of the output in "\r\n".
+	LineEndingCRLF
+)
+
+// String returns the lower-case name of the line ending.
+func (e LineEnding) String() string {
+	switch e {
+	case LineEndingPreserve:
+		return "preserve"
+	case LineEndingLF:
+		return "lf"
+	case LineEndingCRLF:
+		return "crlf"
+	default:
+		return "unknown"
+	}
+}
+
+// Indent selects how EngineAST indents the code it prints.
+type Indent int
+
+const (
+	// IndentTabs indents with tabs, as go/printer does by default.
+	IndentTabs Indent = iota
+
+	// IndentSpaces indents and aligns with spaces, a tab width per level.
+	IndentSpaces
+)
+
+// defaultTabWidth is the tab width that go/printer and gofmt assume.
+const defaultTabWidth = 8
+
+// String returns the lower-case name of the indentation.
+func (i Indent) String() string {
+	switch i {
+	case IndentTabs:
+		return "tabs"
+	case IndentSpaces:
+		return "spaces"
+	default:
+		return "unknown"
+	}
+}
+
+// mostlyCRLF reports whether more lines of src end in "\r\n" than in a bare
+// "\n".
+func mostlyCRLF(src string) bool {
+	crlf := strings.Count(src, "\r\n")
+
+	return crlf > strings.Count(src, "\n")-crlf
+}
+
+// convertLineEndings ends every line of src in "\r\n" if crlf is set, or in
+// "\n" otherwise. A carriage return not followed by a newline is kept.
+// Raw string literals are converted too, which leaves their values
+// unchanged, since Go discards carriage returns in raw strings.
+func convertLineEndings(src string, crlf bool) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	if crlf {
+		src = strings.ReplaceAll(src, "\n", "\r\n")
+	}
+
+	return src
+}
+
+// squeezeBlankLines collapses each run of blank lines in src to a single
+// empty line, keeping the line ending of the first. Lines inside raw string
+// literals are left alone, since they are part of the program's data. A
+// line is blank if it holds nothing but spaces, tabs, and carriage returns.
+func squeezeBlankLines(src string) string {
+	literals := rawStrings(src)
+
+	var out strings.Builder
+
+	out.Grow(len(src))
+
+	blank, next := false, 0
+
+	for offset := 0; offset < len(src); {
+		for next < len(literals) && literals[next][1] <= offset {
+			next++
+		}
+
+		inLiteral := next < len(literals) && literals[next][0] <= offset
+
+		end := strings.IndexByte(src[offset:], '\n') + 1
+		if end == 0 {
+			end = len(src) - offset
+		}
+
+		line := src[offset : offset+end]
+		content := strings.TrimRight(line, "\r\n")
+
+		switch {
+		case inLiteral || strings.Trim(content, " \t\r") != "":
+			out.WriteString(line)
+
+			blank = false
+		case !blank:
+			out.WriteString(line[len(content):])
+
+			blank = true
+		}
+
+		offset += end
+	}
+
+	return out.String()
+}
+
+// rawStrings returns the byte ranges of the contents of the raw string
+// literals in src, from just past the opening backquote to just past the
+// closing one, in order. Source that fails to tokenize is scanned as far as
+// possible.
+func rawStrings(src string) [][2]int {
+	var literals [][2]int
+
+	scan := newTokenScanner(src, 0)
+	defer scan.release()
+
+	for {
+		pos, tok, lit := scan.Scan()
+		if tok == token.EOF {
+			return literals
+		}
+
+		if tok == token.STRING && strings.HasPrefix(lit, "`") {
+			start := scan.file.Offset(pos)
+			end := start + 1 + strings.IndexByte(src[start+1:], '`') + 1
+			literals = append(literals, [2]int{start + 1, end})
+		}
+	}
+}
+
+// matchFinalNewline ends src, the output for input, in a line break exactly
+// if input ends in one, adding the break that ends input or dropping the
+// one that ends src. Empty output is left empty.
+func matchFinalNewline(src, input string) string {
+	switch {
+	case src == "":
+		return src
+	case strings.HasSuffix(input, "\n") && !strings.HasSuffix(src, "\n"):
+		if strings.HasSuffix(input, "\r\n") {
+			return src + "\r\n"
+		}
+
+		return src + "\n"
+	case !strings.HasSuffix(input, "\n"):
+		src = strings.TrimSuffix(src, "\n")
+
+		return strings.TrimSuffix(src, "\r")
+	default:
+		return src
+	}
+}
+
+// countLines returns the number of lines of src, counting a last line that
+// lacks a line break.
+func countLines(src string) int {
+	lines := strings.Count(src, "\n")
+	if src != "" && !strings.HasSuffix(src, "\n") {
+		lines++
+	}
+
+	return lines
+}
@@ -0,0 +1,143 @@
+package commentremover
+
+import (
+	"go/scanner"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// LicenseHeader is the copyright or license header at the start of a
+// source, as found by FindLicenseHeader.
+type LicenseHeader struct {
+	FirstLine int    `json:"firstLine"` // FirstLine is the 1-based line of the source the header begins on.
+	LastLine  int    `json:"lastLine"`  // LastLine is the line the header ends on.
+	License   string `json:"license"`   // License names the license of the header, as listed at FindLicenseHeader.
+	Text      string `json:"text"`      // Text holds the comments of the header, including their markers.
+}
+
+// licenseNames are the names that FindLicenseHeader gives the licenses
+// whose wording it recognizes, in the order they are tried.
+var licenseNames = []struct {
+	pattern *regexp.Regexp
+	name    string
+}{
+	{regexp.MustCompile(`(?i)apache license,? version 2\.0|apache\.org/licenses/license-2\.0`), "Apache-2.0"},
+	{regexp.MustCompile(`(?i)mozilla public license,? v(ersion|\.) ?2\.0`), "MPL-2.0"},
+	{regexp.MustCompile(`(?i)gnu affero general public license`), "AGPL"},
+	{regexp.MustCompile(`(?i)gnu lesser general public license`), "LGPL"},
+	{regexp.MustCompile(`(?i)gnu general public license`), "GPL"},
+	{regexp.MustCompile(`(?i)permission is hereby granted,? free of charge`), "MIT"},
+	{regexp.MustCompile(`(?i)permission to use, copy, modify, and(/or)? distribute this software`), "ISC"},
+	{regexp.MustCompile(`(?i)neither the name of`), "BSD-3-Clause"},
+	{regexp.MustCompile(`(?i)redistribution and use in source and binary forms`), "BSD-2-Clause"},
+	{regexp.MustCompile(`(?i)governed by an? mit-style license`), "MIT-style"},
+	{regexp.MustCompile(`(?i)governed by an? bsd-style license`), "BSD-style"},
+}
+
+// spdxPattern matches an SPDX-License-Identifier line and captures the
+// license expression it gives.
+var spdxPattern = regexp.MustCompile(`(?i)spdx-license-identifier:\s*([^\s*]+(?:\s+(?:and|or|with)\s+[^\s*]+)*)`)
+
+// licensePattern matches the phrases that mark a comment as a copyright or
+// license notice: a copyright line with a year or symbol, and the wording of
+// common headers such as those of the Apache, BSD, GPL, and MIT licenses
+// and of the Go project itself.
+var licensePattern = regexp.MustCompile(`(?i)copyright\s+(\(c\)|©|\d{4})|` +
+	`licensed under|use of this source code is governed by|permission is hereby granted|` +
+	`redistribution and use in source and binary forms|general public license|` +
+	`all rights reserved|spdx-license-identifier:`)
+
+// isLicenseHeader reports whether the comments texts, which form the first
+// comment group of a file, read like a copyright or license header.
+func isLicenseHeader(texts []string) bool {
+	return licensePattern.MatchString(strings.Join(texts, "\n"))
+}
+
+// leadingGroup returns the number of spans, starting from the first, that
+// form the first comment group in the header of a file. Like go/parser, it
+// groups comments separated by at most one line break.
+func leadingGroup(spans []commentSpan) int {
+	n := 0
+
+	for n < len(spans) && spans[n].inHeader {
+		if n > 0 && spans[n].line > endLine(spans[n-1].comment)+1 {
+			break
+		}
+
+		n++
+	}
+
+	return n
+}
+
+// endLine returns the line on which c ends.
+func endLine(c comment) int {
+	return c.line + strings.Count(c.text, "\n")
+}
+
+// FindLicenseHeader returns the license header of src, the first comment
+// group ahead of the package clause if it reads like a copyright or license
+// notice, the header that KeepLicenseHeader keeps. Its license is named by
+// the expression of an SPDX-License-Identifier line, or else by the
+// wording of the header: Apache-2.0, MPL-2.0, AGPL, LGPL, GPL, MIT, ISC,
+// BSD-3-Clause, or BSD-2-Clause for the licenses themselves, and MIT-style
+// or BSD-style for a notice that points to a LICENSE file, as that of the
+// Go project does. It is empty for a bare copyright notice. src is scanned
+// rather than parsed, so the header of code with syntax errors is found
+// all the same. The boolean reports whether src has a header.
+func FindLicenseHeader(src string) (LicenseHeader, bool) {
+	scan := newTokenScanner(src, scanner.ScanComments)
+	defer scan.release()
+
+	// A syntax error later on does not change the header.
+	spans, _ := scanComments(scan)
+
+	var texts []string
+
+	for _, span := range spans {
+		if !span.license {
+			break
+		}
+
+		texts = append(texts, span.text)
+	}
+
+	if len(texts) == 0 {
+		return LicenseHeader{}, false
+	}
+
+	text := strings.Join(texts, "\n")
+
+	return LicenseHeader{
+		FirstLine: spans[0].line,
+		LastLine:  endLine(spans[len(texts)-1].comment),
+		License:   licenseName(text),
+		Text:      text,
+	}, true
+}
+
+// commentMarkers replaces the markers of comments with spaces.
+var commentMarkers = strings.NewReplacer("//", " ", "/*", " ", "*/", " ")
+
+// licenseName returns the name of the license of header, the text of a
+// license header, as FindLicenseHeader describes, or "" if not recognized.
+// The header is matched as its words, without the comment markers and line
+// breaks that split its sentences.
+func licenseName(header string) string {
+	words := strings.Fields(commentMarkers.Replace(header))
+	words = slices.DeleteFunc(words, func(word string) bool { return word == "*" })
+	header = strings.Join(words, " ")
+
+	if match := spdxPattern.FindStringSubmatch(header); match != nil {
+		return match[1]
+	}
+
+	for _, license := range licenseNames {
+		if license.pattern.MatchString(header) {
+			return license.name
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,532 @@
+package commentremover
+
+import (
+	"go/ast"
+	"regexp"
+)
+
+// config holds the settings assembled from the Options passed to Process.
+type config struct {
+	engine               Engine // engine is the pipeline used to remove comments.
+	keepBuildConstraints bool   // keepBuildConstraints retains //go:build and // +build lines.
+	keepGenerate         bool   // keepGenerate retains //go:generate directives.
+	keepEmbed            bool   // keepEmbed retains //go:embed directives on variable declarations.
+	keepCgoPreamble      bool   // keepCgoPreamble retains the comment preceding import "C".
+	keepCgoExports       bool   // keepCgoExports retains //export directives in files that import "C".
+
+	keepCompilerDirectives bool // keepCompilerDirectives retains //go: pragmas such as //go:noinline.
+	keepSyscallDirectives  bool // keepSyscallDirectives retains //sys and //sysnb directives.
+	keepLineDirectives     bool // keepLineDirectives retains //line and /*line*/ directives.
+	keepNolint             bool // keepNolint retains //nolint lint-suppression comments.
+	keepDoc                bool // keepDoc retains the doc comments of exported declarations.
+	keepPackageDoc         bool // keepPackageDoc retains the doc comment of the package clause.
+	keepDeprecated         bool // keepDeprecated retains paragraphs starting with "Deprecated:".
+	keepAnnotations        bool // keepAnnotations retains comments starting with an @ marker.
+	keepTodos              bool // keepTodos retains comments starting with a TODO, FIXME, or HACK marker.
+	onlyTodos              bool // onlyTodos retains every comment not starting with a TODO, FIXME, or HACK marker.
+	keepLineComments       bool // keepLineComments retains every // comment.
+	keepBlockComments      bool // keepBlockComments retains every /* */ comment.
+	keepFieldComments      bool // keepFieldComments retains end-of-line comments of struct fields and constants.
+	keepLicense            bool // keepLicense retains a leading copyright or license header.
+	keepSPDX               bool // keepSPDX retains SPDX-License-Identifier comments.
+	onlyFuncBodies         bool // onlyFuncBodies retains every comment outside function bodies.
+	strictUTF8             bool // strictUTF8 rejects source that is not valid UTF-8.
+	bestEffort             bool // bestEffort cleans source that fails to parse or tokenize instead of failing.
+	recoverPartial         bool // recoverPartial cleans the declarations that parse, leaving the rest unchanged.
+	writeBack              bool // writeBack makes the file and directory functions write results back.
+
+	minimalDiff   bool       // minimalDiff splices comments out of the source instead of printing the AST.
+	squeezeBlank  bool       // squeezeBlank collapses runs of blank lines in the output.
+	preserveLines bool       // preserveLines keeps every input line at the same line number in the output.
+	lineEnding    LineEnding // lineEnding selects the line breaks of the output.
+	keepBOM       bool       // keepBOM restores a leading byte order mark of the input in the output.
+	indent        Indent     // indent selects how printed code is indented.
+	tabWidth      int        // tabWidth is the width of a tab when printed code is aligned.
+	style         Style      // style selects the formatting rules of printed code.
+	finalNewline  bool       // finalNewline ends the output in a line break exactly if the input ends in one.
+
+	keepPatterns   []*regexp.Regexp // keepPatterns retains comments whose text matches any of them.
+	removePatterns []*regexp.Regexp // removePatterns, if set, retains comments matching none of them.
+	lineRanges     []lineRange      // lineRanges, if set, retains comments beginning outside all of them.
+	removeFilters  []LineFilter     // removeFilters retains comments that any of them rejects.
+	commentFilters []CommentFilter  // commentFilters retains comments that any of them rejects.
+}
+
+// Option configures how comments are removed.
+type Option func(*config)
+
+// newConfig returns the default configuration with opts applied in order.
+func newConfig(opts []Option) config {
+	cfg := config{
+		engine:               EngineAST,
+		keepBuildConstraints: true,
+		keepGenerate:         true,
+		keepEmbed:            true,
+		keepCgoPreamble:      true,
+		keepCgoExports:       true,
+
+		keepCompilerDirectives: true,
+		keepSyscallDirectives:  true,
+		keepLineDirectives:     true,
+		keepLicense:            true,
+		keepSPDX:               true,
+		keepBOM:                true,
+		tabWidth:               defaultTabWidth,
+		finalNewline:           true,
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return cfg
+}
+
+// WithEngine selects the pipeline used to remove comments. The default is
+// EngineAST.
+func WithEngine(engine Engine) Option {
+	return func(cfg *config) {
+		cfg.engine = engine
+	}
+}
+
+// BestEffort controls whether source that does not parse is cleaned as far
+// as possible rather than rejected, as for incomplete snippets or code in
+// the middle of an edit. EngineAST then falls back to EngineScanner as
+// EngineAuto does, and EngineScanner removes the comments it finds even if
+// it cannot tokenize the whole source, as with an unterminated string
+// literal, leaving a block comment that is never closed in place. It is off
+// by default.
+func BestEffort(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.bestEffort = enabled
+	}
+}
+
+// RecoverPartial controls whether the AST pipeline removes the comments
+// from the declarations of a file or snippet with syntax errors that parse
+// on their own, rather than rejecting the whole source. The declarations
+// holding the errors are left unchanged and listed in Result.Unparsed, and
+// the source is not reprinted but spliced as with MinimalDiff. Combined
+// with BestEffort, the recovery is tried before falling back to
+// EngineScanner. It is off by default.
+func RecoverPartial(enabled bool) Option {
+	return func(cfg *config) {
+		cfg.recoverPartial = enabled
+	}
+}
+
+// KeepBuildConstraints controls whether //go:build and // +build lines in
+// the file header are retained. They are kept by default, since removing
+// them silently changes which platforms the file compiles for.
+func KeepBuildConstraints(keep bool) Option {
+	return func(cfg *config) {
+		cfg.keepBuildConstraints = keep
+	}
+}
+
+// KeepGenerate controls whether //go:generate directives are retained.
+// They are kept by default so that go generate still works on the output.
+func KeepGenerate(keep bool) Option {
+	return func(cfg *config) {
+		cfg.keepGenerate = keep
+	}
+}
+
+// KeepEmbed controls whether //go:embed directives on variable declarations
+// are retained. They are kept by default, since without them the output
+// either fails to compile or silently embeds nothing.
+func KeepEmbed(keep bool) Option {
+	return func(cfg *config) {
+		cfg.keepEmbed = keep
+	}
+}
+
+// KeepCgoPreamble controls whether the cgo preamble, the comment that
+// immediately precedes import "C", is retained. It is kept by default,
+// since it holds the C declarations the file compiles against.
+func KeepCgoPreamble(keep bool) Option {
+	return func(cfg *config) {
+		cfg.keepCgoPreamble = keep
+	}
+}
+
+// KeepCgoExports controls whether //export directives are retained in
+// files that import "C". They are kept by default, since without them the
+// functions are no longer exported to C.
+func KeepCgoExports(keep bool) Option {
+	return func(cfg *config) {
+		cfg.keepCgoExports = keep
+	}
+}
+
+// KeepCompilerDirectives controls whether //go: pragmas such as
+// //go:noinline, //go:linkname, //go:noescape, and //go:nosplit are
+// retained. They are kept by default, since removing them changes the
+// compiled program. Build constraints, //go:generate, and //go:embed are
+// controlled by their own options.
+func KeepCompilerDirectives(keep bool) Option {
+	return func(cfg *config) {
+		cfg.keepCompilerDirectives = keep
+	}
+}
+
+// KeepSyscallDirectives controls whether //sys and //sysnb directives are
+// retained. They are kept by default, since packages following the
+// golang.org/x/sys conventions generate their syscall wrappers from them.
+func KeepSyscallDirectives(keep bool) Option {
+	return func(cfg *config) {
+		cfg.keepSyscallDirectives = keep
+	}
+}
+
+// KeepLineDirectives controls whether //line and /*line*/ directives are
+// retained. They are kept by default, since they set the positions that the
+// compiler reports for generated code.
+func KeepLineDirectives(keep bool) Option {
+	return func(cfg *config) {
+		cfg.keepLineDirectives = keep
+	}
+}
+
+// KeepDirectives sets every directive option at once: build constraints,
+// //go:generate, //go:embed, the cgo preamble, //export, compiler
+// directives such as //go:linkname, //sys, and //line. All of them are kept
+// by default, so the output builds and behaves like the input;
+// KeepDirectives(false) removes them all. Options given after it adjust
+// individual directives.
+func KeepDirectives(keep bool) Option {
+	return func(cfg *config) {
+		cfg.keepBuildConstraints = keep
+		cfg.keepGenerate = keep
+		cfg.keepEmbed = keep
+		cfg.keepCgoPreamble = keep
+		cfg.keepCgoExports = keep
+		cfg.keepCompilerDirectives = keep
+		cfg.keepSyscallDirectives = keep
+		cfg.keepLineDirectives = keep
+	}
+}
+
+// KeepNolint controls whether //nolint lint-suppression comments are
+// retained, so that linting the output does not report findings that were
+// deliberately suppressed. It is off by default.
+func KeepNolint(keep bool) Option {
+	return func(cfg *config) {
+		cfg.keepNolint = keep
+	}
+}
+
+// KeepDoc controls whether the doc comments of exported top-level
+// declarations are retained: types, functions, constants, variables, and
+// methods of exported types. The output then still documents its public
+// API for go doc, while implementation comments are removed. It is off by
+// default.
+func KeepDoc(keep bool) Option {
+	return func(cfg *config) {
+		cfg.keepDoc = keep
+	}
+}
+
+// KeepPackageDoc controls whether the package doc comment, the comment
+// immediately preceding the package clause, is retained. This keeps the
+// package overview, such as the contents of a doc.go file, while all other
+// comments are removed. It is off by default.
+func KeepPackageDoc(keep bool) Option {
+	return func(cfg *config) {
+		cfg.keepPackageDoc = keep
+	}
+}
+
+// KeepDeprecated controls whether deprecation notices, the comment
+// paragraphs starting with "Deprecated:" by godoc convention, are retained
+// while the rest of their comments is removed. This keeps API deprecations
+// visible to consumers, including those of struct fields and unexported
+// declarations that KeepDoc does not cover. It is off by default.
+func KeepDeprecated(keep bool) Option {
+	return func(cfg *config) {
+		cfg.keepDeprecated = keep
+	}
+}
+
+// KeepAnnotations controls whether annotations for code generators are
+// retained: comments beginning with an @ marker, such as // @Router and
+// // @Summary for swaggo. Generators that parse them silently produce
+// different output from stripped code. It is off by default.
+func KeepAnnotations(keep bool) Option {
+	return func(cfg *config) {
+		cfg.keepAnnotations = keep
+	}
+}
+
+// KeepTodos controls whether comments beginning with a task marker, such
+// as "// TODO: ..." or "/* FIXME(name) ... */", are retained. The markers
+// are TODO, FIXME, and HACK. It is off by default.
+func KeepTodos(keep bool) Option {
+	return func(cfg *config) {
+		cfg.keepTodos = keep
+	}
+}
+
+// OnlyTodos controls whether removal is restricted to the comments
+// beginning with a task marker, as recognized by KeepTodos. Combined with
+// RemovePattern, the comments matching either are removed. It is off by
+// default.
+func OnlyTodos(only bool) Option {
+	return func(cfg *config) {
+		cfg.onlyTodos = only
+	}
+}
+
+// KeepLineComments controls whether all // line comments are retained, so
+// that only /* */ block comments are removed. It is off by default.
+func KeepLineComments(keep bool) Option {
+	return func(cfg *config) {
+		cfg.keepLineComments = keep
+	}
+}
+
+// KeepBlockComments controls whether all /* */ block comments are
+// retained, so that only // line comments are removed. It is off by
+// default.
+func KeepBlockComments(keep bool) Option {
+	return func(cfg *config) {
+		cfg.keepBlockComments = keep
+	}
+}
+
+// KeepFieldComments controls whether the end-of-line comments of struct
+// fields and constant declarations are retained. Such comments often record
+// units, encoding semantics, or protocol notes. It is off by default.
+func KeepFieldComments(keep bool) Option {
+	return func(cfg *config) {
+		cfg.keepFieldComments = keep
+	}
+}
+
+// KeepLicenseHeader controls whether a copyright or license header is
+// retained. The header is recognized heuristically: it is the first comment
+// group of the file, placed before any code, and it carries a copyright line
+// with a year or symbol, an SPDX identifier, or the wording of a common
+// license such as Apache, BSD, GPL, or MIT. It is kept by default, since
+// stripping legal notices from files often violates their licenses.
+func KeepLicenseHeader(keep bool) Option {
+	return func(cfg *config) {
+		cfg.keepLicense = keep
+	}
+}
+
+// KeepSPDX controls whether SPDX-License-Identifier comments are retained
+// wherever they appear, independently of KeepLicenseHeader. They are kept
+// by default, since license scanners look for them.
+func KeepSPDX(keep bool) Option {
+	return func(cfg *config) {
+		cfg.keepSPDX = keep
+	}
+}
+
+// KeepPattern retains every comment whose text, including its // or /* */
+// markers, matches any of patterns. It serves as an escape hatch for
+// directives and annotations that have no option of their own. Patterns
+// accumulate across calls.
+func KeepPattern(patterns ...*regexp.Regexp) Option {
+	return func(cfg *config) {
+		cfg.keepPatterns = append(cfg.keepPatterns, patterns...)
+	}
+}
+
+// RemovePattern restricts removal to the comments whose text, including
+// its // or /* */ markers, matches any of patterns; all other comments are
+// kept. This is the inverse of KeepPattern, such as for deleting "DEBUG:"
+// notes before a release. Comments retained by the other options, such as
+// build constraints, are kept even if they match. Patterns accumulate
+// across calls.
+func RemovePattern(patterns ...*regexp.Regexp) Option {
+	return func(cfg *config) {
+		cfg.removePatterns = append(cfg.removePatterns, patterns...)
+	}
+}
+
+// OnlyFuncBodies controls whether removal is restricted to the comments
+// inside the bodies of functions, methods, and function literals. The
+// comments of top-level declarations, such as their documentation, are
+// kept, while implementation comments are removed. It is off by default.
+func OnlyFuncBodies(only bool) Option {
+	return func(cfg *config) {
+		cfg.onlyFuncBodies = only
+	}
+}
+
+// lineRange is the range of lines first through last, inclusive.
+type lineRange struct {
+	first, last int
+}
+
+// LineRange restricts removal to the comments that begin on lines first
+// through last, inclusive, counting from 1. All other comments are kept.
+// This suits stripping a selection in an editor; use EngineScanner to leave
+// the code outside the range untouched as well. Ranges accumulate across
+// calls, and a comment is removed if it begins within any of them. By
+// default, comments on every line are removed.
+func LineRange(first, last int) Option {
+	return func(cfg *config) {
+		cfg.lineRanges = append(cfg.lineRanges, lineRange{first: first, last: last})
+	}
+}
+
+// LineFilter reports whether a comment spanning lines first through last,
+// inclusive and counting from 1, may be removed.
+type LineFilter func(first, last int) bool
+
+// RemoveIf restricts removal to the comments that filter accepts; all other
+// comments are kept. It lets callers select comments by data from outside
+// the source, such as the commits that last changed each line, or by asking
+// a user. Filters are consulted last, in source order, and only for the
+// comments that no other option keeps. Filters accumulate across calls, and
+// a comment is removed only if all of them accept it.
+func RemoveIf(filter LineFilter) Option {
+	return func(cfg *config) {
+		cfg.removeFilters = append(cfg.removeFilters, filter)
+	}
+}
+
+// CommentFilter reports whether the comment c, a comment of group, may be
+// removed. owner is the node that c belongs to: the declaration, spec, or
+// field that group documents or follows, or else the innermost node
+// enclosing c, such as the block of a function body or the file.
+type CommentFilter func(c *ast.Comment, group *ast.CommentGroup, owner ast.Node) bool
+
+// RemoveIfComment restricts removal to the comments that filter accepts,
+// like RemoveIf, but decides on the parsed comment and its place in the
+// AST, for policies the other options cannot express, such as
+// company-specific annotations on certain declarations. Filters are
+// consulted after those of RemoveIf, and only for the comments that they
+// and the other options leave to be removed. EngineScanner parses no AST,
+// so it passes c with its text only, and group and owner as nil.
+func RemoveIfComment(filter CommentFilter) Option {
+	return func(cfg *config) {
+		cfg.commentFilters = append(cfg.commentFilters, filter)
+	}
+}
+
+// MinimalDiff controls whether EngineAST, which decides which comments to
+// remove on the parsed AST, deletes them from the original bytes as
+// EngineScanner does, rather than printing the AST with go/printer. The
+// output then differs from the input only where comments were removed,
+// instead of being reformatted throughout. It is off by default.
+func MinimalDiff(minimal bool) Option {
+	return func(cfg *config) {
+		cfg.minimalDiff = minimal
+	}
+}
+
+// SqueezeBlank controls whether each run of blank lines in the output,
+// such as those left where comments were removed, is collapsed to a single
+// empty line. Raw string literals are left intact. It is off by default.
+func SqueezeBlank(squeeze bool) Option {
+	return func(cfg *config) {
+		cfg.squeezeBlank = squeeze
+	}
+}
+
+// PreserveLines controls whether every line of the input keeps its line
+// number in the output: a line that held only comments is left empty, and
+// a block comment spanning lines is replaced by as many line breaks. Stack
+// traces, coverage data, and review references then remain valid against
+// the output. Like MinimalDiff, it deletes comments from the original
+// bytes rather than printing the AST, and it overrides SqueezeBlank. It is
+// off by default.
+func PreserveLines(preserve bool) Option {
+	return func(cfg *config) {
+		cfg.preserveLines = preserve
+	}
+}
+
+// WithLineEnding selects the line breaks of the output. The default is
+// LineEndingPreserve.
+func WithLineEnding(ending LineEnding) Option {
+	return func(cfg *config) {
+		cfg.lineEnding = ending
+	}
+}
+
+// KeepBOM controls whether a UTF-8 byte order mark at the start of the
+// input is restored at the start of the output. The mark is set aside
+// before the input is parsed either way, so that snippets beginning with
+// one are handled. It is kept by default.
+func KeepBOM(keep bool) Option {
+	return func(cfg *config) {
+		cfg.keepBOM = keep
+	}
+}
+
+// WithIndent selects how EngineAST indents the code it prints. It has no
+// effect on EngineScanner, MinimalDiff, or PreserveLines, which keep the
+// indentation of the input. The default is IndentTabs, which together with
+// the default TabWidth prints exactly as gofmt does.
+func WithIndent(indent Indent) Option {
+	return func(cfg *config) {
+		cfg.indent = indent
+	}
+}
+
+// TabWidth sets the width that EngineAST assumes for a tab when it aligns
+// the code it prints, and the number of spaces per level under
+// IndentSpaces. Widths below 1 select the default of 8.
+func TabWidth(width int) Option {
+	return func(cfg *config) {
+		cfg.tabWidth = width
+		if width < 1 {
+			cfg.tabWidth = defaultTabWidth
+		}
+	}
+}
+
+// WithStyle selects the formatting rules by which EngineAST prints code.
+// Like WithIndent, it has no effect where comments are deleted from the
+// original bytes. The default is StyleGofmt. StyleGofumpt applies these
+// rules of gofumpt on top of gofmt:
+//   - no empty lines at the start or end of a function body, of a block
+//     holding a single statement, of a composite literal, or of a field list;
+//   - no empty lines between an assignment to err and a check of err != nil;
+//   - no parentheses around a single var declaration;
+//   - standard library imports in a group of their own at the top;
+//   - octal integer literals with the 0o prefix;
+//   - a space after the slashes of // comments other than directives.
+func WithStyle(style Style) Option {
+	return func(cfg *config) {
+		cfg.style = style
+	}
+}
+
+// MatchFinalNewline controls whether the output ends in a line break
+// exactly if the input does, so that diffing the two shows no change at the
+// end of the file. Otherwise the output of EngineAST always ends in a line
+// break, as gofmt requires. PreserveLines leaves the final line break to
+// the input either way. It is on by default.
+func MatchFinalNewline(match bool) Option {
+	return func(cfg *config) {
+		cfg.finalNewline = match
+	}
+}
+
+// StrictUTF8 controls whether the source is validated as UTF-8 before any
+// engine runs. Invalid source is rejected with an error wrapping
+// ErrInvalidUTF8 that lists every offending position, rather than with the
+// first diagnostic of the Go scanner, and without EngineAuto falling back.
+// It is off by default.
+func StrictUTF8(strict bool) Option {
+	return func(cfg *config) {
+		cfg.strictUTF8 = strict
+	}
+}
+
+// WriteBack controls whether RemoveCommentsFromFile and RemoveCommentsFromDir
+// write the result back to each file they read, keeping its permissions. A
+// file whose content would not change is left alone. It is off by default
+// and has no effect on the functions that take source text.
+func WriteBack(write bool) Option {
+	return func(cfg *config) {
+		cfg.writeBack = write
+	}
+}
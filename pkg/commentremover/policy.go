@@ -0,0 +1,253 @@
+package commentremover
+
+import (
+	"go/ast"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Kinds of Hazard.
+const (
+	HazardBuildConstraint = "build constraint"
+	HazardEmbed           = "embed directive"
+	HazardCgoPreamble     = "cgo preamble"
+	HazardCgoExport       = "cgo export directive"
+	HazardLineDirective   = "line directive"
+)
+
+// Hazard describes a removed comment whose removal changes how the code
+// builds or behaves, such as a build constraint or a //go:embed directive.
+type Hazard struct {
+	Line int    // Line is the 1-based line of the comment in the input.
+	Kind string // Kind is one of the Hazard constants, such as HazardBuildConstraint.
+	Text string // Text is the comment, including its // or /* */ markers.
+}
+
+// comment describes a single comment for the purpose of deciding whether
+// it is kept. Both engines build one for every comment they encounter.
+type comment struct {
+	text     string // text is the comment, including its // or /* */ markers.
+	line     int    // line is the 1-based line of the comment in the input.
+	inHeader bool   // inHeader is set for comments that precede all code, including the package clause.
+	varDoc   bool   // varDoc is set for comments in the doc comment of a variable declaration.
+
+	cgoPreamble bool // cgoPreamble is set for comments in the cgo preamble preceding import "C".
+	cgoFile     bool // cgoFile is set for every comment in a file that imports "C".
+	exportedDoc bool // exportedDoc is set for comments in the doc comment of an exported declaration.
+	packageDoc  bool // packageDoc is set for comments in the doc comment of the package clause.
+	license     bool // license is set for comments in a leading copyright or license header.
+	deprecated  bool // deprecated is set for comments in a paragraph starting with "Deprecated:".
+	inFuncBody  bool // inFuncBody is set for comments inside the body of a function or method.
+
+	fieldComment bool // fieldComment is set for end-of-line comments of struct fields and constants.
+	inline       bool // inline is set for comments that follow code on the same line.
+
+	node  *ast.Comment      // node is the comment as parsed, or as scanned by EngineScanner.
+	group *ast.CommentGroup // group is the parsed group of node, or nil for EngineScanner.
+	owner func() ast.Node   // owner returns the node that node belongs to, or is nil for EngineScanner.
+}
+
+// keepComment reports whether cfg retains c rather than removing it.
+func (cfg config) keepComment(c comment) bool {
+	switch {
+	case len(cfg.lineRanges) > 0 && !inLineRanges(cfg.lineRanges, c.line):
+		return true
+	case (len(cfg.removePatterns) > 0 || cfg.onlyTodos) && !cfg.selectedForRemoval(c.text):
+		return true
+	case cfg.onlyFuncBodies && !c.inFuncBody:
+		return true
+	case cfg.keepLicense && c.license:
+		return true
+	case cfg.keepLineComments && isLineComment(c.text), cfg.keepBlockComments && !isLineComment(c.text):
+		return true
+	case cfg.keepSPDX && isSPDXIdentifier(c.text):
+		return true
+	case cfg.keepBuildConstraints && c.inHeader && isBuildConstraint(c.text):
+		return true
+	case cfg.keepGenerate && isGenerateDirective(c.text):
+		return true
+	case cfg.keepEmbed && c.varDoc && isEmbedDirective(c.text):
+		return true
+	case cfg.keepCgoPreamble && c.cgoPreamble:
+		return true
+	case cfg.keepCgoExports && c.cgoFile && isExportDirective(c.text):
+		return true
+	case cfg.keepCompilerDirectives && isCompilerDirective(c.text):
+		return true
+	case cfg.keepSyscallDirectives && isSyscallDirective(c.text):
+		return true
+	case cfg.keepLineDirectives && isLineDirective(c.text):
+		return true
+	case cfg.keepNolint && isNolintDirective(c.text):
+		return true
+	case cfg.keepDoc && c.exportedDoc:
+		return true
+	case cfg.keepPackageDoc && c.packageDoc:
+		return true
+	case cfg.keepDeprecated && c.deprecated:
+		return true
+	case cfg.keepAnnotations && isAnnotation(c.text):
+		return true
+	case cfg.keepTodos && isTaskMarker(c.text):
+		return true
+	case cfg.keepFieldComments && c.fieldComment:
+		return true
+	case matchesAny(cfg.keepPatterns, c.text):
+		return true
+	default:
+		return !acceptedByAll(cfg.removeFilters, c) || !acceptedByAllComments(cfg.commentFilters, c)
+	}
+}
+
+// selectedForRemoval reports whether text matches one of the patterns of
+// RemovePattern or, with OnlyTodos, begins with a task marker.
+func (cfg config) selectedForRemoval(text string) bool {
+	return matchesAny(cfg.removePatterns, text) || cfg.onlyTodos && isTaskMarker(text)
+}
+
+// inLineRanges reports whether line lies within any of ranges.
+func inLineRanges(ranges []lineRange, line int) bool {
+	return slices.ContainsFunc(ranges, func(r lineRange) bool {
+		return r.first <= line && line <= r.last
+	})
+}
+
+// acceptedByAll reports whether every one of filters accepts the removal
+// of c.
+func acceptedByAll(filters []LineFilter, c comment) bool {
+	last := endLine(c)
+
+	return !slices.ContainsFunc(filters, func(filter LineFilter) bool {
+		return !filter(c.line, last)
+	})
+}
+
+// acceptedByAllComments reports whether every one of filters accepts the
+// removal of c.
+func acceptedByAllComments(filters []CommentFilter, c comment) bool {
+	if len(filters) == 0 {
+		return true
+	}
+
+	var owner ast.Node
+	if c.owner != nil {
+		owner = c.owner()
+	}
+
+	return !slices.ContainsFunc(filters, func(filter CommentFilter) bool {
+		return !filter(c.node, c.group, owner)
+	})
+}
+
+// isLineComment reports whether text is a // comment rather than a /* */
+// comment.
+func isLineComment(text string) bool {
+	return strings.HasPrefix(text, "//")
+}
+
+// matchesAny reports whether text matches any of patterns.
+func matchesAny(patterns []*regexp.Regexp, text string) bool {
+	return slices.ContainsFunc(patterns, func(pattern *regexp.Regexp) bool {
+		return pattern.MatchString(text)
+	})
+}
+
+// hazard reports whether removing c changes how the code builds or runs,
+// and if so, describes the removal.
+func hazard(c comment) (Hazard, bool) {
+	var kind string
+
+	switch {
+	case c.inHeader && isBuildConstraint(c.text):
+		kind = HazardBuildConstraint
+	case c.varDoc && isEmbedDirective(c.text):
+		kind = HazardEmbed
+	case c.cgoPreamble:
+		kind = HazardCgoPreamble
+	case c.cgoFile && isExportDirective(c.text):
+		kind = HazardCgoExport
+	case isLineDirective(c.text):
+		kind = HazardLineDirective
+	default:
+		return Hazard{}, false
+	}
+
+	return Hazard{Line: c.line, Kind: kind, Text: c.text}, true
+}
+
+// removeComment records in result that c is being removed, counting it in
+// the stats and noting a Hazard if the removal changes how the code builds
+// or runs.
+func removeComment(result *Result, c comment) {
+	result.Stats.RemovedByCategory.count(c)
+
+	if isLineComment(c.text) {
+		result.Stats.LineComments++
+	} else {
+		result.Stats.BlockComments++
+	}
+
+	if c.exportedDoc || c.packageDoc {
+		result.Stats.DocComments++
+	}
+
+	if h, ok := hazard(c); ok {
+		result.Hazards = append(result.Hazards, h)
+	}
+}
+
+// retainComment records in result that c is being kept, counting it in the
+// stats.
+func retainComment(result *Result, c comment) {
+	result.Stats.KeptByCategory.count(c)
+
+	if isDirective(c) {
+		result.Stats.DirectivesKept++
+	}
+}
+
+// isDirective reports whether c is one of the directives that
+// KeepDirectives covers.
+func isDirective(c comment) bool {
+	return c.inHeader && isBuildConstraint(c.text) || isGenerateDirective(c.text) ||
+		c.varDoc && isEmbedDirective(c.text) || c.cgoPreamble || c.cgoFile && isExportDirective(c.text) ||
+		isCompilerDirective(c.text) || isSyscallDirective(c.text) || isLineDirective(c.text)
+}
+
+// count adds c to the category it falls in, as listed at Categories.
+func (categories *Categories) count(c comment) {
+	switch {
+	case c.license:
+		categories.License++
+	case isDirective(c):
+		categories.Directive++
+	case isTaskMarker(c.text):
+		categories.Todo++
+	case c.exportedDoc || c.packageDoc:
+		categories.Doc++
+	case c.inline:
+		categories.Inline++
+	case !isLineComment(c.text):
+		categories.Block++
+	default:
+		categories.Line++
+	}
+}
+
+// followsCode reports whether the comment at offset in src follows code on
+// the same line, as an end-of-line comment does. It reports false if src
+// does not reach offset, as for a file parsed elsewhere.
+func followsCode[Source ~string | ~[]byte](src Source, offset int) bool {
+	if offset > len(src) {
+		return false
+	}
+
+	for i := offset - 1; i >= 0 && src[i] != '\n'; i-- {
+		if src[i] != ' ' && src[i] != '\t' && src[i] != '\r' {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,121 @@
+package commentremover
+
+import (
+	"bytes"
+	"go/scanner"
+	"go/token"
+	"strings"
+	"sync"
+)
+
+// The state for tokenizing and printing a source is kept in pools and
+// reused from one source to the next, since for batches of small files
+// allocating it afresh each time takes much of the time spent.
+const (
+	// maxPooledSize is the capacity up to which buffers go back to their
+	// pool once used, so that one huge input does not keep its memory.
+	maxPooledSize = 1 << 20
+
+	// maxFileSetBase is the base past which the file set of a pooled
+	// tokenScanner is replaced, since removing a file from a set does not
+	// free its range of positions and token.Pos may only hold 32 bits.
+	maxFileSetBase = 1 << 30
+
+	// probeSize is about how much of a source is tokenized by the checks
+	// that only look at its first few tokens, so that they do not copy all
+	// of a large source.
+	probeSize = 4 << 10
+)
+
+var (
+	// tokenScanners holds the tokenScanners that are not in use.
+	tokenScanners = sync.Pool{New: func() any { return &tokenScanner{fset: token.NewFileSet()} }}
+
+	// printBuffers holds the buffers that code is printed to.
+	printBuffers = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+)
+
+// tokenScanner tokenizes a copy of a source, in a file set of its own.
+type tokenScanner struct {
+	scanner.Scanner
+
+	fset *token.FileSet    // fset holds file while the scanner is in use.
+	file *token.File       // file holds the positions of src.
+	src  []byte            // src is the copy of the source being tokenized.
+	errs scanner.ErrorList // errs collects the errors reported while scanning.
+}
+
+// newTokenScanner returns a scanner of src from the pool, set up as
+// scanner.Scanner.Init does with mode. Its errors are collected in errs.
+// Once neither the scanner nor the positions it returned are needed any
+// more, it must be given back with release.
+func newTokenScanner(src string, mode scanner.Mode) *tokenScanner {
+	scan, _ := tokenScanners.Get().(*tokenScanner)
+	if scan.fset.Base() > maxFileSetBase {
+		scan.fset = token.NewFileSet()
+	}
+
+	scan.src = append(scan.src[:0], src...)
+	scan.file = scan.fset.AddFile("", scan.fset.Base(), len(scan.src))
+	scan.Init(scan.file, scan.src, scan.errs.Add, mode)
+
+	return scan
+}
+
+// release gives scan back to the pool. A copy of a huge source is dropped
+// rather than kept along with it.
+func (scan *tokenScanner) release() {
+	scan.fset.RemoveFile(scan.file)
+	scan.Scanner, scan.file, scan.errs = scanner.Scanner{}, nil, nil
+
+	if cap(scan.src) > maxPooledSize {
+		scan.src = nil
+	}
+
+	tokenScanners.Put(scan)
+}
+
+// probeTokens returns what check finds in the first tokens of src. It
+// tokenizes only the start of src, up to a line break near probeSize
+// bytes, so that no token but a raw string or a block comment is cut in
+// two. Should check report, through its second result, that it reached
+// the end of that start without an answer, it is run again on all of src.
+func probeTokens[T any](src string, check func(scan *tokenScanner) (T, bool)) T {
+	start := src
+	if len(src) > probeSize {
+		if end := strings.LastIndexByte(src[:probeSize], '\n'); end >= 0 {
+			start = src[:end+1]
+		}
+	}
+
+	scan := newTokenScanner(start, 0)
+	result, ok := check(scan)
+	scan.release()
+
+	if ok || len(start) == len(src) {
+		return result
+	}
+
+	scan = newTokenScanner(src, 0)
+	defer scan.release()
+
+	result, _ = check(scan)
+
+	return result
+}
+
+// printBuffer returns an empty buffer from the pool. It must be given back
+// with releasePrintBuffer once its contents are no longer needed.
+func printBuffer() *bytes.Buffer {
+	buf, _ := printBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+
+	return buf
+}
+
+// releasePrintBuffer gives buf back to the pool.
+func releasePrintBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledSize {
+		printBuffers.Put(buf)
+	}
+}
@@ -0,0 +1,224 @@
+package commentremover
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Unparsed is a region of the input that RecoverPartial left unchanged
+// because it does not parse.
+type Unparsed struct {
+	FirstLine int    `json:"firstLine"` // FirstLine is the 1-based line of the input the region begins on.
+	LastLine  int    `json:"lastLine"`  // LastLine is the line the region ends on.
+	Error     string `json:"error"`     // Error is the syntax error that the region was left unchanged for.
+}
+
+// declChunk is the text of a top-level declaration in a source, from its
+// doc comment up to the next declaration.
+type declChunk struct {
+	start   int // start is the offset of the doc comment, or of the keyword if there is none.
+	keyword int // keyword is the offset of the func, type, var, const, or import keyword.
+	end     int // end is the offset of the next chunk, or the size of the source.
+}
+
+// processPartial implements RecoverPartial for sourceCode, a file or a
+// snippet of declarations that failed to parse with astErr. It parses the
+// source again and again, each time blanking out the declaration holding
+// the first syntax error that go/parser reports, until the rest parses.
+// Blanking keeps the offsets and lines of the rest, so its comments are
+// removed from the original source as usual, with the declarations in
+// which they sit known, while the blanked declarations are left unchanged
+// and listed in the result. Code that does not parse cannot be printed, so
+// the comments are spliced out of the source as with MinimalDiff.
+//
+// astErr is returned if no declaration can be blamed for an error, as for
+// one in the package clause or in a snippet of statements.
+func processPartial(sourceCode string, cfg config, astErr error) (Result, error) {
+	shape := shapeFile
+	if !hasPackageClause(sourceCode) {
+		shape = shapeDecls
+	}
+
+	wrapped := shape.wrap(sourceCode)
+	chunks := declChunks(wrapped)
+
+	fset, file, blanked := parseBlanking(wrapped, chunks)
+	if file == nil {
+		return Result{}, astErr
+	}
+
+	result := Result{Engine: EngineAST}
+	_, removed := removeCommentsFromAST(fset, file, wrapped, cfg, shape, &result)
+	result.Source = spliceSource(wrapped, removed, shape, cfg.preserveLines)
+
+	skip, lines := shape.lines(), countLines(sourceCode)
+
+	for _, i := range slices.Sorted(maps.Keys(blanked)) {
+		chunk := chunks[i]
+		first := strings.Count(wrapped[:chunk.start], "\n") + 1
+		last := first + strings.Count(strings.TrimRight(wrapped[chunk.start:chunk.end], " \t\r\n"), "\n")
+
+		result.Unparsed = append(result.Unparsed, Unparsed{
+			FirstLine: max(first-skip, 1),
+			LastLine:  min(last-skip, lines),
+			Error:     blanked[i],
+		})
+	}
+
+	return result, nil
+}
+
+// parseBlanking parses src, blanking out the chunks that hold syntax
+// errors as described at processPartial, and returns the file set and the
+// file of the blanked source along with the indexes of the blanked chunks,
+// mapped to the error each was blanked for. Once the rest parses, every
+// blanked chunk is tried once more on its own, since an error that the
+// parser only noticed in a later declaration, as for a missing closing
+// brace, blames that one first. The file is nil if an error lies ahead of
+// the first declaration.
+func parseBlanking(src string, chunks []declChunk) (*token.FileSet, *ast.File, map[int]string) {
+	text := []byte(src)
+	blanked := make(map[int]string)
+
+	fset, file, diagnostic := parseChunks(text)
+	for diagnostic != nil {
+		i := blamedChunk(chunks, blanked, diagnostic.Pos.Offset)
+		if i < 0 {
+			return nil, nil, nil
+		}
+
+		blankOut(text, chunks[i])
+		blanked[i] = diagnostic.Msg
+		fset, file, diagnostic = parseChunks(text)
+	}
+
+	for _, i := range slices.Sorted(maps.Keys(blanked)) {
+		chunk := chunks[i]
+		copy(text[chunk.start:chunk.end], src[chunk.start:chunk.end])
+
+		restoredFset, restoredFile, diagnostic := parseChunks(text)
+		if diagnostic != nil {
+			blankOut(text, chunk)
+
+			continue
+		}
+
+		fset, file = restoredFset, restoredFile
+		delete(blanked, i)
+	}
+
+	return fset, file, blanked
+}
+
+// parseChunks parses src and returns the file set and file, or the first
+// syntax error if it does not parse.
+func parseChunks(src []byte) (*token.FileSet, *ast.File, *scanner.Error) {
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err == nil {
+		return fset, file, nil
+	}
+
+	var list scanner.ErrorList
+	if !errors.As(err, &list) || len(list) == 0 {
+		return nil, nil, &scanner.Error{Msg: err.Error()}
+	}
+
+	return nil, nil, list[0]
+}
+
+// blamedChunk returns the index of the chunk that an error at offset is
+// blamed on, or -1 if it lies ahead of the first declaration. An error
+// reported at a declaration keyword is blamed on the chunk before, since
+// the parser found the keyword where it still expected the rest of that
+// one. An error within a chunk already blanked out is blamed on the last
+// chunk before that is not.
+func blamedChunk(chunks []declChunk, blanked map[int]string, offset int) int {
+	i := len(chunks) - 1
+	for i >= 0 && chunks[i].keyword >= offset {
+		i--
+	}
+
+	for i >= 0 {
+		if _, ok := blanked[i]; !ok {
+			break
+		}
+
+		i--
+	}
+
+	return i
+}
+
+// blankOut replaces the text of chunk in src with spaces, keeping its line
+// breaks.
+func blankOut(src []byte, chunk declChunk) {
+	for i := chunk.start; i < chunk.end; i++ {
+		if src[i] != '\n' {
+			src[i] = ' '
+		}
+	}
+}
+
+// declChunks splits src into the chunks of its top-level declarations,
+// found by their keywords at the start of a line, as gofmt leaves them. A
+// run of comments on the lines just above a keyword, starting at the start
+// of a line as well, is taken to be its doc comment. The text ahead of the
+// first declaration, such as the package clause, is not a chunk.
+func declChunks(src string) []declChunk {
+	scan := newTokenScanner(src, scanner.ScanComments)
+	defer scan.release()
+
+	var (
+		chunks             []declChunk
+		docStart, docLines = -1, 0
+	)
+
+	for {
+		pos, tok, lit := scan.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		position := scan.file.PositionFor(pos, false)
+
+		switch {
+		case tok == token.SEMICOLON && lit == "\n":
+		case tok == token.COMMENT && position.Column == 1:
+			if docStart < 0 || docLines != position.Line-1 {
+				docStart = position.Offset
+			}
+
+			docLines = position.Line + strings.Count(lit, "\n")
+		case position.Column == 1 && isDeclKeyword(tok):
+			start := position.Offset
+			if docStart >= 0 && docLines == position.Line-1 {
+				start = docStart
+			}
+
+			if len(chunks) > 0 {
+				chunks[len(chunks)-1].end = start
+			}
+
+			chunks = append(chunks, declChunk{start: start, keyword: position.Offset, end: len(src)})
+			docStart = -1
+		default:
+			docStart = -1
+		}
+	}
+
+	return chunks
+}
+
+// isDeclKeyword reports whether tok is a keyword that begins a top-level
+// declaration.
+func isDeclKeyword(tok token.Token) bool {
+	return tok == token.FUNC || tok == token.TYPE || tok == token.VAR || tok == token.CONST || tok == token.IMPORT
+}
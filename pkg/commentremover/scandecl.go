@@ -0,0 +1,129 @@
+package commentremover
+
+import (
+	"go/token"
+	"slices"
+	"strings"
+)
+
+// decl follows a top-level declaration for the scanner engine, so that the
+// doc comments of exported declarations can be recognized without an AST.
+type decl struct {
+	keyword   token.Token // keyword is CONST, TYPE, VAR, or FUNC, or ILLEGAL outside a declaration.
+	doc       []int       // doc indexes the doc comment of the declaration.
+	specDoc   []int       // specDoc indexes the doc comment of the current spec of a block.
+	grouped   bool        // grouped is set inside the parentheses of a declaration block.
+	names     bool        // names is set while the names of a spec are being read.
+	specStart bool        // specStart is set before the first name of a spec in a block.
+	recvDepth int         // recvDepth is the parenthesis depth of a method receiver while inside it.
+	recvType  string      // recvType is the type name of the method receiver.
+	method    bool        // method is set once a receiver has been seen.
+}
+
+// trackDecl follows top-level declarations through the token tok, with
+// literal lit, on line. It runs before advance updates the nesting depths,
+// and marks the doc comments of declarations that turn out to declare an
+// exported name. A method counts as exported only if its receiver type is
+// exported too, matching what go doc shows.
+func (ctx *scanContext) trackDecl(tok token.Token, lit string, line int, spans []commentSpan) {
+	topLevel := ctx.braceDepth == 0 && ctx.parenDepth == 0
+
+	switch {
+	case topLevel && (tok == token.CONST || tok == token.TYPE || tok == token.VAR || tok == token.FUNC):
+		ctx.decl = decl{keyword: tok, doc: ctx.docGroup(line, spans), names: tok != token.FUNC}
+	case ctx.decl.keyword == token.FUNC:
+		ctx.trackFunc(tok, lit, spans)
+	case ctx.decl.keyword != token.ILLEGAL:
+		ctx.trackSpecs(tok, lit, line, spans)
+	default:
+	}
+}
+
+// trackFunc follows the receiver and name of a function declaration.
+func (ctx *scanContext) trackFunc(tok token.Token, lit string, spans []commentSpan) {
+	current := &ctx.decl
+
+	switch {
+	case tok == token.LPAREN && ctx.prev == token.FUNC:
+		current.recvDepth = ctx.parenDepth + 1
+		current.method = true
+	case current.recvDepth > 0:
+		if tok == token.IDENT && ctx.bracketDepth == 0 {
+			current.recvType = lit
+		}
+
+		if tok == token.RPAREN && ctx.parenDepth == current.recvDepth {
+			current.recvDepth = 0
+		}
+	case tok == token.IDENT:
+		if token.IsExported(lit) && (!current.method || token.IsExported(current.recvType)) {
+			markExportedDoc(spans, current.doc)
+		}
+
+		*current = decl{}
+	default:
+		*current = decl{}
+	}
+}
+
+// trackSpecs follows the specs of a const, type, or var declaration.
+func (ctx *scanContext) trackSpecs(tok token.Token, lit string, line int, spans []commentSpan) {
+	current := &ctx.decl
+	atBlock := current.grouped && ctx.parenDepth == 1 && ctx.braceDepth == 0
+
+	switch {
+	case tok == token.LPAREN && ctx.prev == current.keyword && !current.grouped:
+		current.grouped, current.names, current.specStart = true, true, true
+	case current.names && tok == token.IDENT:
+		if current.specStart {
+			current.specDoc = ctx.docGroup(line, spans)
+			current.specStart = false
+		}
+
+		if token.IsExported(lit) {
+			markExportedDoc(spans, current.doc)
+			markExportedDoc(spans, current.specDoc)
+		}
+	case current.names && tok == token.COMMA:
+	case atBlock && tok == token.SEMICOLON:
+		current.names, current.specStart = true, true
+	case atBlock && tok == token.RPAREN:
+		*current = decl{}
+	default:
+		current.names = false
+	}
+}
+
+// docGroup returns the pending spans that go/parser would attach as the
+// doc comment of a token on line: the last comment group, if it ends on
+// the line before. Comments are grouped while each starts at most one line
+// after the previous one ends, and a comment trailing the previous token
+// on its line starts no group.
+func (ctx *scanContext) docGroup(line int, spans []commentSpan) []int {
+	start := len(ctx.pending)
+	next := line
+
+	for start > 0 {
+		span := spans[ctx.pending[start-1]]
+		if span.line == ctx.prevLine && ctx.prev != token.ILLEGAL {
+			break
+		}
+
+		end := span.line + strings.Count(span.text, "\n")
+		if end != next-1 && (start == len(ctx.pending) || end < next-1) {
+			break
+		}
+
+		start--
+		next = span.line
+	}
+
+	return slices.Clone(ctx.pending[start:])
+}
+
+// markExportedDoc marks the spans indexed by doc as exported doc comments.
+func markExportedDoc(spans []commentSpan, doc []int) {
+	for _, i := range doc {
+		spans[i].exportedDoc = true
+	}
+}
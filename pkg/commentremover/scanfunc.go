@@ -0,0 +1,60 @@
+package commentremover
+
+import "go/token"
+
+// funcBodies follows function bodies for the scanner engine. The opening
+// brace of a body is the first brace after a func keyword at the same
+// nesting depth, unless it opens a struct or interface type in the
+// signature. A func keyword without a body, as in a function type, is
+// forgotten at the end of its list element, statement, or enclosing
+// brackets.
+type funcBodies struct {
+	pending []int // pending holds the nesting depths of func keywords whose body has not begun.
+	open    []int // open holds the nesting depths of the bodies being scanned.
+}
+
+// depth returns the combined nesting depth of parentheses, brackets, and
+// braces.
+func (ctx *scanContext) depth() int {
+	return ctx.parenDepth + ctx.bracketDepth + ctx.braceDepth
+}
+
+// inFuncBody reports whether the scanner is inside a function body.
+func (ctx *scanContext) inFuncBody() bool {
+	return len(ctx.bodies.open) > 0
+}
+
+// trackFuncBody follows function bodies through the token tok. Like
+// trackDecl, it runs before advance updates the nesting depths.
+func (ctx *scanContext) trackFuncBody(tok token.Token) {
+	bodies := &ctx.bodies
+	depth := ctx.depth()
+
+	switch tok {
+	case token.FUNC:
+		bodies.pending = append(bodies.pending, depth)
+	case token.LBRACE:
+		if n := len(bodies.pending); n > 0 && bodies.pending[n-1] == depth &&
+			ctx.prev != token.STRUCT && ctx.prev != token.INTERFACE {
+			bodies.pending = bodies.pending[:n-1]
+			bodies.open = append(bodies.open, depth)
+		}
+	case token.RBRACE:
+		if n := len(bodies.open); n > 0 && bodies.open[n-1] == depth-1 {
+			bodies.open = bodies.open[:n-1]
+		}
+
+		bodies.forget(depth)
+	case token.RPAREN, token.RBRACK, token.SEMICOLON, token.COMMA, token.ASSIGN, token.DEFINE:
+		bodies.forget(depth)
+	default:
+	}
+}
+
+// forget drops the pending func keywords at depth or deeper, which a
+// closing bracket or separator at depth has ended.
+func (bodies *funcBodies) forget(depth int) {
+	for n := len(bodies.pending); n > 0 && bodies.pending[n-1] >= depth; n-- {
+		bodies.pending = bodies.pending[:n-1]
+	}
+}
@@ -0,0 +1,412 @@
+package commentremover
+
+import (
+	"bytes"
+	"go/ast"
+	"go/scanner"
+	"go/token"
+)
+
+// commentSpan is the byte range [start, end) of a comment in the source,
+// along with the details needed to decide whether it is kept.
+type commentSpan struct {
+	comment
+
+	start int // start is the offset of the opening "//" or "/*".
+	end   int // end is the offset just past the comment text.
+}
+
+// scanContext tracks the token context that the scanner engine uses in
+// place of an AST when classifying comments.
+type scanContext struct {
+	inHeader   bool        // inHeader is set until the first token of code.
+	importsC   bool        // importsC is set once an import of "C" has been seen.
+	prev       token.Token // prev is the previous non-comment token.
+	parenDepth int         // parenDepth is the current nesting of parentheses.
+	block      token.Token // block is the keyword of the enclosing var or import ( ... ) block.
+	blockDepth int         // blockDepth is the parenthesis depth of block, or 0 outside one.
+	pending    []int       // pending indexes the spans awaiting the token that follows them.
+	importDoc  []int       // importDoc indexes the spans preceding the last import keyword.
+
+	prevLine     int  // prevLine is the line of prev.
+	braceDepth   int  // braceDepth is the current nesting of braces.
+	bracketDepth int  // bracketDepth is the current nesting of brackets.
+	decl         decl // decl follows the current top-level declaration.
+
+	bodies funcBodies // bodies follows the function bodies enclosing the current token.
+	braces []bool     // braces records, for each open brace, whether it opens a struct type.
+}
+
+// inBlock reports whether the current token sits directly inside a
+// parenthesized declaration block introduced by keyword.
+func (ctx *scanContext) inBlock(keyword token.Token) bool {
+	return ctx.blockDepth > 0 && ctx.block == keyword && ctx.parenDepth == ctx.blockDepth
+}
+
+// advance records the non-comment token tok, with literal lit, on line,
+// and completes the context of the comments immediately preceding it.
+func (ctx *scanContext) advance(tok token.Token, lit string, line int, spans []commentSpan) {
+	ctx.trackDecl(tok, lit, line, spans)
+	ctx.trackFuncBody(tok)
+
+	if tok == token.PACKAGE {
+		for _, i := range ctx.docGroup(line, spans) {
+			spans[i].packageDoc = true
+		}
+	}
+
+	for _, i := range ctx.pending {
+		spans[i].varDoc = tok == token.VAR || (tok == token.IDENT && ctx.inBlock(token.VAR))
+	}
+
+	// The cgo preamble is the comment before `import "C"`, or before "C"
+	// within an import block.
+	if tok == token.STRING && lit == `"C"` && (ctx.prev == token.IMPORT || ctx.inBlock(token.IMPORT)) {
+		ctx.importsC = true
+
+		preamble := ctx.pending
+		if ctx.prev == token.IMPORT {
+			preamble = ctx.importDoc
+		}
+
+		for _, i := range preamble {
+			spans[i].cgoPreamble = true
+		}
+	}
+
+	ctx.importDoc = ctx.importDoc[:0]
+	if tok == token.IMPORT {
+		ctx.importDoc = append(ctx.importDoc, ctx.pending...)
+	}
+
+	ctx.pending = ctx.pending[:0]
+	ctx.inHeader = false
+
+	switch tok {
+	case token.LPAREN:
+		ctx.parenDepth++
+		if ctx.prev == token.VAR || ctx.prev == token.IMPORT {
+			ctx.block = ctx.prev
+			ctx.blockDepth = ctx.parenDepth
+		}
+	case token.RPAREN:
+		if ctx.parenDepth == ctx.blockDepth {
+			ctx.blockDepth = 0
+		}
+
+		ctx.parenDepth--
+	case token.LBRACE:
+		ctx.braceDepth++
+		ctx.braces = append(ctx.braces, ctx.prev == token.STRUCT)
+	case token.RBRACE:
+		ctx.braceDepth--
+		if len(ctx.braces) > 0 {
+			ctx.braces = ctx.braces[:len(ctx.braces)-1]
+		}
+	case token.LBRACK:
+		ctx.bracketDepth++
+	case token.RBRACK:
+		ctx.bracketDepth--
+	default:
+	}
+
+	ctx.prev = tok
+	ctx.prevLine = line
+}
+
+// trailsField reports whether a comment on line trails a struct field or
+// a constant declaration on that line.
+func (ctx *scanContext) trailsField(line int) bool {
+	if ctx.prev == token.ILLEGAL || ctx.prevLine != line {
+		return false
+	}
+
+	inStruct := len(ctx.braces) > 0 && ctx.braces[len(ctx.braces)-1]
+	inConst := ctx.decl.keyword == token.CONST && ctx.braceDepth == 0 &&
+		(ctx.parenDepth == 0 || ctx.decl.grouped && ctx.parenDepth == 1)
+
+	return inStruct || inConst
+}
+
+// scanComments tokenizes the source of scan, set up with
+// scanner.ScanComments, and returns the location of every comment in
+// source order. It returns an error if the scanner reports any errors, such
+// as an unterminated comment or string literal, along with the comments
+// found all the same. A block comment that is never closed is not among
+// them.
+func scanComments(scan *tokenScanner) ([]commentSpan, error) {
+	src, file := scan.src, scan.file
+
+	// Every comment starts with one of these, so counting them sizes spans
+	// once rather than growing it through copies, which for generated files
+	// with many comments would take several times the size of the input.
+	spans := make([]commentSpan, 0, bytes.Count(src, []byte("//"))+bytes.Count(src, []byte("/*")))
+
+	ctx := scanContext{inHeader: true}
+
+	for {
+		pos, tok, lit := scan.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		line := file.PositionFor(pos, false).Line
+
+		// Automatically inserted semicolons do not separate a doc comment
+		// from its declaration, but they do end declaration specs.
+		if tok == token.SEMICOLON && lit == "\n" {
+			ctx.trackDecl(tok, lit, line, spans)
+			ctx.trackFuncBody(tok)
+
+			continue
+		}
+
+		if tok != token.COMMENT {
+			ctx.advance(tok, lit, line, spans)
+
+			continue
+		}
+
+		start := file.Offset(pos)
+
+		// An open block comment runs to the end of the source, which may
+		// well be code in the middle of an edit, so it is left alone.
+		if src[start+1] == '*' && !bytes.Contains(src[start+2:], []byte("*/")) {
+			continue
+		}
+
+		end := commentEnd(src, start)
+		text := string(src[start:end])
+		ctx.pending = append(ctx.pending, len(spans))
+		spans = append(spans, commentSpan{
+			comment: comment{
+				text:       text,
+				line:       line,
+				inHeader:   ctx.inHeader,
+				inFuncBody: ctx.inFuncBody(),
+
+				fieldComment: ctx.trailsField(line),
+				inline:       followsCode(src, start),
+
+				node: &ast.Comment{Text: text},
+			},
+			start: start,
+			end:   end,
+		})
+	}
+
+	for i := range spans {
+		spans[i].cgoFile = ctx.importsC
+	}
+
+	markLicenseHeader(spans)
+	markDeprecated(src, spans)
+
+	if scan.errs.Len() > 0 {
+		scan.errs.Sort()
+
+		return spans, parseError(scan.errs.Err(), "")
+	}
+
+	return spans, nil
+}
+
+// markLicenseHeader marks the comments of the first comment group in the
+// header of the file if they form a license header.
+func markLicenseHeader(spans []commentSpan) {
+	lead := spans[:leadingGroup(spans)]
+	texts := make([]string, len(lead))
+
+	for i, span := range lead {
+		texts[i] = span.text
+	}
+
+	if !isLicenseHeader(texts) {
+		return
+	}
+
+	for i := range lead {
+		lead[i].license = true
+	}
+}
+
+// markDeprecated marks the comments that belong to a deprecation notice
+// within their comment group.
+func markDeprecated(src []byte, spans []commentSpan) {
+	for start := 0; start < len(spans); {
+		end := start + 1
+		for end < len(spans) && continuesGroup(src, spans[start], spans[end-1], spans[end]) {
+			end++
+		}
+
+		texts := make([]string, 0, end-start)
+		for _, span := range spans[start:end] {
+			texts = append(texts, span.text)
+		}
+
+		for i, notice := range deprecatedNotices(texts) {
+			spans[start+i].deprecated = notice
+		}
+
+		start = end
+	}
+}
+
+// continuesGroup reports whether next belongs to the comment group running
+// from first to last, as go/parser forms groups: comments separated only by
+// whitespace with at most one line break belong together, except that a
+// group trailing code on its line only takes comments on that same line.
+func continuesGroup(src []byte, first, last, next commentSpan) bool {
+	between := src[last.end:next.start]
+	if len(bytes.Trim(between, " \t\r\n")) > 0 {
+		return false
+	}
+
+	breaks := 1
+	if lineStart := bytes.LastIndexByte(src[:first.start], '\n') + 1; !isBlank(src[lineStart:first.start]) {
+		breaks = 0
+	}
+
+	return bytes.Count(between, []byte("\n")) <= breaks
+}
+
+// commentEnd returns the offset just past the comment starting at start.
+// A trailing carriage return on a line comment is not part of the comment,
+// so CRLF line endings survive removal.
+func commentEnd(src []byte, start int) int {
+	if src[start+1] == '*' {
+		return start + 2 + bytes.Index(src[start+2:], []byte("*/")) + 2
+	}
+
+	end := bytes.IndexByte(src[start:], '\n')
+	if end < 0 {
+		end = len(src)
+	} else {
+		end += start
+	}
+
+	if end > start && src[end-1] == '\r' {
+		end--
+	}
+
+	return end
+}
+
+// isBlank reports whether b contains only spaces, tabs, and carriage
+// returns.
+func isBlank(b []byte) bool {
+	return len(bytes.Trim(b, " \t\r")) == 0
+}
+
+// isSpace reports whether c is a whitespace byte in Go source.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+// lineEnd returns the offset of the newline that terminates the line
+// containing offset, or len(src) if that line is the last one.
+func lineEnd(src []byte, offset int) int {
+	end := bytes.IndexByte(src[offset:], '\n')
+	if end < 0 {
+		return len(src)
+	}
+
+	return offset + end
+}
+
+// spliceComments copies src to a new buffer, omitting the given comment
+// spans. Whitespace left dangling by a removal is cleaned up: a line that
+// held nothing but comments is dropped entirely, and blanks before an
+// end-of-line comment are trimmed. A block comment between two tokens is
+// replaced by a space, or by a line break if it spanned lines, so the
+// surrounding code tokenizes exactly as before. The line break is "\r\n" if
+// the comment holds one, which keeps the line endings of CRLF input.
+//
+// If keepLines is set, every line of src survives, so that each line of
+// the output has the same number as in src: a line that held nothing but
+// comments is left empty, and a block comment spanning lines is replaced
+// by as many line breaks.
+func spliceComments(src []byte, spans []commentSpan, keepLines bool) []byte {
+	out := make([]byte, 0, len(src))
+	cursor := 0
+
+	for _, span := range spans {
+		out = append(out, src[cursor:span.start]...)
+		cursor = span.end
+		eol := lineEnd(src, span.end)
+		comment := src[span.start:span.end]
+
+		if isBlank(src[span.end:eol]) {
+			out = bytes.TrimRight(out, " \t")
+
+			switch {
+			case keepLines:
+				out = append(out, lineBreaks(comment)...)
+				cursor += len(src[cursor:eol]) - len(bytes.TrimLeft(src[cursor:eol], " \t"))
+			case len(out) == 0 || out[len(out)-1] == '\n':
+				cursor = min(eol+1, len(src))
+			}
+
+			continue
+		}
+
+		switch {
+		case keepLines && bytes.IndexByte(comment, '\n') >= 0:
+			out = append(bytes.TrimRight(out, " \t"), lineBreaks(comment)...)
+		case bytes.Contains(comment, []byte("\r\n")):
+			out = append(bytes.TrimRight(out, " \t"), '\r', '\n')
+		case bytes.IndexByte(comment, '\n') >= 0:
+			out = append(bytes.TrimRight(out, " \t"), '\n')
+		case len(out) > 0 && !isSpace(out[len(out)-1]) && !isSpace(src[span.end]):
+			out = append(out, ' ')
+		}
+	}
+
+	return append(out, src[cursor:]...)
+}
+
+// lineBreaks returns the line breaks within comment, "\n" or "\r\n" each,
+// in order.
+func lineBreaks(comment []byte) []byte {
+	var breaks []byte
+
+	for i, c := range comment {
+		switch {
+		case c == '\n' && i > 0 && comment[i-1] == '\r':
+			breaks = append(breaks, '\r', '\n')
+		case c == '\n':
+			breaks = append(breaks, '\n')
+		}
+	}
+
+	return breaks
+}
+
+// processScanner implements EngineScanner. It does not require the source
+// to parse, only to tokenize, so it also accepts snippets of any shape.
+// With BestEffort, it does not even require that.
+func processScanner(sourceCode string, cfg config) (Result, error) {
+	scan := newTokenScanner(sourceCode, scanner.ScanComments)
+	defer scan.release()
+
+	spans, err := scanComments(scan)
+	if err != nil && !cfg.bestEffort {
+		return Result{}, err
+	}
+
+	result := Result{Engine: EngineScanner}
+	removed := spans[:0]
+
+	for _, span := range spans {
+		if cfg.keepComment(span.comment) {
+			retainComment(&result, span.comment)
+		} else {
+			removeComment(&result, span.comment)
+			removed = append(removed, span)
+		}
+	}
+
+	result.Source = string(spliceComments(scan.src, removed, cfg.preserveLines))
+
+	return result, nil
+}
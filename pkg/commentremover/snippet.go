@@ -0,0 +1,368 @@
+package commentremover
+
+import (
+	"errors"
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"strings"
+)
+
+// dummyPackage is the package clause put ahead of a snippet so that it
+// parses as a file.
+const dummyPackage = "package main\n"
+
+// Text around a snippet of statements, which are parsed as the body of a
+// function.
+const (
+	stmtsOpen  = "func _() {\n" // stmtsOpen follows dummyPackage ahead of the statements.
+	stmtsClose = "\n}\n"        // stmtsClose closes the function after the statements.
+)
+
+// Text around a snippet holding a single expression, which is parsed as the
+// value of a variable declaration.
+const (
+	exprOpen  = "var _ = " // exprOpen follows dummyPackage ahead of the expression.
+	exprClose = "\n"       // exprClose ends the declaration after the expression.
+)
+
+// snippetShape tells how the input was wrapped so that it parses as a file.
+type snippetShape int
+
+const (
+	shapeFile  snippetShape = iota // shapeFile is a complete file, parsed as is.
+	shapeDecls                     // shapeDecls is a list of declarations, parsed behind dummyPackage.
+	shapeStmts                     // shapeStmts is a list of statements, parsed in a function behind dummyPackage.
+	shapeExpr                      // shapeExpr is a single expression, parsed as the value of a variable.
+)
+
+// snippetFallbacks are the shapes that input lacking a package clause is
+// parsed as, in the order they are tried. Expressions come before
+// statements, since go/parser accepts any expression as a statement.
+var snippetFallbacks = []snippetShape{shapeDecls, shapeExpr, shapeStmts}
+
+// prefix returns the text put ahead of input of the shape.
+func (shape snippetShape) prefix() string {
+	switch shape {
+	case shapeFile:
+		return ""
+	case shapeDecls:
+		return dummyPackage
+	case shapeStmts:
+		return dummyPackage + stmtsOpen
+	case shapeExpr:
+		return dummyPackage + exprOpen
+	default:
+		return ""
+	}
+}
+
+// suffix returns the text put after input of the shape.
+func (shape snippetShape) suffix() string {
+	switch shape {
+	case shapeStmts:
+		return stmtsClose
+	case shapeExpr:
+		return exprClose
+	default:
+		return ""
+	}
+}
+
+// wrap returns sourceCode with the text of the shape around it.
+func (shape snippetShape) wrap(sourceCode string) string {
+	return shape.prefix() + sourceCode + shape.suffix()
+}
+
+// fits reports whether file, parsed from input wrapped in the shape, holds
+// only what the shape allows. Input wrapped as shapeExpr may parse while
+// being more than a single expression, such as a list of expressions or an
+// expression followed by declarations, which are left to other shapes.
+func (shape snippetShape) fits(file *ast.File) bool {
+	if shape != shapeExpr {
+		return true
+	}
+
+	if len(file.Decls) != 1 {
+		return false
+	}
+
+	decl, ok := file.Decls[0].(*ast.GenDecl)
+	if !ok || decl.Tok != token.VAR || len(decl.Specs) != 1 {
+		return false
+	}
+
+	spec, ok := decl.Specs[0].(*ast.ValueSpec)
+
+	return ok && len(spec.Values) == 1
+}
+
+// lines returns the number of lines put ahead of input of the shape.
+func (shape snippetShape) lines() int {
+	return strings.Count(shape.prefix(), "\n")
+}
+
+// parseSnippet parses sourceCode as a file or, lacking a package clause, as
+// the first of snippetFallbacks that it parses as, so that callers need not
+// know what kind of snippet they hold. It returns the file set, the parsed
+// file, the wrapped source it was parsed from, and its shape. If no shape
+// parses, the error is that of the attempt that got furthest into
+// sourceCode before failing, or of the first of them, wrapping ErrParse,
+// so that a broken snippet of statements is not reported as a misplaced
+// declaration.
+func parseSnippet(sourceCode string) (*token.FileSet, *ast.File, string, snippetShape, error) {
+	if hasPackageClause(sourceCode) {
+		fset := token.NewFileSet()
+
+		file, err := parseSourceCode(fset, sourceCode)
+		if err != nil {
+			return nil, nil, "", shapeFile, parseError(err, "")
+		}
+
+		return fset, file, sourceCode, shapeFile, nil
+	}
+
+	var furthestErr error
+
+	furthest := -1
+
+	for _, shape := range snippetFallbacks {
+		fset := token.NewFileSet()
+		wrapped := shape.wrap(sourceCode)
+
+		file, err := parseSourceCode(fset, wrapped)
+		if err == nil && shape.fits(file) {
+			return fset, file, wrapped, shape, nil
+		}
+
+		if err == nil {
+			continue
+		}
+
+		if err = parseError(err, shape.prefix()); errorOffset(err) > furthest {
+			furthest, furthestErr = errorOffset(err), err
+		}
+	}
+
+	return nil, nil, "", shapeDecls, furthestErr
+}
+
+// errorOffset returns the offset of the first problem that err, a failure
+// of the parser, lists, or 0 if it lists none.
+func errorOffset(err error) int {
+	var list scanner.ErrorList
+	if !errors.As(err, &list) || len(list) == 0 {
+		return 0
+	}
+
+	return list[0].Pos.Offset
+}
+
+// hasPackageClause reports whether the first token of sourceCode after any
+// comments is the keyword package. Only the start of sourceCode is
+// tokenized, so the check costs next to nothing next to the parse that
+// follows. Input without any
+// code, such as what is left of a snippet of statements once its comments
+// are removed, has none and is parsed as an empty list of declarations.
+func hasPackageClause(sourceCode string) bool {
+	return probeTokens(sourceCode, func(scan *tokenScanner) (bool, bool) {
+		_, tok, _ := scan.Scan()
+
+		return tok == token.PACKAGE, tok != token.EOF
+	})
+}
+
+// unwrapStatements returns the statements in printed, the printed function
+// that a snippet of statements was parsed in, without its package clause.
+// They are taken out of the function body, without the blank lines that
+// the printer keeps after its opening and before its closing brace, and
+// dedented by the level of indentation that cfg selects. Lines inside raw
+// string literals are left alone, since they are part of the program's
+// data.
+func unwrapStatements(printed string, cfg config) string {
+	scan := newTokenScanner(printed, 0)
+	open, closing := -1, -1
+
+	for {
+		pos, tok, _ := scan.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		switch {
+		case tok == token.LBRACE && open < 0:
+			open = scan.file.Offset(pos)
+		case tok == token.RBRACE:
+			closing = scan.file.Offset(pos)
+		}
+	}
+
+	scan.release()
+
+	if open < 0 || closing < open {
+		return printed
+	}
+
+	unit := "\t"
+	if cfg.indent == IndentSpaces {
+		unit = strings.Repeat(" ", cfg.tabWidth)
+	}
+
+	body := strings.Trim(printed[open+1:closing], "\n")
+	if body == "" {
+		return ""
+	}
+
+	return dedentLines(body+"\n", unit)
+}
+
+// unwrapExpression returns the expression in printed, the printed variable
+// declaration that a snippet holding an expression was parsed in, without
+// its package clause.
+func unwrapExpression(printed string) string {
+	return probeTokens(printed, func(scan *tokenScanner) (string, bool) {
+		for {
+			pos, tok, _ := scan.Scan()
+
+			switch tok {
+			case token.EOF:
+				return printed, false
+			case token.ASSIGN:
+				return strings.TrimLeft(printed[scan.file.Offset(pos)+len("="):], " "), true
+			default:
+			}
+		}
+	})
+}
+
+// dedentLines removes unit from the start of each line of src that begins
+// with it, except for lines inside raw string literals.
+func dedentLines(src, unit string) string {
+	return editCodeLines(src, func(line string) string {
+		return strings.TrimPrefix(line, unit)
+	})
+}
+
+// indentLines puts indent ahead of each line of src that is not empty,
+// except for lines inside raw string literals. If unit is set, each tab
+// that a line of src starts with is replaced by unit, so that code the
+// printer indented with tabs is nested as the snippet was.
+func indentLines(src, indent, unit string) string {
+	if indent == "" {
+		return src
+	}
+
+	return editCodeLines(src, func(line string) string {
+		if onlySpace(line) {
+			return line
+		}
+
+		if unit != "" {
+			tabs := len(line) - len(strings.TrimLeft(line, "\t"))
+			line = strings.Repeat(unit, tabs) + line[tabs:]
+		}
+
+		return indent + line
+	})
+}
+
+// indentUnit returns the spaces that src, indented by indent, nests each
+// level of code with: the least that a line goes in beyond indent, or
+// indent itself if no line does. It returns "" if src is indented with
+// tabs, as the printer indents.
+func indentUnit(src, indent string) string {
+	if indent == "" || strings.Contains(indent, "\t") {
+		return ""
+	}
+
+	var unit string
+
+	editCodeLines(src, func(line string) string {
+		lead := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if onlySpace(line) || len(lead) <= len(indent) {
+			return line
+		}
+
+		if step := lead[len(indent):]; unit == "" || len(step) < len(unit) {
+			unit = step
+		}
+
+		return line
+	})
+
+	switch {
+	case unit == "":
+		return indent
+	case strings.Contains(unit, "\t"):
+		return ""
+	}
+
+	return unit
+}
+
+// leadingIndent returns the white space that all lines of src holding more
+// than white space start with, leaving out lines inside raw string
+// literals.
+func leadingIndent(src string) string {
+	var (
+		indent string
+		found  bool
+	)
+
+	editCodeLines(src, func(line string) string {
+		if onlySpace(line) {
+			return line
+		}
+
+		lead := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+
+		if !found {
+			indent, found = lead, true
+
+			return line
+		}
+
+		for !strings.HasPrefix(lead, indent) {
+			indent = indent[:len(indent)-1]
+		}
+
+		return line
+	})
+
+	return indent
+}
+
+// editCodeLines returns src with each line, including its line break,
+// replaced by what edit returns for it, except for lines that start inside
+// raw string literals, which are part of the program's data.
+func editCodeLines(src string, edit func(line string) string) string {
+	literals := rawStrings(src)
+
+	var out strings.Builder
+
+	out.Grow(len(src))
+
+	next := 0
+
+	for offset := 0; offset < len(src); {
+		for next < len(literals) && literals[next][1] <= offset {
+			next++
+		}
+
+		end := strings.IndexByte(src[offset:], '\n') + 1
+		if end == 0 {
+			end = len(src) - offset
+		}
+
+		line := src[offset : offset+end]
+		if next == len(literals) || literals[next][0] > offset {
+			line = edit(line)
+		}
+
+		out.WriteString(line)
+
+		offset += end
+	}
+
+	return out.String()
+}
@@ -0,0 +1,99 @@
+package commentremover
+
+import (
+	"go/token"
+)
+
+// Segment maps a stretch of an output line back to the input. From
+// Line:Column up to the next Segment on the same line, each byte of the
+// output comes from the byte at the same distance from
+// SourceLine:SourceColumn in the input. Lines and columns count from 1, and
+// columns count bytes, as in Go's own diagnostics.
+type Segment struct {
+	Line         int `json:"line"`         // Line is the line in the output.
+	Column       int `json:"column"`       // Column is the column in the output.
+	SourceLine   int `json:"sourceLine"`   // SourceLine is the line in the input.
+	SourceColumn int `json:"sourceColumn"` // SourceColumn is the column in the input.
+}
+
+// positionedToken is a token along with its position in the source.
+type positionedToken struct {
+	tok  token.Token    // tok is the kind of the token.
+	lit  string         // lit is the literal text of the token, if any.
+	pos  token.Position // pos is the position of the first byte of the token.
+	auto bool           // auto is set for a semicolon inserted at a line end.
+}
+
+// MapPositions returns the segments that map the code in output, the
+// result of removing comments from input, back to input. The tokens of both
+// are matched up in order, so it applies to the output of either engine.
+// Separators that go/printer adds or drops, such as the commas ending a
+// multi-line list, are skipped. A line of the output without a segment
+// holds no start of a token, such as the middle of a raw string literal.
+func MapPositions(input, output string) []Segment {
+	inTokens, outTokens := scanTokens(input), scanTokens(output)
+
+	var segments []Segment
+
+	for i, j := 0, 0; i < len(inTokens) && j < len(outTokens); {
+		in, out := inTokens[i], outTokens[j]
+
+		switch {
+		case in.auto:
+			i++
+		case out.auto:
+			j++
+		case in.tok == out.tok && in.lit == out.lit:
+			segments = extendSegments(segments, out.pos, in.pos)
+			i++
+			j++
+		case out.tok == token.COMMA || out.tok == token.SEMICOLON:
+			j++
+		case in.tok == token.COMMA || in.tok == token.SEMICOLON:
+			i++
+		default:
+			i++
+			j++
+		}
+	}
+
+	return segments
+}
+
+// extendSegments records that the output position out comes from the input
+// position in, starting a new segment unless the last one already implies
+// it.
+func extendSegments(segments []Segment, out, in token.Position) []Segment {
+	if n := len(segments); n > 0 {
+		last := segments[n-1]
+		if last.Line == out.Line && last.SourceLine == in.Line &&
+			out.Column-last.Column == in.Column-last.SourceColumn {
+			return segments
+		}
+	}
+
+	return append(segments, Segment{Line: out.Line, Column: out.Column, SourceLine: in.Line, SourceColumn: in.Column})
+}
+
+// scanTokens returns the tokens of src, without comments. Source that fails
+// to tokenize is scanned as far as possible.
+func scanTokens(src string) []positionedToken {
+	var tokens []positionedToken
+
+	scan := newTokenScanner(src, 0)
+	defer scan.release()
+
+	for {
+		pos, tok, lit := scan.Scan()
+		if tok == token.EOF {
+			return tokens
+		}
+
+		tokens = append(tokens, positionedToken{
+			tok:  tok,
+			lit:  lit,
+			pos:  scan.fset.Position(pos),
+			auto: tok == token.SEMICOLON && lit != ";",
+		})
+	}
+}
@@ -0,0 +1,370 @@
+package commentremover
+
+import (
+	"go/ast"
+	"go/token"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Style selects the formatting rules by which EngineAST prints code.
+type Style int
+
+const (
+	// StyleGofmt prints code as gofmt does.
+	StyleGofmt Style = iota
+
+	// StyleGofumpt prints code as gofmt does and then applies the stricter
+	// rules of gofumpt listed at WithStyle.
+	StyleGofumpt
+)
+
+// String returns the lower-case name of the style.
+func (s Style) String() string {
+	switch s {
+	case StyleGofmt:
+		return "gofmt"
+	case StyleGofumpt:
+		return "gofumpt"
+	default:
+		return "unknown"
+	}
+}
+
+// commentDirective matches the text after the slashes of a // comment that
+// gofumpt treats as a directive, which is written without a space, such as
+// "go:noinline", "line", or "nolint". Lines of a cgo preamble starting with
+// "#" are left alone as well.
+var commentDirective = regexp.MustCompile(
+	`^([a-z-]+:[a-z]+|line\b|export\b|extern\b|sys(nb)?\b|no(inspection|lint)\b|#)`,
+)
+
+// styleEdit replaces the bytes start through end of a source with text.
+type styleEdit struct {
+	start, end int
+	text       string
+}
+
+// gofumptRules collects the edits that bring gofmt-formatted source in line
+// with gofumpt.
+type gofumptRules struct {
+	src      string              // src is the source being edited.
+	file     *token.File         // file holds the positions of src.
+	comments []*ast.CommentGroup // comments are the comments of src.
+	edits    []styleEdit         // edits are the edits collected so far.
+}
+
+// applyGofumpt applies the gofumpt rules to src, code printed as gofmt
+// does, and prints the edited code again as cfg selects.
+func applyGofumpt(src string, cfg config) (string, error) {
+	fset := token.NewFileSet()
+
+	file, err := parseSourceCode(fset, src)
+	if err != nil {
+		return "", parseError(err, "")
+	}
+
+	rules := &gofumptRules{src: src, file: fset.File(file.Pos()), comments: file.Comments}
+	rules.collect(file)
+
+	if len(rules.edits) == 0 {
+		return src, nil
+	}
+
+	fset = token.NewFileSet()
+
+	file, err = parseSourceCode(fset, rules.apply())
+	if err != nil {
+		return "", parseError(err, "")
+	}
+
+	return formatAST(file, fset, cfg)
+}
+
+// collect records the edits for file.
+func (rules *gofumptRules) collect(file *ast.File) {
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.FuncDecl:
+			if node.Body != nil {
+				rules.trimBlankLines(node.Body.Lbrace, node.Body.Rbrace)
+			}
+		case *ast.FuncLit:
+			rules.trimBlankLines(node.Body.Lbrace, node.Body.Rbrace)
+		case *ast.BlockStmt:
+			if len(node.List) == 1 {
+				rules.trimBlankLines(node.Lbrace, node.Rbrace)
+			}
+
+			rules.joinErrorChecks(node.List)
+		case *ast.CaseClause:
+			rules.joinErrorChecks(node.Body)
+		case *ast.CommClause:
+			rules.joinErrorChecks(node.Body)
+		case *ast.CompositeLit:
+			rules.trimBlankLines(node.Lbrace, node.Rbrace)
+		case *ast.FieldList:
+			if node.Opening.IsValid() && node.Closing.IsValid() {
+				rules.trimBlankLines(node.Opening, node.Closing)
+			}
+		case *ast.GenDecl:
+			rules.ungroupVar(node)
+			rules.groupStdImports(node)
+		case *ast.BasicLit:
+			rules.octalPrefix(node)
+		}
+
+		return true
+	})
+
+	for _, group := range rules.comments {
+		for _, c := range group.List {
+			rules.commentSpace(c)
+		}
+	}
+}
+
+// apply returns src with the collected edits applied. An edit overlapping
+// an earlier one is dropped.
+func (rules *gofumptRules) apply() string {
+	slices.SortStableFunc(rules.edits, func(a, b styleEdit) int { return a.start - b.start })
+
+	var out strings.Builder
+
+	last := 0
+
+	for _, edit := range rules.edits {
+		if edit.start < last {
+			continue
+		}
+
+		out.WriteString(rules.src[last:edit.start])
+		out.WriteString(edit.text)
+		last = edit.end
+	}
+
+	out.WriteString(rules.src[last:])
+
+	return out.String()
+}
+
+// trimBlankLines deletes the empty lines directly after the line of the
+// opening brace or parenthesis at opening and directly before the line of
+// the closing one at closing, provided each ends or starts its line.
+func (rules *gofumptRules) trimBlankLines(opening, closing token.Pos) {
+	open, closeOffset := rules.file.Offset(opening), rules.file.Offset(closing)
+
+	if end := strings.IndexByte(rules.src[open:], '\n'); end >= 0 && onlySpace(rules.src[open+1:open+end]) {
+		start := open + end + 1
+		rules.deleteBlankLines(start, blankLinesEnd(rules.src, start, closeOffset))
+	}
+
+	start := strings.LastIndexByte(rules.src[:closeOffset], '\n') + 1
+	if start > open && onlySpace(rules.src[start:closeOffset]) {
+		rules.deleteBlankLines(blankLinesStart(rules.src, open, start), start)
+	}
+}
+
+// joinErrorChecks deletes the empty lines between an assignment to err and
+// a simple check of err that follows it in list.
+func (rules *gofumptRules) joinErrorChecks(list []ast.Stmt) {
+	for i := 1; i < len(list); i++ {
+		assign, ok := list[i-1].(*ast.AssignStmt)
+		if !ok || !assignsErr(assign) || !isErrCheck(list[i]) {
+			continue
+		}
+
+		prev := strings.IndexByte(rules.src[rules.file.Offset(assign.End()):], '\n')
+		if prev < 0 {
+			continue
+		}
+
+		start := rules.file.Offset(assign.End()) + prev + 1
+		end := strings.LastIndexByte(rules.src[:rules.file.Offset(list[i].Pos())], '\n') + 1
+
+		if start < end && onlySpace(rules.src[start:end]) {
+			rules.deleteBlankLines(start, end)
+		}
+	}
+}
+
+// ungroupVar drops the parentheses around a var declaration of a single
+// spec, unless a comment is among them.
+func (rules *gofumptRules) ungroupVar(decl *ast.GenDecl) {
+	if decl.Tok != token.VAR || !decl.Lparen.IsValid() || len(decl.Specs) != 1 ||
+		rules.hasComment(decl.Lparen, decl.Rparen) {
+		return
+	}
+
+	spec := decl.Specs[0]
+
+	rules.edits = append(rules.edits,
+		styleEdit{start: rules.file.Offset(decl.Lparen), end: rules.file.Offset(spec.Pos())},
+		styleEdit{start: rules.file.Offset(spec.End()), end: rules.file.Offset(decl.Rparen) + 1},
+	)
+}
+
+// groupStdImports moves the standard library imports of a parenthesized
+// import declaration into a group of their own at the top, unless a comment
+// is among them or the declaration imports "C".
+func (rules *gofumptRules) groupStdImports(decl *ast.GenDecl) {
+	if decl.Tok != token.IMPORT || !decl.Lparen.IsValid() || rules.hasComment(decl.Lparen, decl.Rparen) {
+		return
+	}
+
+	var (
+		std    []string   // std are the standard library imports.
+		groups [][]string // groups are the other imports, in their groups.
+		sorted = true     // sorted is unset once the first group mixes in other imports or std imports follow it.
+	)
+
+	for i, spec := range decl.Specs {
+		text := rules.src[rules.file.Offset(spec.Pos()):rules.file.Offset(spec.End())]
+
+		if i == 0 || rules.file.Line(spec.Pos()) > rules.file.Line(decl.Specs[i-1].End())+1 {
+			groups = append(groups, nil)
+		}
+
+		path, _ := strconv.Unquote(spec.(*ast.ImportSpec).Path.Value)
+
+		switch {
+		case path == "C":
+			return
+		case isStdImport(path):
+			std = append(std, text)
+			sorted = sorted && len(groups) == 1
+		default:
+			groups[len(groups)-1] = append(groups[len(groups)-1], text)
+			sorted = sorted && len(groups) > 1
+		}
+	}
+
+	if len(std) == 0 || sorted || len(std) == len(decl.Specs) {
+		return
+	}
+
+	blocks := []string{strings.Join(std, "\n")}
+
+	for _, group := range groups {
+		if len(group) > 0 {
+			blocks = append(blocks, strings.Join(group, "\n"))
+		}
+	}
+
+	rules.edits = append(rules.edits, styleEdit{
+		start: rules.file.Offset(decl.Lparen),
+		end:   rules.file.Offset(decl.Rparen) + 1,
+		text:  "(\n" + strings.Join(blocks, "\n\n") + "\n)",
+	})
+}
+
+// octalPrefix writes an octal integer literal such as 0755 as 0o755.
+func (rules *gofumptRules) octalPrefix(lit *ast.BasicLit) {
+	if lit.Kind != token.INT || len(lit.Value) < 2 || lit.Value[0] != '0' || lit.Value[1] < '0' || lit.Value[1] > '9' {
+		return
+	}
+
+	offset := rules.file.Offset(lit.Pos()) + 1
+	rules.edits = append(rules.edits, styleEdit{start: offset, end: offset, text: "o"})
+}
+
+// commentSpace inserts a space after the slashes of a // comment that is
+// not a directive.
+func (rules *gofumptRules) commentSpace(c *ast.Comment) {
+	text, ok := strings.CutPrefix(c.Text, "//")
+	if !ok || text == "" || text[0] == ' ' || text[0] == '\t' || commentDirective.MatchString(text) {
+		return
+	}
+
+	offset := rules.file.Offset(c.Pos()) + len("//")
+	rules.edits = append(rules.edits, styleEdit{start: offset, end: offset, text: " "})
+}
+
+// hasComment reports whether a comment lies between from and to.
+func (rules *gofumptRules) hasComment(from, to token.Pos) bool {
+	return slices.ContainsFunc(rules.comments, func(group *ast.CommentGroup) bool {
+		return group.Pos() > from && group.End() < to
+	})
+}
+
+// deleteBlankLines records the deletion of the empty lines in
+// src[start:end], if there are any.
+func (rules *gofumptRules) deleteBlankLines(start, end int) {
+	if start < end {
+		rules.edits = append(rules.edits, styleEdit{start: start, end: end})
+	}
+}
+
+// blankLinesEnd returns the offset of the first line, starting at the
+// line at start and not reaching limit, that is not empty.
+func blankLinesEnd(src string, start, limit int) int {
+	for start < limit {
+		end := strings.IndexByte(src[start:limit], '\n')
+		if end < 0 || !onlySpace(src[start:start+end]) {
+			break
+		}
+
+		start += end + 1
+	}
+
+	return start
+}
+
+// blankLinesStart returns the offset of the first of the empty lines that
+// directly precede the line at end, staying after the offset limit.
+func blankLinesStart(src string, limit, end int) int {
+	for end > limit+1 {
+		start := strings.LastIndexByte(src[limit+1:end-1], '\n') + limit + 2
+		if !onlySpace(src[start : end-1]) {
+			break
+		}
+
+		end = start
+	}
+
+	return end
+}
+
+// onlySpace reports whether text holds nothing but white space.
+func onlySpace(text string) bool {
+	return strings.TrimSpace(text) == ""
+}
+
+// isStdImport reports whether path names a package of the standard
+// library, whose first path element, unlike that of a module, holds no
+// dot.
+func isStdImport(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+
+	return !strings.Contains(first, ".")
+}
+
+// assignsErr reports whether assign assigns to a variable named err.
+func assignsErr(assign *ast.AssignStmt) bool {
+	return slices.ContainsFunc(assign.Lhs, func(expr ast.Expr) bool {
+		ident, ok := expr.(*ast.Ident)
+
+		return ok && ident.Name == "err"
+	})
+}
+
+// isErrCheck reports whether stmt is a simple error check: an if statement
+// without an init statement whose condition is err != nil.
+func isErrCheck(stmt ast.Stmt) bool {
+	ifStmt, ok := stmt.(*ast.IfStmt)
+	if !ok || ifStmt.Init != nil {
+		return false
+	}
+
+	cond, ok := ifStmt.Cond.(*ast.BinaryExpr)
+	if !ok || cond.Op != token.NEQ {
+		return false
+	}
+
+	x, xOK := cond.X.(*ast.Ident)
+	y, yOK := cond.Y.(*ast.Ident)
+
+	return xOK && yOK && x.Name == "err" && y.Name == "nil"
+}
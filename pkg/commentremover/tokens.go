@@ -0,0 +1,158 @@
+package commentremover
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Sizes of the pieces that EstimateTokens takes a token to cover.
+const (
+	lettersPerToken     = 8 // lettersPerToken is the length of a word part that still fits in one token.
+	digitsPerToken      = 3 // digitsPerToken is the most digits that the encodings put in one token.
+	punctuationPerToken = 2 // punctuationPerToken is the length of a run of punctuation, like ") {", per token.
+	blanksPerToken      = 4 // blanksPerToken is the number of spaces or tabs of indentation per token.
+)
+
+// EstimateTokens returns an estimate of the number of tokens that the
+// byte-pair encodings of large language models, such as cl100k_base and
+// o200k_base, split src into, for telling how much of a context window
+// removing comments saves without shipping a vocabulary. Like those
+// encodings, it splits src into runs of letters, digits, punctuation, and
+// blanks, with a blank joining the piece after it and line breaks the
+// punctuation before them. A run of letters is split further at case
+// changes, as identifiers such as removeCommentsFromAST are, and each part
+// counts one token per lettersPerToken letters; most other runs count one
+// token per few characters. Characters beyond ASCII count one token each.
+// The estimate is meant for comparing an input with its output, not for
+// billing.
+func EstimateTokens(src string) int {
+	tokens := 0
+	afterPunctuation := false
+
+	for i := 0; i < len(src); {
+		char, size := utf8.DecodeRuneInString(src[i:])
+
+		switch {
+		case isWhitespace(src[i]):
+			end := i
+			for end < len(src) && isWhitespace(src[end]) {
+				end++
+			}
+
+			tokens += blankTokens(src[i:end], afterPunctuation, end < len(src))
+			i, afterPunctuation = end, false
+		case unicode.IsLetter(char):
+			end := i
+			for end < len(src) {
+				next, nextSize := utf8.DecodeRuneInString(src[end:])
+				if !unicode.IsLetter(next) {
+					break
+				}
+
+				end += nextSize
+			}
+
+			tokens += wordTokens(src[i:end])
+			i, afterPunctuation = end, false
+		case char < utf8.RuneSelf && unicode.IsDigit(char):
+			end := i
+			for end < len(src) && src[end] >= '0' && src[end] <= '9' {
+				end++
+			}
+
+			tokens += ceilDiv(end-i, digitsPerToken)
+			i, afterPunctuation = end, false
+		case char >= utf8.RuneSelf:
+			tokens++
+			i += size
+		default:
+			end := i
+			for end < len(src) && isPunctuation(src[end]) {
+				end++
+			}
+
+			tokens += ceilDiv(end-i, punctuationPerToken)
+			i, afterPunctuation = end, true
+		}
+	}
+
+	return tokens
+}
+
+// blankTokens returns the tokens of blanks, a run of spaces, tabs, and line
+// breaks. The line breaks of the run count one token, or none if they
+// directly follow punctuation, which they join. The blanks after the last
+// line break count one token per blanksPerToken, except for the last one,
+// which joins the piece after it unless the run ends the source.
+func blankTokens(blanks string, afterPunctuation, more bool) int {
+	tokens, indent := 0, blanks
+
+	for i := len(blanks) - 1; i >= 0; i-- {
+		if isLineBreak(blanks[i]) {
+			if !afterPunctuation || !isLineBreak(blanks[0]) {
+				tokens++
+			}
+
+			indent = blanks[i+1:]
+
+			break
+		}
+	}
+
+	if more && len(indent) > 0 {
+		indent = indent[1:]
+	}
+
+	return tokens + ceilDiv(len(indent), blanksPerToken)
+}
+
+// wordTokens returns the tokens of word, a run of letters, counting the
+// parts that it splits into at changes of case: before an upper-case letter
+// that follows a lower-case one, and before the last of a run of
+// upper-case letters that a lower-case one follows, as in ASTContext.
+func wordTokens(word string) int {
+	tokens, partLength := 0, 0
+
+	var previous rune
+
+	for offset, char := range word {
+		if char >= utf8.RuneSelf {
+			tokens += ceilDiv(partLength, lettersPerToken) + 1
+			partLength, previous = 0, char
+
+			continue
+		}
+
+		next, _ := utf8.DecodeRuneInString(word[offset+1:])
+		if unicode.IsUpper(char) && (unicode.IsLower(previous) || unicode.IsUpper(previous) && unicode.IsLower(next)) {
+			tokens += ceilDiv(partLength, lettersPerToken)
+			partLength = 0
+		}
+
+		partLength++
+		previous = char
+	}
+
+	return tokens + ceilDiv(partLength, lettersPerToken)
+}
+
+// isWhitespace reports whether b is a space, a tab, or a line break.
+func isWhitespace(b byte) bool {
+	return b == ' ' || b == '\t' || isLineBreak(b)
+}
+
+// isLineBreak reports whether b ends or begins a line break.
+func isLineBreak(b byte) bool {
+	return b == '\n' || b == '\r'
+}
+
+// isPunctuation reports whether b is an ASCII character other than a
+// letter, digit, or whitespace.
+func isPunctuation(b byte) bool {
+	return b < utf8.RuneSelf && !isWhitespace(b) && !unicode.IsLetter(rune(b)) && !unicode.IsDigit(rune(b))
+}
+
+// ceilDiv returns n divided by size, rounded up.
+func ceilDiv(n, size int) int {
+	return (n + size - 1) / size
+}
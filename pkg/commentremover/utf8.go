@@ -0,0 +1,55 @@
+package commentremover
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxReportedUTF8Errors caps the number of positions listed in an
+// ErrInvalidUTF8 error.
+const maxReportedUTF8Errors = 10
+
+// ErrInvalidUTF8 is returned, wrapped with the offending positions, when
+// StrictUTF8 is enabled and the source contains invalid UTF-8.
+var ErrInvalidUTF8 = errors.New("invalid UTF-8")
+
+// checkUTF8 returns an error listing the line:column position of each
+// invalid UTF-8 sequence in src, or nil if src is valid. Columns count
+// bytes, as in Go's own diagnostics.
+func checkUTF8(src string) error {
+	if utf8.ValidString(src) {
+		return nil
+	}
+
+	var positions []string
+
+	line, column, invalid := 1, 1, 0
+
+	for offset := 0; offset < len(src); {
+		r, size := utf8.DecodeRuneInString(src[offset:])
+
+		if r == utf8.RuneError && size == 1 {
+			invalid++
+			if invalid <= maxReportedUTF8Errors {
+				positions = append(positions, strconv.Itoa(line)+":"+strconv.Itoa(column))
+			}
+		}
+
+		if src[offset] == '\n' {
+			line, column = line+1, 1
+		} else {
+			column += size
+		}
+
+		offset += size
+	}
+
+	if invalid > maxReportedUTF8Errors {
+		positions = append(positions, fmt.Sprintf("and %d more", invalid-maxReportedUTF8Errors))
+	}
+
+	return fmt.Errorf("%w: %s", ErrInvalidUTF8, strings.Join(positions, ", "))
+}
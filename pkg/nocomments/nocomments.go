@@ -0,0 +1,135 @@
+// Package nocomments provides an analysis.Analyzer that reports the
+// comments nogocomments would remove, each with a suggested fix removing
+// it, so the same rules can run under go vet and in tools built on
+// golang.org/x/tools/go/analysis, such as gopls and multichecker binaries.
+// Its flags mirror those of the nogocomments command of the same names.
+package nocomments
+
+import (
+	"bytes"
+	"go/ast"
+	"go/token"
+
+	"github.com/pierow2k/nogocomments/pkg/commentremover"
+	"golang.org/x/tools/go/analysis"
+)
+
+// doc is the documentation of Analyzer.
+const doc = `report comments that nogocomments removes
+
+The nocomments analyzer reports every comment that running nogocomments
+over the file would remove, with a suggested fix that removes it. Comments
+alone on their lines are removed with their lines. Directives, license
+headers, and SPDX identifiers are kept, as by the command.`
+
+// Analyzer reports the comments that nogocomments removes.
+var Analyzer = newAnalyzer()
+
+// settings holds the flags of an Analyzer.
+type settings struct {
+	keepDoc            bool // keepDoc keeps doc comments of exported declarations.
+	keepPackageDoc     bool // keepPackageDoc keeps package doc comments.
+	keepNolint         bool // keepNolint keeps //nolint comments.
+	keepTodos          bool // keepTodos keeps TODO, FIXME, and HACK comments.
+	stripDirectives    bool // stripDirectives reports directives as well.
+	stripLicenseHeader bool // stripLicenseHeader reports leading license headers as well.
+}
+
+// newAnalyzer returns the nocomments analyzer with its flags registered.
+func newAnalyzer() *analysis.Analyzer {
+	flags := &settings{}
+
+	analyzer := &analysis.Analyzer{
+		Name: "nocomments",
+		Doc:  doc,
+		URL:  "https://github.com/pierow2k/nogocomments",
+		Run:  flags.run,
+	}
+
+	analyzer.Flags.BoolVar(&flags.keepDoc, "keep-doc", false, "keep doc comments of exported declarations")
+	analyzer.Flags.BoolVar(&flags.keepPackageDoc, "keep-package-doc", false, "keep package doc comments")
+	analyzer.Flags.BoolVar(&flags.keepNolint, "keep-nolint", false, "keep //nolint lint-suppression comments")
+	analyzer.Flags.BoolVar(&flags.keepTodos, "keep-todos", false, "keep TODO, FIXME, and HACK comments")
+	analyzer.Flags.BoolVar(&flags.stripDirectives, "strip-directives", false, "report directives as well")
+	analyzer.Flags.BoolVar(&flags.stripLicenseHeader, "strip-license-header", false,
+		"report leading copyright and license headers as well")
+
+	return analyzer
+}
+
+// options returns the commentremover options that the flags select.
+func (flags *settings) options() []commentremover.Option {
+	return []commentremover.Option{
+		commentremover.KeepDoc(flags.keepDoc),
+		commentremover.KeepPackageDoc(flags.keepPackageDoc),
+		commentremover.KeepNolint(flags.keepNolint),
+		commentremover.KeepTodos(flags.keepTodos),
+		commentremover.KeepDirectives(!flags.stripDirectives),
+		commentremover.KeepLicenseHeader(!flags.stripLicenseHeader),
+	}
+}
+
+// run reports the removable comments of the files of pass.
+func (flags *settings) run(pass *analysis.Pass) (any, error) {
+	opts := flags.options()
+
+	for _, file := range pass.Files {
+		tokenFile := pass.Fset.File(file.Pos())
+
+		var src []byte
+		if pass.ReadFile != nil {
+			src, _ = pass.ReadFile(tokenFile.Name())
+		}
+
+		for _, c := range commentremover.RemovableComments(pass.Fset, file, opts...) {
+			pos, end := removalRange(tokenFile, src, c)
+
+			pass.Report(analysis.Diagnostic{
+				Pos:     c.Pos(),
+				End:     c.End(),
+				Message: "comment can be removed",
+				SuggestedFixes: []analysis.SuggestedFix{{
+					Message:   "Remove comment",
+					TextEdits: []analysis.TextEdit{{Pos: pos, End: end}},
+				}},
+			})
+		}
+	}
+
+	return nil, nil //nolint:nilnil // The analyzer has no result.
+}
+
+// removalRange returns the range that the fix for c deletes from src, the
+// content of file: the whole lines if c is alone on its lines, c with the
+// blanks separating it from the code it shares a line with otherwise, and
+// c itself if src is not at hand.
+func removalRange(file *token.File, src []byte, c *ast.Comment) (token.Pos, token.Pos) {
+	start, end := file.Offset(c.Pos()), file.Offset(c.End())
+	if len(src) != file.Size() {
+		return c.Pos(), c.End()
+	}
+
+	lineStart := bytes.LastIndexByte(src[:start], '\n') + 1
+
+	lineEnd := bytes.IndexByte(src[end:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(src)
+	} else {
+		lineEnd += end + 1
+	}
+
+	codeBefore := len(bytes.TrimSpace(src[lineStart:start])) > 0
+	codeAfter := len(bytes.TrimSpace(src[end:lineEnd])) > 0
+
+	switch {
+	case !codeBefore && !codeAfter:
+		return file.Pos(lineStart), file.Pos(lineEnd)
+	case !codeAfter:
+		start = lineStart + len(bytes.TrimRight(src[lineStart:start], " \t"))
+	case !codeBefore:
+		end = lineEnd - len(bytes.TrimLeft(src[end:lineEnd], " \t"))
+	default:
+	}
+
+	return file.Pos(start), file.Pos(end)
+}
@@ -0,0 +1,93 @@
+package nocomments_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"testing"
+
+	"github.com/pierow2k/nogocomments/pkg/nocomments"
+	"golang.org/x/tools/go/analysis"
+)
+
+// TestAnalyzer verifies that the analyzer reports the comments that
+// nogocomments removes, and that applying its fixes removes them.
+func TestAnalyzer(t *testing.T) {
+	t.Parallel()
+
+	const src = `//go:build linux
+
+package p
+
+// Size is documented.
+const Size = 1 // trailing
+
+/* block */ var x = Size
+
+func f() {
+	// alone
+	_ = x
+}
+`
+
+	const want = `//go:build linux
+
+package p
+
+const Size = 1
+
+var x = Size
+
+func f() {
+	_ = x
+}
+`
+
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var diagnostics []analysis.Diagnostic
+
+	pass := &analysis.Pass{
+		Analyzer: nocomments.Analyzer,
+		Fset:     fset,
+		Files:    []*ast.File{file},
+		ReadFile: func(string) ([]byte, error) { return []byte(src), nil },
+		Report:   func(d analysis.Diagnostic) { diagnostics = append(diagnostics, d) },
+	}
+
+	if _, err := nocomments.Analyzer.Run(pass); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(diagnostics) != 4 {
+		t.Fatalf("got %d diagnostics, want 4", len(diagnostics))
+	}
+
+	if got := applyFixes(fset.File(file.Pos()), src, diagnostics); got != want {
+		t.Errorf("fixed source = %q, want %q", got, want)
+	}
+}
+
+// applyFixes returns src with the first suggested fix of each of
+// diagnostics applied.
+func applyFixes(file *token.File, src string, diagnostics []analysis.Diagnostic) string {
+	var edits []analysis.TextEdit
+
+	for _, d := range diagnostics {
+		edits = append(edits, d.SuggestedFixes[0].TextEdits...)
+	}
+
+	slices.SortFunc(edits, func(a, b analysis.TextEdit) int { return int(b.Pos - a.Pos) })
+
+	for _, edit := range edits {
+		src = src[:file.Offset(edit.Pos)] + string(edit.NewText) + src[file.Offset(edit.End):]
+	}
+
+	return src
+}
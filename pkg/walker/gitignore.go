@@ -0,0 +1,126 @@
+package walker
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreRule is one pattern line of a .gitignore file.
+type ignoreRule struct {
+	pattern string // pattern is matched against paths relative to the .gitignore file.
+	negate  bool   // negate re-includes matching paths.
+	dirOnly bool   // dirOnly restricts the rule to directories.
+}
+
+// parseGitignore returns the rules of a .gitignore file. Blank lines and
+// comments are skipped, and lines may be of any length. Patterns without a
+// slash other than a trailing one match at any depth, as in git.
+func parseGitignore(data []byte) []ignoreRule {
+	var rules []ignoreRule
+
+	for text := range bytes.Lines(data) {
+		line := strings.TrimRight(string(text), " \t\r\n")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+
+		if cut, ok := strings.CutPrefix(line, "!"); ok {
+			rule.negate, line = true, cut
+		}
+
+		line = strings.TrimPrefix(line, `\`)
+
+		if cut, ok := strings.CutSuffix(line, "/"); ok {
+			rule.dirOnly, line = true, cut
+		}
+
+		if cut, ok := strings.CutPrefix(line, "/"); ok {
+			line = cut
+		} else if !strings.Contains(line, "/") {
+			line = "**/" + line
+		}
+
+		if _, err := Match(line, ""); err != nil || line == "" {
+			continue
+		}
+
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
+
+// ignoreSet holds the .gitignore rules found during a walk, keyed by the
+// slash-separated directory holding them, relative to the walk root.
+type ignoreSet map[string][]ignoreRule
+
+// load reads the .gitignore file of the directory at rel, if any.
+func (set ignoreSet) load(root, rel string) {
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(rel), ".gitignore"))
+	if err != nil {
+		return
+	}
+
+	if rules := parseGitignore(data); len(rules) > 0 {
+		set[rel] = rules
+	}
+}
+
+// ignored reports whether the path rel, relative to the walk root, is
+// ignored by the rules of its ancestor directories. Rules of deeper
+// directories take precedence, and within a file the last matching rule
+// wins.
+func (set ignoreSet) ignored(rel string, isDir bool) bool {
+	ignored := false
+
+	for dir := path.Dir(rel); ; dir = path.Dir(dir) {
+		if dir == "." {
+			dir = ""
+		}
+
+		if matched, ok := matchRules(set[dir], relativeTo(dir, rel), isDir); ok {
+			ignored = matched
+
+			break
+		}
+
+		if dir == "" {
+			break
+		}
+	}
+
+	return ignored
+}
+
+// matchRules applies rules to name. It returns whether name is ignored and
+// whether any rule matched at all.
+func matchRules(rules []ignoreRule, name string, isDir bool) (bool, bool) {
+	for i := len(rules) - 1; i >= 0; i-- {
+		rule := rules[i]
+		if rule.dirOnly && !isDir {
+			continue
+		}
+
+		if ok, _ := Match(rule.pattern, name); ok {
+			return !rule.negate, true
+		}
+	}
+
+	return false, false
+}
+
+// relativeTo returns the slash-separated path rel relative to dir, an
+// ancestor directory of it.
+func relativeTo(dir, rel string) string {
+	if dir == "" {
+		return rel
+	}
+
+	return strings.TrimPrefix(rel, dir+"/")
+}
@@ -0,0 +1,51 @@
+package walker
+
+import (
+	"path"
+	"strings"
+)
+
+// Match reports whether the slash-separated path name matches pattern. A
+// "**" segment matches any number of path segments, including none; other
+// segments use path.Match syntax, so "*" does not match "/". The whole
+// pattern is validated, and path.ErrBadPattern is returned if any segment
+// is malformed.
+func Match(pattern, name string) (bool, error) {
+	segments := strings.Split(pattern, "/")
+
+	for _, segment := range segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return false, err //nolint:wrapcheck // Mirrors path.Match.
+		}
+	}
+
+	return matchSegments(segments, strings.Split(name, "/")), nil
+}
+
+// matchSegments matches the segments of a path against those of a valid
+// pattern.
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := range len(name) + 1 {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
@@ -0,0 +1,283 @@
+// Package walker selects the Go source files to process under a set of
+// files and directories. It implements the file-selection rules of the
+// nogocomments command, so other tools can select exactly the same files:
+// directory walking, include and exclude globs, .gitignore files, build
+// constraints, and detection of generated files.
+package walker
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"iter"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// defaultInclude selects the files considered when no Include option is
+// given.
+const defaultInclude = "**/*.go"
+
+// config holds the settings assembled from the Options passed to Walk.
+type config struct {
+	include       []string       // include lists the globs a file must match one of.
+	exclude       []string       // exclude lists the globs of files and directories to skip.
+	gitignore     bool           // gitignore skips paths ignored by .gitignore files.
+	skipGenerated bool           // skipGenerated skips files marked as generated.
+	buildContext  *build.Context // buildContext, if set, skips files that it does not build.
+}
+
+// Option configures which files Walk selects.
+type Option func(*config)
+
+// newConfig returns the default configuration with opts applied in order.
+func newConfig(opts []Option) config {
+	cfg := config{
+		gitignore:     true,
+		skipGenerated: true,
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if len(cfg.include) == 0 {
+		cfg.include = []string{defaultInclude}
+	}
+
+	return cfg
+}
+
+// Include restricts the files selected in directories to those matching
+// at least one of globs; see Match for the syntax. Globs are matched
+// against the slash-separated path relative to the directory being
+// walked. The default is "**/*.go". Repeated options accumulate.
+func Include(globs ...string) Option {
+	return func(cfg *config) {
+		cfg.include = append(cfg.include, globs...)
+	}
+}
+
+// Exclude skips files and directories matching any of globs, matched like
+// the globs of Include. A matching directory is skipped with everything
+// below it. Repeated options accumulate.
+func Exclude(globs ...string) Option {
+	return func(cfg *config) {
+		cfg.exclude = append(cfg.exclude, globs...)
+	}
+}
+
+// RespectGitignore controls whether paths ignored by .gitignore files in
+// the walked directories are skipped. It is on by default.
+func RespectGitignore(respect bool) Option {
+	return func(cfg *config) {
+		cfg.gitignore = respect
+	}
+}
+
+// SkipGenerated controls whether files marked as generated are skipped;
+// see IsGenerated. It is on by default, since generated files are
+// regenerated rather than edited.
+func SkipGenerated(skip bool) Option {
+	return func(cfg *config) {
+		cfg.skipGenerated = skip
+	}
+}
+
+// BuildContext restricts the files selected in directories to those that
+// ctxt builds, as ctxt.MatchFile tells from their names and contents, so
+// that the selection agrees with the go command for the GOOS, GOARCH, and
+// build tags of ctxt: a file whose name ends in another GOOS or GOARCH, as
+// in x_windows.go, or whose //go:build line ctxt does not satisfy, or that
+// imports "C" without cgo, is skipped. Test files are selected like others.
+// A copy of build.Default follows the environment. With nil, the default,
+// build constraints are not looked at.
+func BuildContext(ctxt *build.Context) Option {
+	return func(cfg *config) {
+		cfg.buildContext = ctxt
+	}
+}
+
+// Walk returns an iterator over the Go source files selected by roots.
+// Roots naming a file are yielded as given, without applying any filter.
+// Roots naming a directory are walked in lexical order, skipping
+// directories that the go command ignores in "./..." patterns: those whose
+// names begin with "." or "_", testdata, and vendor. The remaining files
+// are filtered by the options.
+//
+// Errors are yielded along with the path they concern, and the walk
+// continues with the next entry. A malformed glob is yielded once, with an
+// empty path, before anything else is walked.
+func Walk(roots []string, opts ...Option) iter.Seq2[string, error] {
+	cfg := newConfig(opts)
+
+	return func(yield func(string, error) bool) {
+		for _, glob := range slices.Concat(cfg.include, cfg.exclude) {
+			if _, err := Match(glob, ""); err != nil {
+				yield("", fmt.Errorf("%w: %q", err, glob))
+
+				return
+			}
+		}
+
+		for _, root := range roots {
+			if !walkRoot(root, cfg, yield) {
+				return
+			}
+		}
+	}
+}
+
+// walkRoot yields the files selected by a single root. It returns false if
+// yield asked to stop.
+func walkRoot(root string, cfg config, yield func(string, error) bool) bool {
+	ignores := ignoreSet{}
+	stopped := false
+
+	err := filepath.WalkDir(root, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return emit(name, err, yield, &stopped)
+		}
+
+		if name == root {
+			if entry.IsDir() {
+				if cfg.gitignore {
+					ignores.load(root, "")
+				}
+
+				return nil
+			}
+
+			return emit(name, nil, yield, &stopped)
+		}
+
+		rel, _ := filepath.Rel(root, name)
+		rel = filepath.ToSlash(rel)
+
+		if entry.IsDir() {
+			if skipDir(entry.Name()) || cfg.skips(rel, true, ignores) {
+				return filepath.SkipDir
+			}
+
+			if cfg.gitignore {
+				ignores.load(root, rel)
+			}
+
+			return nil
+		}
+
+		if !entry.Type().IsRegular() || cfg.skips(rel, false, ignores) || !cfg.included(rel) {
+			return nil
+		}
+
+		if cfg.buildContext != nil {
+			built, err := builds(cfg.buildContext, name)
+			if err != nil {
+				return emit(name, err, yield, &stopped)
+			}
+
+			if !built {
+				return nil
+			}
+		}
+
+		if cfg.skipGenerated {
+			generated, err := IsGenerated(name)
+			if err != nil {
+				return emit(name, err, yield, &stopped)
+			}
+
+			if generated {
+				return nil
+			}
+		}
+
+		return emit(name, nil, yield, &stopped)
+	})
+	if err != nil && !stopped {
+		return yield(root, err)
+	}
+
+	return !stopped
+}
+
+// emit yields name with err, which is nil for a selected file, and records
+// whether the consumer asked to stop.
+func emit(name string, err error, yield func(string, error) bool, stopped *bool) error {
+	if !yield(name, err) {
+		*stopped = true
+
+		return filepath.SkipAll
+	}
+
+	return nil
+}
+
+// skipDir reports whether the go command ignores directories called name.
+func skipDir(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+		name == "testdata" || name == "vendor"
+}
+
+// skips reports whether the path rel, relative to the walk root, is
+// excluded or ignored by a .gitignore file.
+func (cfg config) skips(rel string, isDir bool, ignores ignoreSet) bool {
+	for _, glob := range cfg.exclude {
+		if ok, _ := Match(glob, rel); ok {
+			return true
+		}
+	}
+
+	return cfg.gitignore && ignores.ignored(rel, isDir)
+}
+
+// included reports whether the file at rel matches an include glob.
+func (cfg config) included(rel string) bool {
+	for _, glob := range cfg.include {
+		if ok, _ := Match(glob, rel); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// builds reports whether ctxt builds the Go source file at path, as
+// described at BuildContext. MatchFile leaves files that import "C" to the
+// import of a package, which skips them without cgo, so that is done here.
+func builds(ctxt *build.Context, path string) (bool, error) {
+	matched, err := ctxt.MatchFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil || !matched || ctxt.CgoEnabled {
+		return matched, err //nolint:wrapcheck // The error of go/build names the file.
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.ImportsOnly)
+	if err != nil && file == nil {
+		return false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	for _, spec := range file.Imports {
+		if spec.Path.Value == `"C"` {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// IsGenerated reports whether the Go source file at path is marked as
+// generated by a "// Code generated ... DO NOT EDIT." comment before its
+// package clause, following the convention described in the go generate
+// documentation.
+func IsGenerated(path string) (bool, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil && file == nil {
+		return false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return ast.IsGenerated(file), nil
+}
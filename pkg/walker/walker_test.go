@@ -0,0 +1,223 @@
+package walker_test
+
+import (
+	"go/build"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/pierow2k/nogocomments/pkg/walker"
+)
+
+// TestMatch verifies the glob syntax shared by Include, Exclude, and
+// .gitignore patterns.
+func TestMatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "api/**", name: "api/v1/x.go", want: true},
+		{pattern: "api/**", name: "api", want: true},
+		{pattern: "**/*.go", name: "x.go", want: true},
+		{pattern: "**/*.go", name: "a/b/x.go", want: true},
+		{pattern: "*.go", name: "a/x.go", want: false},
+		{pattern: "a/**/b", name: "a/x/y/b", want: true},
+		{pattern: "a/**/b", name: "a/b", want: true},
+		{pattern: "a/**/b", name: "a/x/c", want: false},
+	}
+
+	for _, testCase := range tests {
+		got, err := walker.Match(testCase.pattern, testCase.name)
+		if err != nil || got != testCase.want {
+			t.Errorf("Match(%q, %q) = %v, %v, want %v", testCase.pattern, testCase.name, got, err, testCase.want)
+		}
+	}
+
+	if _, err := walker.Match("a/[", "a/b"); err == nil {
+		t.Error("Match() accepted a malformed pattern")
+	}
+}
+
+// TestWalk verifies which files are selected under a directory tree.
+//
+//nolint:funlen
+func TestWalk(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"main.go":               "package main\n",
+		"main_test.go":          "package main\n",
+		"README.md":             "# readme\n",
+		"api/api.go":            "package api\n",
+		"api/gen.go":            "// Code generated by hand. DO NOT EDIT.\n\npackage api\n",
+		"api/internal/x.go":     "package internal\n",
+		"build/out.go":          "package build\n",
+		"keep/.gitignore":       "*.go\n!keep.go\n",
+		"keep/drop.go":          "package keep\n",
+		"keep/keep.go":          "package keep\n",
+		"testdata/t.go":         "package testdata\n",
+		"vendor/v/v.go":         "package v\n",
+		".hidden/h.go":          "package hidden\n",
+		"_skip/s.go":            "package skip\n",
+		".gitignore":            "/build/\n",
+		"explicit/generated.go": "// Code generated by hand. DO NOT EDIT.\n\npackage explicit\n",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		roots []string
+		opts  []walker.Option
+		want  []string
+	}{
+		{
+			name:  "defaults",
+			roots: []string{root},
+			want:  []string{"api/api.go", "api/internal/x.go", "keep/keep.go", "main.go", "main_test.go"},
+		},
+		{
+			name:  "include and exclude",
+			roots: []string{root},
+			opts:  []walker.Option{walker.Include("api/**"), walker.Exclude("**/internal")},
+			want:  []string{"api/api.go"},
+		},
+		{
+			name:  "gitignore and generated files disabled",
+			roots: []string{root},
+			opts:  []walker.Option{walker.RespectGitignore(false), walker.SkipGenerated(false), walker.Exclude("main*")},
+			want: []string{
+				"api/api.go", "api/gen.go", "api/internal/x.go", "build/out.go",
+				"explicit/generated.go", "keep/drop.go", "keep/keep.go",
+			},
+		},
+		{
+			name:  "explicit files are not filtered",
+			roots: []string{filepath.Join(root, "explicit", "generated.go"), filepath.Join(root, "README.md")},
+			want:  []string{"explicit/generated.go", "README.md"},
+		},
+	}
+
+	for _, testCase := range tests {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []string
+
+			for path, err := range walker.Walk(testCase.roots, testCase.opts...) {
+				if err != nil {
+					t.Fatalf("Walk() error = %v", err)
+				}
+
+				rel, _ := filepath.Rel(root, path)
+				got = append(got, filepath.ToSlash(rel))
+			}
+
+			if !slices.Equal(got, testCase.want) {
+				t.Errorf("Walk() = %q, want %q", got, testCase.want)
+			}
+		})
+	}
+}
+
+// TestWalkBuildContext verifies that BuildContext selects the files that
+// the go command builds for a context.
+func TestWalkBuildContext(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"a.go":         "package p\n",
+		"a_test.go":    "package p\n",
+		"b_linux.go":   "package p\n",
+		"b_windows.go": "package p\n",
+		"c.go":         "//go:build extra\n\npackage p\n",
+		"d.go":         "//go:build !linux\n\npackage p\n",
+		"e.go":         "package p\n\nimport \"C\"\n",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctxt := build.Default
+	ctxt.GOOS, ctxt.GOARCH, ctxt.CgoEnabled = "linux", "amd64", false
+
+	tests := []struct {
+		name string
+		tags []string
+		want []string
+	}{
+		{name: "no tags", want: []string{"a.go", "a_test.go", "b_linux.go"}},
+		{name: "tags", tags: []string{"extra"}, want: []string{"a.go", "a_test.go", "b_linux.go", "c.go"}},
+	}
+
+	for _, testCase := range tests {
+		ctxt := ctxt
+		ctxt.BuildTags = testCase.tags
+
+		var got []string
+
+		for path, err := range walker.Walk([]string{root}, walker.BuildContext(&ctxt)) {
+			if err != nil {
+				t.Fatalf("Walk() error = %v", err)
+			}
+
+			got = append(got, filepath.Base(path))
+		}
+
+		if !slices.Equal(got, testCase.want) {
+			t.Errorf("Walk() with %s = %q, want %q", testCase.name, got, testCase.want)
+		}
+	}
+}
+
+// TestWalkLongGitignoreLine verifies that a .gitignore line longer than a
+// bufio.Scanner token does not end the file, so the rules after it apply.
+func TestWalkLongGitignoreLine(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore": "/" + strings.Repeat("x", 1<<17) + ".go\ndrop.go\n",
+		"drop.go":    "package p\n",
+		"keep.go":    "package p\n",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+
+	for path, err := range walker.Walk([]string{root}) {
+		if err != nil {
+			t.Fatalf("Walk() error = %v", err)
+		}
+
+		got = append(got, filepath.Base(path))
+	}
+
+	if want := []string{"keep.go"}; !slices.Equal(got, want) {
+		t.Errorf("Walk() = %q, want %q", got, want)
+	}
+}